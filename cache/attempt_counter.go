@@ -0,0 +1,87 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Các hàm dưới đây dùng Redis khi có, ngược lại dùng memory store của process hiện tại
+// Dùng cho bộ đếm đăng nhập sai, khóa tạm thời...
+
+// IncrementCounter tăng bộ đếm và trả về giá trị mới
+// TTL chỉ được đặt ở lần tăng đầu tiên nên bộ đếm tự reset sau mỗi window
+func IncrementCounter(key string, window time.Duration) (int64, error) {
+	if RedisClient != nil {
+		pipe := RedisClient.TxPipeline()
+		incr := pipe.Incr(ctx, key)
+		pipe.ExpireNX(ctx, key, window)
+		if _, err := pipe.Exec(ctx); err != nil {
+			return 0, fmt.Errorf("failed to increment counter: %w", err)
+		}
+		return incr.Val(), nil
+	}
+
+	return memoryFallback.incr(key, window), nil
+}
+
+// GetCounter lấy giá trị bộ đếm (0 nếu chưa có)
+func GetCounter(key string) (int64, error) {
+	if RedisClient != nil {
+		value, err := RedisClient.Get(ctx, key).Int64()
+		if err != nil {
+			if err == redis.Nil {
+				return 0, nil
+			}
+			return 0, fmt.Errorf("failed to get counter: %w", err)
+		}
+		return value, nil
+	}
+
+	value, _ := strconv.ParseInt(memoryFallback.get(key), 10, 64)
+	return value, nil
+}
+
+// SetFlag đặt key tồn tại trong khoảng ttl (vd: khóa tài khoản tạm thời)
+func SetFlag(key string, ttl time.Duration) error {
+	if RedisClient != nil {
+		if err := RedisClient.Set(ctx, key, "1", ttl).Err(); err != nil {
+			return fmt.Errorf("failed to set flag: %w", err)
+		}
+		return nil
+	}
+
+	memoryFallback.set(key, "1", ttl)
+	return nil
+}
+
+// GetTTL trả về thời gian sống còn lại của key (0 nếu key không tồn tại)
+func GetTTL(key string) (time.Duration, error) {
+	if RedisClient != nil {
+		ttl, err := RedisClient.PTTL(ctx, key).Result()
+		if err != nil {
+			return 0, fmt.Errorf("failed to get ttl: %w", err)
+		}
+		if ttl < 0 {
+			return 0, nil // -2: không tồn tại, -1: không có TTL (không dùng cho các key ở đây)
+		}
+		return ttl, nil
+	}
+
+	return memoryFallback.ttl(key), nil
+}
+
+// DeleteKeys xóa các key (dùng được cả khi không có Redis)
+func DeleteKeys(keys ...string) error {
+	if RedisClient != nil {
+		if err := RedisClient.Del(ctx, keys...).Err(); err != nil {
+			return fmt.Errorf("failed to delete keys: %w", err)
+		}
+		return nil
+	}
+
+	memoryFallback.delete(keys...)
+	return nil
+}
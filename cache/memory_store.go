@@ -0,0 +1,136 @@
+package cache
+
+import (
+	"strconv"
+	"sync"
+	"time"
+)
+
+// memoryStore là store thay thế khi không có Redis (chỉ có hiệu lực trong process hiện tại)
+type memoryStore struct {
+	mu        sync.Mutex
+	entries   map[string]memoryEntry
+	lastSweep time.Time
+}
+
+type memoryEntry struct {
+	value     string
+	expiresAt time.Time
+}
+
+var memoryFallback = &memoryStore{
+	entries: make(map[string]memoryEntry),
+}
+
+func (m *memoryStore) set(key, value string, ttl time.Duration) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+	m.sweep(now)
+}
+
+func (m *memoryStore) get(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key, time.Now())
+	if !ok {
+		return ""
+	}
+	return entry.value
+}
+
+// incr tăng bộ đếm, TTL chỉ được đặt khi key được tạo mới (giống INCR + EXPIRE của Redis)
+func (m *memoryStore) incr(key string, ttl time.Duration) int64 {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := m.lookup(key, now)
+	if !ok {
+		entry = memoryEntry{value: "0", expiresAt: now.Add(ttl)}
+	}
+	count, _ := strconv.ParseInt(entry.value, 10, 64)
+	count++
+	entry.value = strconv.FormatInt(count, 10)
+	m.entries[key] = entry
+	m.sweep(now)
+	return count
+}
+
+// ttl trả về thời gian sống còn lại của key (0 nếu không tồn tại)
+func (m *memoryStore) ttl(key string) time.Duration {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	entry, ok := m.lookup(key, now)
+	if !ok {
+		return 0
+	}
+	return entry.expiresAt.Sub(now)
+}
+
+// setNX đặt key nếu chưa tồn tại, trả về true nếu key được tạo mới (giống SET NX của Redis)
+func (m *memoryStore) setNX(key, value string, ttl time.Duration) bool {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	now := time.Now()
+	if _, ok := m.lookup(key, now); ok {
+		return false
+	}
+	m.entries[key] = memoryEntry{value: value, expiresAt: now.Add(ttl)}
+	m.sweep(now)
+	return true
+}
+
+// take lấy giá trị và xóa key trong cùng một thao tác (giống GETDEL của Redis)
+func (m *memoryStore) take(key string) string {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	entry, ok := m.lookup(key, time.Now())
+	if !ok {
+		return ""
+	}
+	delete(m.entries, key)
+	return entry.value
+}
+
+func (m *memoryStore) delete(keys ...string) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	for _, key := range keys {
+		delete(m.entries, key)
+	}
+}
+
+// lookup phải được gọi khi đang giữ m.mu
+func (m *memoryStore) lookup(key string, now time.Time) (memoryEntry, bool) {
+	entry, ok := m.entries[key]
+	if !ok {
+		return memoryEntry{}, false
+	}
+	if now.After(entry.expiresAt) {
+		delete(m.entries, key)
+		return memoryEntry{}, false
+	}
+	return entry, true
+}
+
+// sweep dọn các entry đã hết hạn định kỳ để map không phình to (phải được gọi khi đang giữ m.mu)
+func (m *memoryStore) sweep(now time.Time) {
+	if now.Sub(m.lastSweep) <= time.Minute {
+		return
+	}
+	for k, entry := range m.entries {
+		if now.After(entry.expiresAt) {
+			delete(m.entries, k)
+		}
+	}
+	m.lastSweep = now
+}
@@ -0,0 +1,110 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Search analytics keys patterns (sorted set, member = từ khóa đã chuẩn hóa, score = số lượt)
+// - search:stats:<source>:count:<yyyymmddhh> → số lượt tìm kiếm trong giờ đó
+// - search:stats:<source>:zero:<yyyymmddhh>  → số lượt tìm kiếm không có kết quả trong giờ đó
+// - search:searchers:<source>:<yyyymmdd>:<term> → HyperLogLog người tìm (hash IP/user) của từ khóa trong ngày
+// - search:seen:<key>                          → một người tìm đã được đếm cho từ khóa trong giờ hiện tại
+const (
+	SearchStatsPrefix     = "search:stats:"
+	SearchSearchersPrefix = "search:searchers:"
+	SearchSeenPrefix      = "search:seen:"
+	SearchStatsRetention  = 8 * 24 * time.Hour // Giữ đủ cho window dài nhất (7 ngày) cộng giờ hiện tại
+
+	// SearchStatsMaxTermsPerHour số từ khóa tối đa giữ trong mỗi sorted set theo giờ (bỏ các từ khóa ít lượt nhất)
+	SearchStatsMaxTermsPerHour = 5000
+)
+
+// SearchStatsKey tạo key sorted set của một giờ (hour theo UTC)
+func SearchStatsKey(source, kind string, hour time.Time) string {
+	return fmt.Sprintf("%s%s:%s:%s", SearchStatsPrefix, source, kind, hour.UTC().Format("2006010215"))
+}
+
+// SearchSearchersKey tạo key HyperLogLog người tìm của từ khóa trong một ngày (day theo UTC)
+func SearchSearchersKey(source, term string, day time.Time) string {
+	return fmt.Sprintf("%s%s:%s:%s", SearchSearchersPrefix, source, day.UTC().Format("20060102"), term)
+}
+
+// MarkSearchSeen đánh dấu key trong khoảng ttl, trả về true nếu đây là lần đầu (SET NX)
+// Dùng được cả khi không có Redis
+func MarkSearchSeen(key string, ttl time.Duration) (bool, error) {
+	if RedisClient != nil {
+		created, err := RedisClient.SetNX(ctx, SearchSeenPrefix+key, "1", ttl).Result()
+		if err != nil {
+			return false, fmt.Errorf("failed to mark search seen: %w", err)
+		}
+		return created, nil
+	}
+
+	return memoryFallback.setNX(SearchSeenPrefix+key, "1", ttl), nil
+}
+
+// IncrementSearchTerm tăng điểm của từ khóa trong các sorted set, cắt bớt từ khóa ít lượt nhất và gia hạn retention (cần Redis)
+// Người tìm chỉ được thêm vào searchersKey nếu từ khóa còn nằm trong sorted set đầu tiên sau khi cắt,
+// để từ khóa rác không tạo thêm key HyperLogLog
+func IncrementSearchTerm(term, searchersKey, searcher string, keys ...string) error {
+	pipe := RedisClient.TxPipeline()
+	for _, key := range keys {
+		pipe.ZIncrBy(ctx, key, 1, term)
+		pipe.ZRemRangeByRank(ctx, key, 0, -SearchStatsMaxTermsPerHour-1)
+		pipe.Expire(ctx, key, SearchStatsRetention)
+	}
+	kept := pipe.ZScore(ctx, keys[0], term)
+	if _, err := pipe.Exec(ctx); err != nil && err != redis.Nil {
+		return fmt.Errorf("failed to increment search term: %w", err)
+	}
+	if searcher == "" || kept.Err() != nil {
+		return nil
+	}
+
+	pipe = RedisClient.TxPipeline()
+	pipe.PFAdd(ctx, searchersKey, searcher)
+	pipe.Expire(ctx, searchersKey, SearchStatsRetention)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return fmt.Errorf("failed to add search term searcher: %w", err)
+	}
+	return nil
+}
+
+// CountSearchTermSearchers đếm số người tìm khác nhau của mỗi từ khóa trên các key HyperLogLog tương ứng (cần Redis)
+func CountSearchTermSearchers(keysByTerm map[string][]string) (map[string]int64, error) {
+	pipe := RedisClient.Pipeline()
+	counts := make(map[string]*redis.IntCmd, len(keysByTerm))
+	for term, keys := range keysByTerm {
+		counts[term] = pipe.PFCount(ctx, keys...)
+	}
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to count search term searchers: %w", err)
+	}
+
+	result := make(map[string]int64, len(counts))
+	for term, count := range counts {
+		result[term] = count.Val()
+	}
+	return result, nil
+}
+
+// TopSearchTerms cộng dồn các sorted set theo giờ và trả về limit từ khóa có điểm cao nhất (cần Redis)
+func TopSearchTerms(keys []string, limit int) ([]redis.Z, error) {
+	if len(keys) == 0 || limit <= 0 {
+		return []redis.Z{}, nil
+	}
+
+	// Gộp vào key tạm rồi xóa luôn trong cùng transaction
+	dest := fmt.Sprintf("%stmp:%s", SearchStatsPrefix, keys[0])
+	pipe := RedisClient.TxPipeline()
+	pipe.ZUnionStore(ctx, dest, &redis.ZStore{Keys: keys})
+	top := pipe.ZRevRangeWithScores(ctx, dest, 0, int64(limit-1))
+	pipe.Del(ctx, dest)
+	if _, err := pipe.Exec(ctx); err != nil {
+		return nil, fmt.Errorf("failed to get top search terms: %w", err)
+	}
+	return top.Val(), nil
+}
@@ -0,0 +1,107 @@
+package cache
+
+import (
+	"fmt"
+	"strconv"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// Denylist keys patterns
+// - denylist:jti:<jti>          → một access token cụ thể (logout)
+// - denylist:session:<id>       → mọi access token của một phiên đăng nhập (thu hồi thiết bị)
+// - denylist:user:<id>          → mọi access token của user cấp trước thời điểm lưu, tính bằng mili giây (vô hiệu hóa, đăng xuất mọi nơi)
+const (
+	DenylistTokenPrefix   = "denylist:jti:"
+	DenylistSessionPrefix = "denylist:session:"
+	DenylistUserPrefix    = "denylist:user:"
+)
+
+// DenyToken đưa access token (theo jti) vào denylist cho tới khi token hết hạn
+func DenyToken(jti string, expiresAt time.Time) error {
+	if jti == "" {
+		return nil
+	}
+	return denylistSet(DenylistTokenPrefix+jti, "1", time.Until(expiresAt))
+}
+
+// DenySession chặn mọi access token của phiên đăng nhập trong khoảng ttl (thời hạn của access token)
+func DenySession(sessionID uint, ttl time.Duration) error {
+	if sessionID == 0 {
+		return nil
+	}
+	return denylistSet(fmt.Sprintf("%s%d", DenylistSessionPrefix, sessionID), "1", ttl)
+}
+
+// DenyUserTokensIssuedBefore chặn mọi access token của user được cấp trước hoặc tại thời điểm at (độ chính xác mili giây)
+// Token cấp lại ngay sau đó trong cùng một giây (đăng nhập lại, đổi mật khẩu) vẫn hợp lệ
+func DenyUserTokensIssuedBefore(userID uint, at time.Time, ttl time.Duration) error {
+	return denylistSet(fmt.Sprintf("%s%d", DenylistUserPrefix, userID), strconv.FormatInt(at.UnixMilli(), 10), ttl)
+}
+
+// IsTokenDenied kiểm tra access token có nằm trong denylist không (theo jti, phiên hoặc user)
+func IsTokenDenied(jti string, sessionID, userID uint, issuedAt time.Time) (bool, error) {
+	keys := []string{
+		DenylistTokenPrefix + jti,
+		fmt.Sprintf("%s%d", DenylistSessionPrefix, sessionID),
+		fmt.Sprintf("%s%d", DenylistUserPrefix, userID),
+	}
+
+	values, err := denylistGet(keys)
+	if err != nil {
+		return false, err
+	}
+
+	if jti != "" && values[0] != "" {
+		return true, nil
+	}
+	if sessionID != 0 && values[1] != "" {
+		return true, nil
+	}
+	if values[2] != "" {
+		cutoff, err := strconv.ParseInt(values[2], 10, 64)
+		if err == nil && issuedAt.UnixMilli() <= cutoff {
+			return true, nil
+		}
+	}
+	return false, nil
+}
+
+func denylistSet(key, value string, ttl time.Duration) error {
+	if ttl <= 0 {
+		return nil // Token đã hết hạn, không cần chặn
+	}
+
+	if RedisClient != nil {
+		if err := RedisClient.Set(ctx, key, value, ttl).Err(); err != nil {
+			return fmt.Errorf("failed to set denylist: %w", err)
+		}
+		return nil
+	}
+
+	memoryFallback.set(key, value, ttl)
+	return nil
+}
+
+func denylistGet(keys []string) ([]string, error) {
+	values := make([]string, len(keys))
+
+	if RedisClient != nil {
+		results, err := RedisClient.MGet(ctx, keys...).Result()
+		if err != nil && err != redis.Nil {
+			return nil, fmt.Errorf("failed to get denylist: %w", err)
+		}
+		for i, result := range results {
+			if str, ok := result.(string); ok {
+				values[i] = str
+			}
+		}
+		return values, nil
+	}
+
+	for i, key := range keys {
+		values[i] = memoryFallback.get(key)
+	}
+	return values, nil
+}
@@ -0,0 +1,41 @@
+package cache
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/redis/go-redis/v9"
+)
+
+// WebSocket ticket key pattern
+// - ws:ticket:<ticket> → access token đã đổi lấy ticket để mở WebSocket (dùng một lần, sống vài chục giây)
+const WSTicketPrefix = "ws:ticket:"
+
+// StoreWSTicket lưu ticket kèm dữ liệu của access token trong khoảng ttl
+func StoreWSTicket(ticket, value string, ttl time.Duration) error {
+	if RedisClient != nil {
+		if err := RedisClient.Set(ctx, WSTicketPrefix+ticket, value, ttl).Err(); err != nil {
+			return fmt.Errorf("failed to store ws ticket: %w", err)
+		}
+		return nil
+	}
+
+	memoryFallback.set(WSTicketPrefix+ticket, value, ttl)
+	return nil
+}
+
+// ConsumeWSTicket lấy và xóa ticket trong cùng một thao tác để ticket chỉ dùng được một lần ("" nếu không tồn tại hoặc đã hết hạn)
+func ConsumeWSTicket(ticket string) (string, error) {
+	if RedisClient != nil {
+		value, err := RedisClient.GetDel(ctx, WSTicketPrefix+ticket).Result()
+		if err != nil {
+			if err == redis.Nil {
+				return "", nil
+			}
+			return "", fmt.Errorf("failed to consume ws ticket: %w", err)
+		}
+		return value, nil
+	}
+
+	return memoryFallback.take(WSTicketPrefix + ticket), nil
+}
@@ -21,6 +21,12 @@ type Config struct {
 	RedisPort     string
 	RedisPassword string
 	RedisDB       int
+
+	// Environment là môi trường chạy (APP_ENV): development, production...
+	Environment string
+
+	// AdminRequireTwoFactor bắt buộc mọi admin bật xác thực 2 lớp (TOTP) khi đăng nhập
+	AdminRequireTwoFactor bool
 }
 
 var AppConfig *Config
@@ -60,11 +66,20 @@ func LoadConfig() error {
 		RedisPort:     getEnv("REDIS_PORT", "6379"),
 		RedisPassword: getEnv("REDIS_PASSWORD", ""),
 		RedisDB:       getEnvAsInt("REDIS_DB", 0),
+
+		Environment: getEnv("APP_ENV", "development"),
+
+		AdminRequireTwoFactor: getEnvAsBool("ADMIN_REQUIRE_2FA", false),
 	}
 
 	return nil
 }
 
+// IsProduction kiểm tra server có đang chạy ở production không (APP_ENV=production hoặc GIN_MODE=release)
+func (c *Config) IsProduction() bool {
+	return c.Environment == "production" || os.Getenv("GIN_MODE") == "release"
+}
+
 func getEnv(key, defaultValue string) string {
 	value := os.Getenv(key)
 	if value == "" {
@@ -85,6 +100,18 @@ func getEnvAsInt(key string, defaultValue int) int {
 	return intValue
 }
 
+func getEnvAsBool(key string, defaultValue bool) bool {
+	value := os.Getenv(key)
+	if value == "" {
+		return defaultValue
+	}
+	boolValue, err := strconv.ParseBool(value)
+	if err != nil {
+		return defaultValue
+	}
+	return boolValue
+}
+
 func GetDSN() string {
 	return fmt.Sprintf("host=%s port=%s user=%s password=%s dbname=%s sslmode=disable TimeZone=Asia/Ho_Chi_Minh",
 		AppConfig.DBHost,
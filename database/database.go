@@ -42,12 +42,6 @@ func ConnectDB() error {
 
 	log.Println("✅ Database connected successfully!")
 
-	// Drop chat tables (sẽ được xử lý bởi Chat Service riêng - MongoDB)
-	if err := DropChatTables(); err != nil {
-		log.Printf("⚠️  Warning: Failed to drop chat tables: %v", err)
-		log.Println("   Bạn có thể xóa thủ công bằng SQL nếu cần")
-	}
-
 	// Auto migrate all models
 	if err := AutoMigrate(); err != nil {
 		return fmt.Errorf("failed to auto migrate: %w", err)
@@ -56,24 +50,6 @@ func ConnectDB() error {
 	return nil
 }
 
-// DropChatTables xóa các bảng chat (sẽ được xử lý bởi Chat Service riêng - MongoDB)
-func DropChatTables() error {
-	log.Println("🗑️  Dropping chat tables (chats, chat_messages)...")
-
-	// Drop chat_messages trước (vì có foreign key)
-	if err := DB.Exec("DROP TABLE IF EXISTS chat_messages CASCADE").Error; err != nil {
-		return fmt.Errorf("failed to drop chat_messages table: %w", err)
-	}
-
-	// Drop chats
-	if err := DB.Exec("DROP TABLE IF EXISTS chats CASCADE").Error; err != nil {
-		return fmt.Errorf("failed to drop chats table: %w", err)
-	}
-
-	log.Println("✅ Chat tables dropped successfully!")
-	return nil
-}
-
 // AutoMigrate tự động tạo/update các bảng trong database
 func AutoMigrate() error {
 	log.Println("🔄 Running database migrations...")
@@ -87,12 +63,21 @@ func AutoMigrate() error {
 		&models.Address{},
 		&models.Order{},
 		&models.OrderItem{},
+		&models.OrderStatusHistory{},
 		&models.Review{},
 		&models.Payment{},
 		&models.Wishlist{},
-		// Chat và ChatMessage sẽ được xử lý bởi Chat Service riêng (MongoDB)
-		// &models.Chat{},
-		// &models.ChatMessage{},
+		&models.Chat{},
+		&models.ChatMessage{},
+		&models.UserSession{},
+		&models.UserOtp{},
+		&models.UserIdentity{},
+		&models.UserTwoFactor{},
+		&models.UserRecoveryCode{},
+		&models.Permission{},
+		&models.Role{},
+		&models.SearchQueryStat{},
+		&models.SearchQuerySearcher{},
 	)
 
 	// Tạo unique indexes với filter soft-deleted records
@@ -127,12 +112,42 @@ func AutoMigrate() error {
 			log.Printf("❌ Error: Failed to create unique index idx_category_children_child_id_unique: %v", indexErr)
 			return fmt.Errorf("failed to create unique index for category_children: %w", indexErr)
 		}
+
+		// Tạo unique index để đảm bảo mỗi user chỉ có một địa chỉ mặc định
+		if indexErr := DB.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_addresses_user_default_unique 
+			ON addresses(user_id) 
+			WHERE is_default = true AND deleted_at IS NULL
+		`).Error; indexErr != nil {
+			log.Printf("❌ Error: Failed to create unique index idx_addresses_user_default_unique: %v", indexErr)
+			return fmt.Errorf("failed to create unique index for default address: %w", indexErr)
+		}
+
+		// Tạo unique index để mỗi user chỉ đánh giá một sản phẩm một lần
+		if indexErr := DB.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_reviews_user_product_unique 
+			ON reviews(user_id, product_id) 
+			WHERE deleted_at IS NULL
+		`).Error; indexErr != nil {
+			log.Printf("❌ Error: Failed to create unique index idx_reviews_user_product_unique: %v", indexErr)
+			return fmt.Errorf("failed to create unique index for reviews: %w", indexErr)
+		}
 	}
 
 	if err != nil {
 		return fmt.Errorf("auto migrate failed: %w", err)
 	}
 
+	// Tìm kiếm sản phẩm/danh mục không phân biệt dấu (unaccent + tsvector/trigram index)
+	if err := SetupFullTextSearch(); err != nil {
+		return fmt.Errorf("failed to set up full-text search: %w", err)
+	}
+
+	// Tạo danh sách quyền và các role mặc định (admin, customer, nhân viên...)
+	if err := SeedRolesAndPermissions(); err != nil {
+		return fmt.Errorf("failed to seed roles: %w", err)
+	}
+
 	log.Println("✅ Database migrations completed successfully!")
 	return nil
 }
@@ -0,0 +1,65 @@
+package database
+
+import (
+	"fmt"
+
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// SeedRolesAndPermissions đồng bộ danh sách quyền và tạo các role mặc định
+//   - Quyền mới trong models.PermissionCatalog được thêm vào bảng permissions
+//   - Role mặc định chỉ được tạo khi chưa có (không ghi đè phân quyền admin đã chỉnh)
+//   - Role admin luôn có toàn bộ quyền
+func SeedRolesAndPermissions() error {
+	return DB.Transaction(func(tx *gorm.DB) error {
+		for _, permission := range models.PermissionCatalog {
+			p := permission
+			if err := tx.Clauses(clause.OnConflict{
+				Columns:   []clause.Column{{Name: "code"}},
+				DoUpdates: clause.AssignmentColumns([]string{"description", "updated_at"}),
+			}).Create(&p).Error; err != nil {
+				return fmt.Errorf("failed to seed permission %s: %w", permission.Code, err)
+			}
+		}
+
+		var allPermissions []models.Permission
+		if err := tx.Find(&allPermissions).Error; err != nil {
+			return err
+		}
+		byCode := make(map[string]models.Permission, len(allPermissions))
+		for _, p := range allPermissions {
+			byCode[p.Code] = p
+		}
+
+		for _, def := range models.DefaultRoles {
+			role := models.Role{
+				Name:        def.Name,
+				DisplayName: def.DisplayName,
+				IsSystem:    def.Name == models.RoleAdmin || def.Name == models.RoleCustomer,
+			}
+			result := tx.Where("name = ?", def.Name).Attrs(role).FirstOrCreate(&role)
+			if result.Error != nil {
+				return fmt.Errorf("failed to seed role %s: %w", def.Name, result.Error)
+			}
+
+			var permissions []models.Permission
+			switch {
+			case def.Name == models.RoleAdmin:
+				permissions = allPermissions
+			case result.RowsAffected > 0:
+				for _, code := range def.Permissions {
+					permissions = append(permissions, byCode[code])
+				}
+			default:
+				continue
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return fmt.Errorf("failed to seed permissions for role %s: %w", def.Name, err)
+			}
+		}
+		return nil
+	})
+}
@@ -0,0 +1,48 @@
+package database
+
+import (
+	"fmt"
+	"log"
+)
+
+// SearchDocumentSQL biểu thức tsvector (đã bỏ dấu) dùng để tìm kiếm full-text trên bảng có các cột
+// name, name_en, description, description_en. Tên được đánh trọng số A, mô tả trọng số C.
+// Query phải dùng đúng biểu thức này thì Postgres mới dùng được GIN index tạo trong SetupFullTextSearch.
+const SearchDocumentSQL = `(` +
+	`setweight(to_tsvector('simple', f_unaccent(coalesce(name, ''))), 'A') || ` +
+	`setweight(to_tsvector('simple', f_unaccent(coalesce(name_en, ''))), 'A') || ` +
+	`setweight(to_tsvector('simple', f_unaccent(coalesce(description, ''))), 'C') || ` +
+	`setweight(to_tsvector('simple', f_unaccent(coalesce(description_en, ''))), 'C'))`
+
+// searchTables các bảng được tìm kiếm full-text
+var searchTables = []string{"products", "categories"}
+
+// SetupFullTextSearch cài extension unaccent/pg_trgm và tạo index tìm kiếm không phân biệt dấu
+// cho products và categories ("dien thoai" khớp "điện thoại")
+func SetupFullTextSearch() error {
+	statements := []string{
+		`CREATE EXTENSION IF NOT EXISTS unaccent`,
+		`CREATE EXTENSION IF NOT EXISTS pg_trgm`,
+		// unaccent() chỉ là STABLE nên không dùng được trong index, bọc lại thành hàm IMMUTABLE
+		`CREATE OR REPLACE FUNCTION f_unaccent(text) RETURNS text
+			LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+			AS $$ SELECT public.unaccent('public.unaccent'::regdictionary, $1) $$`,
+	}
+
+	for _, table := range searchTables {
+		statements = append(statements,
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_search_document ON %s USING GIN (%s)`, table, table, SearchDocumentSQL),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_name_trgm ON %s USING GIN (f_unaccent(lower(name)) gin_trgm_ops)`, table, table),
+			fmt.Sprintf(`CREATE INDEX IF NOT EXISTS idx_%s_name_en_trgm ON %s USING GIN (f_unaccent(lower(name_en)) gin_trgm_ops)`, table, table),
+		)
+	}
+
+	for _, statement := range statements {
+		if err := DB.Exec(statement).Error; err != nil {
+			log.Printf("❌ Error: Failed to set up full-text search: %v", err)
+			return err
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,38 @@
+package dto
+
+// CreateAddressRequest - Request để thêm địa chỉ vào sổ địa chỉ
+type CreateAddressRequest struct {
+	FullName  string  `json:"fullName" binding:"required"`
+	Phone     string  `json:"phone" binding:"required"`
+	Address   string  `json:"address" binding:"required"` // Địa chỉ chi tiết
+	Ward      *string `json:"ward"`
+	District  *string `json:"district"`
+	City      *string `json:"city"`
+	IsDefault *bool   `json:"isDefault"`
+}
+
+// UpdateAddressRequest - Request để cập nhật địa chỉ (partial update)
+type UpdateAddressRequest struct {
+	FullName  *string `json:"fullName"`
+	Phone     *string `json:"phone"`
+	Address   *string `json:"address"`
+	Ward      *string `json:"ward"`
+	District  *string `json:"district"`
+	City      *string `json:"city"`
+	IsDefault *bool   `json:"isDefault"`
+}
+
+// AddressResponse - Response cho một địa chỉ
+type AddressResponse struct {
+	ID        uint    `json:"id"`
+	FullName  string  `json:"fullName"`
+	Phone     string  `json:"phone"`
+	Address   string  `json:"address"`
+	Ward      *string `json:"ward"`
+	District  *string `json:"district"`
+	City      *string `json:"city"`
+	IsDefault bool    `json:"isDefault"`
+	UserID    uint    `json:"userId"`
+	CreatedAt string  `json:"createdAt"`
+	UpdatedAt string  `json:"updatedAt"`
+}
@@ -30,12 +30,14 @@ type UpdateCategoryFullRequest struct {
 }
 
 type SearchCategoryRequest struct {
-	Name      *string     `json:"name"`     // Search (partial match), không phải filter exact
-	IsActive  interface{} `json:"isActive"` // *bool hoặc []bool - true = active, false = inactive, nil = all, [true, false] = all
-	SortBy    *string     `json:"sortBy" binding:"omitempty,oneof=id name createdAt updatedAt"`
-	SortOrder *string     `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
-	Page      *int        `json:"page" binding:"omitempty,min=1"`
-	Limit     *int        `json:"limit" binding:"omitempty,min=1,max=1000"`
+	Name         *string     `json:"name"`     // Search (partial match), không phải filter exact
+	IsActive     interface{} `json:"isActive"` // *bool hoặc []bool - true = active, false = inactive, nil = all, [true, false] = all
+	SortBy       *string     `json:"sortBy" binding:"omitempty,oneof=id name createdAt updatedAt relevance"`
+	SortOrder    *string     `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
+	Page         *int        `json:"page" binding:"omitempty,min=1"`
+	Limit        *int        `json:"limit" binding:"omitempty,min=1,max=1000"`
+	Cursor       *string     `json:"cursor"`       // Phân trang theo cursor: "" = trang đầu, các trang sau gửi nextCursor của response (bỏ qua page)
+	IncludeTotal *bool       `json:"includeTotal"` // Đếm total/totalPages (mặc định true khi phân trang theo page, false khi dùng cursor)
 }
 
 type CategoryResponse struct {
@@ -72,7 +74,7 @@ type SearchCategoryChildRequest struct {
 	ParentID  *uint       `json:"parentId"` // Filter theo parent ID (exact match)
 	Name      *string     `json:"name"`     // Search (partial match), không phải filter exact
 	IsActive  interface{} `json:"isActive"` // *bool hoặc []bool - true = active, false = inactive, nil = all, [true, false] = all
-	SortBy    *string     `json:"sortBy" binding:"omitempty,oneof=id name createdAt updatedAt"`
+	SortBy    *string     `json:"sortBy" binding:"omitempty,oneof=id name createdAt updatedAt relevance"`
 	SortOrder *string     `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
 	Page      *int        `json:"page" binding:"omitempty,min=1"`
 	Limit     *int        `json:"limit" binding:"omitempty,min=1,max=1000"`
@@ -82,10 +84,10 @@ type SearchCategoryChildResponse struct {
 	Success    bool               `json:"success"`
 	Message    string             `json:"message"`
 	Data       []CategoryResponse `json:"data"`
-	Total      int64              `json:"total"`
+	Total      *int64             `json:"total"`
 	Page       int                `json:"page"`
 	Limit      int                `json:"limit"`
-	TotalPages int                `json:"totalPages"`
+	TotalPages *int               `json:"totalPages"`
 }
 
 type CreateCategoryResponse struct {
@@ -107,20 +109,22 @@ type ListCategoryResponse struct {
 
 type CategoryPaginationResponse struct {
 	Data       []CategoryResponse `json:"data"`
-	Total      int64              `json:"total"`
-	Page       int                `json:"page"`
+	Total      *int64             `json:"total,omitempty"` // nil khi không đếm (includeTotal = false)
+	Page       int                `json:"page,omitempty"`  // 0 khi phân trang theo cursor
 	Limit      int                `json:"limit"`
-	TotalPages int                `json:"totalPages"`
+	TotalPages *int               `json:"totalPages,omitempty"`
+	NextCursor *string            `json:"nextCursor,omitempty"` // nil khi đã hết dữ liệu
 }
 
 type SearchCategoryResponse struct {
 	Success    bool               `json:"success"`
 	Message    string             `json:"message"`
 	Data       []CategoryResponse `json:"data"`
-	Total      int64              `json:"total"`
-	Page       int                `json:"page"`
+	Total      *int64             `json:"total,omitempty"` // nil khi không đếm (includeTotal = false)
+	Page       int                `json:"page,omitempty"`  // 0 khi phân trang theo cursor
 	Limit      int                `json:"limit"`
-	TotalPages int                `json:"totalPages"`
+	TotalPages *int               `json:"totalPages,omitempty"`
+	NextCursor *string            `json:"nextCursor,omitempty"` // nil khi đã hết dữ liệu
 }
 
 type DeleteCategoryResponse struct {
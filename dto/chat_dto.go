@@ -0,0 +1,91 @@
+package dto
+
+// OpenChatRequest - Request để khách hàng mở cuộc trò chuyện hỗ trợ
+type OpenChatRequest struct {
+	Subject string  `json:"subject" binding:"required,max=255"`
+	Message *string `json:"message"` // Tin nhắn đầu tiên (không bắt buộc)
+}
+
+// AssignChatRequest - Request để phân công admin phụ trách cuộc trò chuyện
+type AssignChatRequest struct {
+	AdminID *uint `json:"adminId"` // Không truyền = tự nhận chat
+}
+
+// SendChatMessageRequest - Request để gửi tin nhắn qua REST
+type SendChatMessageRequest struct {
+	Content string `json:"content" binding:"required,max=5000"`
+	Type    string `json:"type" binding:"omitempty,oneof=text image file"`
+}
+
+// ChatUser - Thông tin rút gọn của người tham gia chat
+type ChatUser struct {
+	ID     uint    `json:"id"`
+	Name   string  `json:"name"`
+	Email  string  `json:"email"`
+	Avatar *string `json:"avatar"`
+}
+
+// ChatMessageResponse - Response cho một tin nhắn
+type ChatMessageResponse struct {
+	ID         uint   `json:"id"`
+	ChatID     uint   `json:"chatId"`
+	SenderID   uint   `json:"senderId"`
+	SenderName string `json:"senderName,omitempty"`
+	Content    string `json:"content"`
+	Type       string `json:"type"`
+	IsRead     bool   `json:"isRead"`
+	CreatedAt  string `json:"createdAt"`
+}
+
+// ChatResponse - Response cho một cuộc trò chuyện
+type ChatResponse struct {
+	ID          uint                 `json:"id"`
+	Subject     string               `json:"subject"`
+	Status      string               `json:"status"`
+	CustomerID  uint                 `json:"customerId"`
+	Customer    *ChatUser            `json:"customer,omitempty"`
+	AdminID     *uint                `json:"adminId"`
+	Admin       *ChatUser            `json:"admin,omitempty"`
+	LastMessage *ChatMessageResponse `json:"lastMessage,omitempty"`
+	UnreadCount int64                `json:"unreadCount"` // Số tin nhắn chưa đọc của người gọi
+	CreatedAt   string               `json:"createdAt"`
+	UpdatedAt   string               `json:"updatedAt"`
+}
+
+type ChatPaginationResponse struct {
+	Data       []ChatResponse `json:"data"`
+	Total      int64          `json:"total"`
+	Page       int            `json:"page"`
+	Limit      int            `json:"limit"`
+	TotalPages int            `json:"totalPages"`
+}
+
+// ChatReadReceipt - Thông báo các tin nhắn đã được đọc
+type ChatReadReceipt struct {
+	ReaderID   uint   `json:"readerId"`
+	MessageIDs []uint `json:"messageIds"`
+}
+
+// ChatClientMessage - Frame client gửi lên qua WebSocket
+// type: join | leave | message | read | typing
+type ChatClientMessage struct {
+	Type        string `json:"type"`
+	ChatID      uint   `json:"chatId"`
+	Content     string `json:"content,omitempty"`
+	MessageType string `json:"messageType,omitempty"` // text | image | file (mặc định text)
+}
+
+// ChatEvent - Frame server gửi xuống qua WebSocket
+// type: joined | left | message | read | typing | chat_opened | chat_updated | error
+type ChatEvent struct {
+	Type   string      `json:"type"`
+	ChatID uint        `json:"chatId,omitempty"`
+	Data   interface{} `json:"data,omitempty"`
+	Error  string      `json:"error,omitempty"`
+}
+
+// ChatWSTicketResponse - Ticket dùng một lần để mở kết nối WebSocket chat (GET /chats/ws?ticket=...)
+type ChatWSTicketResponse struct {
+	Ticket    string `json:"ticket"`
+	ExpiresIn int    `json:"expiresIn"` // Số giây ticket còn hiệu lực
+}
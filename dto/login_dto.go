@@ -1,8 +1,15 @@
 package dto
 
 type LoginRequest struct {
-	Email    string `json:"email" binding:"required,email"`
-	Password string `json:"password" binding:"required,min=6"`
+	Email      string  `json:"email" binding:"required,email"`
+	Password   string  `json:"password" binding:"required,min=6"`
+	DeviceName *string `json:"deviceName" binding:"omitempty,max=255"` // Tên thiết bị hiển thị trong danh sách phiên đăng nhập
+}
+
+type OAuthLoginRequest struct {
+	IDToken    string  `json:"idToken" binding:"required"`       // ID token do Google/Apple cấp cho app
+	Name       *string `json:"name" binding:"omitempty,max=255"` // Tên hiển thị (Apple chỉ trả cho app ở lần đầu)
+	DeviceName *string `json:"deviceName" binding:"omitempty,max=255"`
 }
 
 type RegisterRequest struct {
@@ -28,16 +35,19 @@ type RefreshTokenResponse struct {
 }
 
 type VerifyOtpRequest struct {
-	Email string `json:"email" binding:"required,email"`
-	OTP   string `json:"otp" binding:"required,len=6"`
+	Email      string  `json:"email" binding:"required,email"`
+	OTP        string  `json:"otp" binding:"required,len=6"`
+	DeviceName *string `json:"deviceName" binding:"omitempty,max=255"`
 }
 
 type VerifyOtpResponse struct {
-	Success      bool          `json:"success"`
-	Message      string        `json:"message"`
-	AccessToken  string        `json:"access_token,omitempty"`
-	RefreshToken string        `json:"refresh_token,omitempty"`
-	User         *UserResponse `json:"user,omitempty"`
+	Success                bool          `json:"success"`
+	Message                string        `json:"message"`
+	AccessToken            string        `json:"access_token,omitempty"`
+	RefreshToken           string        `json:"refresh_token,omitempty"`
+	RequiresTwoFactorSetup bool          `json:"requiresTwoFactorSetup,omitempty"`
+	TwoFactorToken         string        `json:"twoFactorToken,omitempty"`
+	User                   *UserResponse `json:"user,omitempty"`
 }
 
 type ResendOtpRequest struct {
@@ -50,13 +60,38 @@ type ResendOtpResponse struct {
 	Email   string `json:"email"`
 }
 
+type ForgotPasswordRequest struct {
+	Email string `json:"email" binding:"required,email"`
+}
+
+type ForgotPasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Email   string `json:"email"`
+}
+
+type ResetPasswordRequest struct {
+	Email       string `json:"email" binding:"required,email"`
+	OTP         string `json:"otp" binding:"required,len=6"`
+	NewPassword string `json:"newPassword" binding:"required,min=6"`
+}
+
+type ResetPasswordResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+}
+
 type LoginResponse struct {
-	Success      bool          `json:"success"`
-	Message      string        `json:"message"`
-	AccessToken  string        `json:"access_token,omitempty"`
-	RefreshToken string        `json:"refresh_token,omitempty"`
-	RequiresOtp  bool          `json:"requiresOtp,omitempty"`
-	User         *UserResponse `json:"user,omitempty"`
+	Success                bool          `json:"success"`
+	Message                string        `json:"message"`
+	AccessToken            string        `json:"access_token,omitempty"`
+	RefreshToken           string        `json:"refresh_token,omitempty"`
+	RequiresOtp            bool          `json:"requiresOtp,omitempty"`
+	RequiresTwoFactor      bool          `json:"requiresTwoFactor,omitempty"`      // Cần nhập mã TOTP/mã khôi phục (POST /auth/2fa/verify)
+	RequiresTwoFactorSetup bool          `json:"requiresTwoFactorSetup,omitempty"` // Admin bắt buộc bật 2FA nhưng chưa thiết lập (POST /auth/2fa/setup)
+	TwoFactorToken         string        `json:"twoFactorToken,omitempty"`
+	RecoveryCodes          []string      `json:"recoveryCodes,omitempty"` // Trả về một lần khi vừa thiết lập 2FA
+	User                   *UserResponse `json:"user,omitempty"`
 }
 
 type UserResponse struct {
@@ -0,0 +1,189 @@
+package dto
+
+// CheckoutRequest - Request để đặt hàng từ giỏ hàng hiện tại
+type CheckoutRequest struct {
+	ShippingAddressID uint    `json:"shippingAddressId" binding:"required"`
+	Notes             *string `json:"notes"`
+}
+
+// ShippingSnapshot - Địa chỉ giao hàng được lưu lại tại thời điểm đặt hàng
+type ShippingSnapshot struct {
+	FullName string  `json:"fullName"`
+	Phone    string  `json:"phone"`
+	Address  string  `json:"address"`
+	Ward     *string `json:"ward"`
+	District *string `json:"district"`
+	City     *string `json:"city"`
+}
+
+// OrderItemResponse - Response cho một sản phẩm trong đơn hàng
+type OrderItemResponse struct {
+	ID        uint             `json:"id"`
+	Quantity  int              `json:"quantity"`
+	Price     float64          `json:"price"`
+	Total     float64          `json:"total"`
+	ProductID uint             `json:"productId"`
+	Product   *ProductResponse `json:"product,omitempty"`
+}
+
+// OrderResponse - Response cho một đơn hàng
+type OrderResponse struct {
+	ID                uint                `json:"id"`
+	OrderNumber       string              `json:"orderNumber"`
+	TotalAmount       float64             `json:"totalAmount"`
+	ShippingFee       float64             `json:"shippingFee"`
+	Discount          float64             `json:"discount"`
+	Status            string              `json:"status"`
+	Notes             *string             `json:"notes"`
+	UserID            uint                `json:"userId"`
+	ShippingAddressID uint                `json:"shippingAddressId"`
+	ShippingAddress   *ShippingSnapshot   `json:"shippingAddress,omitempty"`
+	Customer          *OrderCustomer      `json:"customer,omitempty"` // Chỉ có trong API admin
+	Items             []OrderItemResponse `json:"items"`
+	Payments          []PaymentResponse   `json:"payments,omitempty"`
+	CreatedAt         string              `json:"createdAt"`
+	UpdatedAt         string              `json:"updatedAt"`
+}
+
+// OrderCustomer - Thông tin khách hàng của đơn hàng (dùng cho admin)
+type OrderCustomer struct {
+	ID    uint    `json:"id"`
+	Name  string  `json:"name"`
+	Email string  `json:"email"`
+	Phone *string `json:"phone"`
+}
+
+// SearchOrderRequest - Request để tìm kiếm đơn hàng của user
+type SearchOrderRequest struct {
+	Status    *string `json:"status" binding:"omitempty,oneof=pending confirmed processing shipping delivered cancelled refunded"`
+	FromDate  *string `json:"fromDate"` // YYYY-MM-DD hoặc RFC3339 (>=)
+	ToDate    *string `json:"toDate"`   // YYYY-MM-DD (bao gồm cả ngày đó) hoặc RFC3339 (<=)
+	SortBy    *string `json:"sortBy" binding:"omitempty,oneof=id totalAmount status createdAt updatedAt"`
+	SortOrder *string `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
+	Page      *int    `json:"page" binding:"omitempty,min=1"`
+	Limit     *int    `json:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type OrderPaginationResponse struct {
+	Data       []OrderResponse `json:"data"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"totalPages"`
+}
+
+type SearchOrderResponse struct {
+	Success    bool            `json:"success"`
+	Message    string          `json:"message"`
+	Data       []OrderResponse `json:"data"`
+	Total      int64           `json:"total"`
+	Page       int             `json:"page"`
+	Limit      int             `json:"limit"`
+	TotalPages int             `json:"totalPages"`
+}
+
+// UpdateOrderStatusRequest - Request để admin chuyển trạng thái đơn hàng
+type UpdateOrderStatusRequest struct {
+	Status string  `json:"status" binding:"required,oneof=pending confirmed processing shipping delivered cancelled refunded"`
+	Reason *string `json:"reason"`
+}
+
+// OrderStatusHistoryResponse - Response cho một lần chuyển trạng thái
+type OrderStatusHistoryResponse struct {
+	ID          uint    `json:"id"`
+	FromStatus  string  `json:"fromStatus"`
+	ToStatus    string  `json:"toStatus"`
+	ChangedByID *uint   `json:"changedById"`
+	ChangedBy   *string `json:"changedBy"` // Tên người thực hiện
+	Reason      *string `json:"reason"`
+	CreatedAt   string  `json:"createdAt"`
+}
+
+// CreatePaymentRequest - Request để tạo thanh toán cho đơn hàng
+type CreatePaymentRequest struct {
+	Method   string  `json:"method" binding:"required,oneof=cod bank_transfer credit_card e_wallet"`
+	Provider *string `json:"provider"` // Cổng thanh toán (vd: vnpay, momo). Mặc định trùng với method
+	Notes    *string `json:"notes"`
+}
+
+// UpdatePaymentStatusRequest - Request để admin xác nhận/từ chối thanh toán
+type UpdatePaymentStatusRequest struct {
+	Status string  `json:"status" binding:"required,oneof=completed failed"`
+	Notes  *string `json:"notes"`
+}
+
+// PaymentResponse - Response cho một giao dịch thanh toán
+type PaymentResponse struct {
+	ID             uint    `json:"id"`
+	TransactionID  string  `json:"transactionId"`
+	Amount         float64 `json:"amount"`
+	Method         string  `json:"method"`
+	Status         string  `json:"status"`
+	PaymentDetails *string `json:"paymentDetails"`
+	Notes          *string `json:"notes"`
+	UserID         uint    `json:"userId"`
+	OrderID        uint    `json:"orderId"`
+	Instructions   *string `json:"instructions,omitempty"` // Hướng dẫn thanh toán (vd: thông tin chuyển khoản)
+	RedirectURL    *string `json:"redirectUrl,omitempty"`  // URL chuyển hướng sang cổng thanh toán
+	CreatedAt      string  `json:"createdAt"`
+	UpdatedAt      string  `json:"updatedAt"`
+}
+
+// FakeGatewayPayRequest - Request giả lập kết quả thanh toán trên cổng giả lập
+type FakeGatewayPayRequest struct {
+	Result string `json:"result" binding:"required,oneof=success failed"`
+}
+
+// FakeGatewayResponse - Kết quả round trip qua cổng giả lập
+type FakeGatewayResponse struct {
+	Payment   PaymentResponse `json:"payment"`
+	Duplicate bool            `json:"duplicate"` // true nếu callback đã được xử lý trước đó
+	Callback  string          `json:"callback"`  // Body callback đã gửi vào webhook
+	Signature string          `json:"signature"` // Chữ ký HMAC tương ứng (header X-Signature)
+}
+
+// AdminSearchOrderRequest - Request để admin tìm kiếm đơn hàng của tất cả user
+type AdminSearchOrderRequest struct {
+	Status        *string  `json:"status" binding:"omitempty,oneof=pending confirmed processing shipping delivered cancelled refunded"`
+	PaymentStatus *string  `json:"paymentStatus" binding:"omitempty,oneof=unpaid pending processing completed failed refunded"` // Trạng thái giao dịch gần nhất, unpaid = chưa có giao dịch
+	Email         *string  `json:"email"`                                                                                       // Email khách hàng (partial match)
+	OrderNumber   *string  `json:"orderNumber"`                                                                                 // Mã đơn hàng (partial match)
+	MinAmount     *float64 `json:"minAmount" binding:"omitempty,min=0"`                                                         // Filter (>=)
+	MaxAmount     *float64 `json:"maxAmount" binding:"omitempty,min=0"`                                                         // Filter (<=)
+	FromDate      *string  `json:"fromDate"`
+	ToDate        *string  `json:"toDate"`
+	SortBy        *string  `json:"sortBy" binding:"omitempty,oneof=id totalAmount status createdAt updatedAt"`
+	SortOrder     *string  `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
+	Page          *int     `json:"page" binding:"omitempty,min=1"`
+	Limit         *int     `json:"limit" binding:"omitempty,min=1,max=100"`
+}
+
+type AdminOrderPaginationResponse struct {
+	Data         []OrderResponse  `json:"data"`
+	Total        int64            `json:"total"`
+	Page         int              `json:"page"`
+	Limit        int              `json:"limit"`
+	TotalPages   int              `json:"totalPages"`
+	StatusCounts map[string]int64 `json:"statusCounts"` // Số đơn theo từng trạng thái (áp dụng các filter khác ngoài status)
+}
+
+// BulkUpdateOrderStatusRequest - Request để chuyển trạng thái nhiều đơn hàng cùng lúc
+type BulkUpdateOrderStatusRequest struct {
+	OrderIDs []uint  `json:"orderIds" binding:"required,min=1,max=200,dive,min=1"`
+	Status   string  `json:"status" binding:"required,oneof=pending confirmed processing shipping delivered cancelled refunded"`
+	Reason   *string `json:"reason"`
+}
+
+// BulkOrderStatusResult - Kết quả chuyển trạng thái của một đơn hàng
+type BulkOrderStatusResult struct {
+	OrderID uint    `json:"orderId"`
+	Success bool    `json:"success"`
+	Status  string  `json:"status,omitempty"` // Trạng thái sau khi xử lý
+	Error   *string `json:"error,omitempty"`
+}
+
+type BulkUpdateOrderStatusResponse struct {
+	Updated int                     `json:"updated"`
+	Failed  int                     `json:"failed"`
+	Results []BulkOrderStatusResult `json:"results"`
+}
@@ -50,10 +50,14 @@ type SearchProductRequest struct {
 	MinPrice       *float64    `json:"minPrice" binding:"omitempty,min=0"` // Filter (>=)
 	MaxPrice       *float64    `json:"maxPrice" binding:"omitempty,min=0"` // Filter (<=)
 	InStock        *bool       `json:"inStock"` // true = chỉ lấy sản phẩm còn hàng (stock > 0)
-	SortBy         *string     `json:"sortBy" binding:"omitempty,oneof=id name price stock createdAt updatedAt"`
+	SortBy         *string     `json:"sortBy" binding:"omitempty,oneof=id name price stock createdAt updatedAt relevance"`
 	SortOrder      *string     `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
 	Page           *int        `json:"page" binding:"omitempty,min=1"`
 	Limit          *int        `json:"limit" binding:"omitempty,min=1,max=1000"`
+	Cursor         *string     `json:"cursor"`       // Phân trang theo cursor: "" = trang đầu, các trang sau gửi nextCursor của response (bỏ qua page)
+	IncludeTotal   *bool       `json:"includeTotal"` // Đếm total/totalPages (mặc định true khi phân trang theo page, false khi dùng cursor)
+	IncludeFacets  *bool       `json:"includeFacets"` // true = trả thêm facets (số lượng theo danh mục, giá, tồn kho, đánh giá) cho sidebar lọc
+	PriceBuckets   *int        `json:"priceBuckets" binding:"omitempty,min=2,max=20"` // Số khoảng giá của facet giá (mặc định 5)
 }
 
 type ProductResponse struct {
@@ -73,6 +77,7 @@ type ProductResponse struct {
 	SKU           *string  `json:"sku"`
 	CategoryID    uint     `json:"categoryId"`
 	Category      *CategoryResponse `json:"category,omitempty"`
+	IsWishlisted  *bool    `json:"isWishlisted,omitempty"` // Chỉ có khi người gọi đã đăng nhập
 	CreatedAt     string   `json:"createdAt"`
 	UpdatedAt     string   `json:"updatedAt"`
 }
@@ -91,20 +96,57 @@ type GetProductResponse struct {
 
 type ProductPaginationResponse struct {
 	Data       []ProductResponse `json:"data"`
-	Total      int64             `json:"total"`
-	Page       int               `json:"page"`
+	Total      *int64            `json:"total,omitempty"` // nil khi không đếm (includeTotal = false)
+	Page       int               `json:"page,omitempty"`  // 0 khi phân trang theo cursor
 	Limit      int               `json:"limit"`
-	TotalPages int               `json:"totalPages"`
+	TotalPages *int              `json:"totalPages,omitempty"`
+	NextCursor *string           `json:"nextCursor,omitempty"` // nil khi đã hết dữ liệu
+	Facets     *ProductFacets    `json:"facets,omitempty"`
+}
+
+// ProductFacets số lượng sản phẩm theo từng nhóm lọc, tính trên cùng bộ lọc với kết quả tìm kiếm
+type ProductFacets struct {
+	Categories []CategoryFacet `json:"categories"`
+	Prices     []PriceFacet    `json:"prices"`
+	Stock      StockFacet      `json:"stock"`
+	Ratings    []RatingFacet   `json:"ratings"`
+}
+
+type CategoryFacet struct {
+	CategoryID uint   `json:"categoryId"`
+	Name       string `json:"name"`
+	Count      int64  `json:"count"`
+}
+
+// PriceFacet khoảng giá [min, max) - khoảng cuối cùng bao gồm cả max
+type PriceFacet struct {
+	Min   float64 `json:"min"`
+	Max   float64 `json:"max"`
+	Count int64   `json:"count"`
+}
+
+type StockFacet struct {
+	InStock    int64 `json:"inStock"`
+	OutOfStock int64 `json:"outOfStock"`
+}
+
+// RatingFacet khoảng đánh giá [min, max) - khoảng 4-5 bao gồm cả 5 sao
+type RatingFacet struct {
+	Min   int   `json:"min"`
+	Max   int   `json:"max"`
+	Count int64 `json:"count"`
 }
 
 type SearchProductResponse struct {
 	Success    bool                   `json:"success"`
 	Message    string                 `json:"message"`
 	Data       []ProductResponse      `json:"data"`
-	Total      int64                  `json:"total"`
-	Page       int                    `json:"page"`
+	Total      *int64                 `json:"total,omitempty"`
+	Page       int                    `json:"page,omitempty"`
 	Limit      int                    `json:"limit"`
-	TotalPages int                    `json:"totalPages"`
+	TotalPages *int                   `json:"totalPages,omitempty"`
+	NextCursor *string                `json:"nextCursor,omitempty"`
+	Facets     *ProductFacets         `json:"facets,omitempty"`
 }
 
 type DeleteProductResponse struct {
@@ -114,9 +156,17 @@ type DeleteProductResponse struct {
 
 // Search Suggestions DTOs
 type SearchSuggestion struct {
-	Text  string `json:"text"`              // Text hiển thị
-	Type  string `json:"type"`              // "product" hoặc "category"
-	Count int    `json:"count,omitempty"`   // Số lượng sản phẩm/danh mục
+	ID        *uint                `json:"id,omitempty"`        // ID sản phẩm/danh mục (không có với type "query")
+	Text      string               `json:"text"`                // Text hiển thị
+	Type      string               `json:"type"`                // "query", "category" hoặc "product"
+	Count     int                  `json:"count,omitempty"`     // Số lượt tìm (query) hoặc số sản phẩm (category)
+	Highlight *SuggestionHighlight `json:"highlight,omitempty"` // Đoạn khớp với từ khóa để tô sáng
+}
+
+// SuggestionHighlight vị trí đoạn khớp trong Text, tính theo ký tự (rune), End không bao gồm
+type SuggestionHighlight struct {
+	Start int `json:"start"`
+	End   int `json:"end"`
 }
 
 type SearchSuggestionsResponse struct {
@@ -133,6 +183,12 @@ type PopularSearch struct {
 	Count int    `json:"count"` // Số lượng tìm kiếm
 }
 
+// ZeroResultSearch từ khóa được tìm nhưng không có sản phẩm nào (báo cáo cho admin)
+type ZeroResultSearch struct {
+	Term  string `json:"term"`
+	Count int64  `json:"count"` // Số lượt tìm không có kết quả
+}
+
 type PopularSearchesResponse struct {
 	Success bool            `json:"success"`
 	Data    []PopularSearch `json:"data"`
@@ -0,0 +1,43 @@
+package dto
+
+// CreateReviewRequest - Request để đánh giá sản phẩm
+type CreateReviewRequest struct {
+	Rating  int      `json:"rating" binding:"required,min=1,max=5"`
+	Comment *string  `json:"comment"`
+	Images  []string `json:"images"`
+}
+
+// UpdateReviewRequest - Request để sửa đánh giá (partial update)
+type UpdateReviewRequest struct {
+	Rating  *int     `json:"rating" binding:"omitempty,min=1,max=5"`
+	Comment *string  `json:"comment"`
+	Images  []string `json:"images"`
+}
+
+// ReviewResponse - Response cho một đánh giá
+type ReviewResponse struct {
+	ID         uint     `json:"id"`
+	Rating     int      `json:"rating"`
+	Comment    *string  `json:"comment"`
+	Images     []string `json:"images,omitempty"`
+	IsVerified bool     `json:"isVerified"` // Đã mua hàng
+	UserID     uint     `json:"userId"`
+	UserName   string   `json:"userName"`
+	UserAvatar *string  `json:"userAvatar"`
+	ProductID  uint     `json:"productId"`
+	OrderID    *uint    `json:"orderId"`
+	CreatedAt  string   `json:"createdAt"`
+	UpdatedAt  string   `json:"updatedAt"`
+}
+
+// ReviewPaginationResponse - Danh sách đánh giá của sản phẩm kèm phân bố số sao
+type ReviewPaginationResponse struct {
+	Data               []ReviewResponse `json:"data"`
+	Total              int64            `json:"total"`
+	Page               int              `json:"page"`
+	Limit              int              `json:"limit"`
+	TotalPages         int              `json:"totalPages"`
+	AverageRating      float64          `json:"averageRating"`
+	ReviewCount        int64            `json:"reviewCount"`        // Tổng số đánh giá (không áp dụng filter rating)
+	RatingDistribution map[int]int64    `json:"ratingDistribution"` // Số lượng đánh giá theo số sao: {"1": 0, ..., "5": 10}
+}
@@ -0,0 +1,35 @@
+package dto
+
+type CreateRoleRequest struct {
+	Name        string   `json:"name" binding:"required,min=2,max=50"` // Chữ thường, số và dấu gạch dưới, vd: catalog_manager
+	DisplayName string   `json:"displayName" binding:"required,max=100"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"` // Mã quyền, vd: product:write
+}
+
+type UpdateRoleRequest struct {
+	DisplayName *string  `json:"displayName" binding:"omitempty,max=100"`
+	Description *string  `json:"description"`
+	Permissions []string `json:"permissions"` // Không truyền = giữ nguyên, [] = bỏ hết quyền
+}
+
+type AssignRoleRequest struct {
+	Role string `json:"role" binding:"required,max=50"`
+}
+
+type PermissionResponse struct {
+	Code        string `json:"code"`
+	Description string `json:"description"`
+}
+
+type RoleResponse struct {
+	ID          uint     `json:"id"`
+	Name        string   `json:"name"`
+	DisplayName string   `json:"displayName"`
+	Description *string  `json:"description"`
+	IsSystem    bool     `json:"isSystem"`
+	Permissions []string `json:"permissions"`
+	UserCount   int64    `json:"userCount"` // Số user đang có role này
+	CreatedAt   string   `json:"createdAt"`
+	UpdatedAt   string   `json:"updatedAt"`
+}
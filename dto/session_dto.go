@@ -0,0 +1,20 @@
+package dto
+
+// ClientInfo - Thông tin thiết bị/kết nối của request, lưu vào phiên đăng nhập
+type ClientInfo struct {
+	DeviceName *string
+	IPAddress  string
+	UserAgent  string
+}
+
+// SessionResponse - Response cho một phiên đăng nhập
+type SessionResponse struct {
+	ID         uint    `json:"id"`
+	DeviceName *string `json:"deviceName"`
+	IPAddress  string  `json:"ipAddress"`
+	UserAgent  string  `json:"userAgent"`
+	IsCurrent  bool    `json:"isCurrent"` // Phiên của token đang gọi API
+	CreatedAt  string  `json:"createdAt"`
+	LastUsedAt string  `json:"lastUsedAt"`
+	ExpiresAt  string  `json:"expiresAt"`
+}
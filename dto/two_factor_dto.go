@@ -0,0 +1,42 @@
+package dto
+
+type TwoFactorCodeRequest struct {
+	Code string `json:"code" binding:"required,max=32"` // Mã TOTP 6 số hoặc mã khôi phục
+}
+
+type TwoFactorTokenRequest struct {
+	TwoFactorToken string `json:"twoFactorToken" binding:"required"`
+}
+
+// TwoFactorVerifyRequest hoàn tất đăng nhập bằng mã TOTP hoặc mã khôi phục
+type TwoFactorVerifyRequest struct {
+	TwoFactorToken string  `json:"twoFactorToken" binding:"required"`
+	Code           string  `json:"code" binding:"required,max=32"`
+	DeviceName     *string `json:"deviceName" binding:"omitempty,max=255"`
+}
+
+type TwoFactorStatusResponse struct {
+	Enabled                bool    `json:"enabled"`
+	Pending                bool    `json:"pending"`  // Đã tạo secret nhưng chưa xác nhận mã đầu tiên
+	Required               bool    `json:"required"` // Bị bắt buộc bởi cấu hình (admin)
+	EnabledAt              *string `json:"enabledAt"`
+	RecoveryCodesRemaining int64   `json:"recoveryCodesRemaining"`
+}
+
+type TwoFactorSetupResponse struct {
+	Secret          string `json:"secret"`          // Dùng khi nhập tay vào app xác thực
+	ProvisioningURI string `json:"provisioningUri"` // otpauth://... để hiển thị QR code
+	Issuer          string `json:"issuer"`
+	Account         string `json:"account"`
+}
+
+type TwoFactorRecoveryCodesResponse struct {
+	RecoveryCodes []string `json:"recoveryCodes"` // Chỉ hiển thị một lần, user cần lưu lại
+}
+
+// TwoFactorChallenge là bước xác thực 2 lớp trả về sau khi nhập đúng mật khẩu
+type TwoFactorChallenge struct {
+	RequiresTwoFactor      bool
+	RequiresTwoFactorSetup bool
+	TwoFactorToken         string
+}
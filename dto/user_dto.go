@@ -5,7 +5,7 @@ type CreateUserByAdminRequest struct {
 	Password string  `json:"password" binding:"required,min=6"`
 	Name     string  `json:"name" binding:"required"`
 	Phone    *string `json:"phone"`
-	Role     *string `json:"role" binding:"omitempty,oneof=customer admin"`
+	Role     *string `json:"role" binding:"omitempty,max=50"` // Role.Name, cần quyền role:manage
 	Avatar   *string `json:"avatar"`
 }
 
@@ -17,13 +17,29 @@ type UpdateProfileRequest struct {
 	Gender  *string `json:"gender" binding:"omitempty,oneof=male female other"`
 }
 
+// ChangeEmailRequest - User tự đổi email: cần mật khẩu hiện tại, kèm mã 2FA nếu tài khoản đã bật xác thực 2 lớp
+type ChangeEmailRequest struct {
+	NewEmail        string `json:"newEmail" binding:"required,email"`
+	CurrentPassword string `json:"currentPassword" binding:"required"`
+	TwoFactorCode   string `json:"twoFactorCode" binding:"max=32"` // Mã TOTP 6 số hoặc mã khôi phục
+}
+
+// ChangeEmailByAdminRequest - Admin sửa email giúp user (user vẫn phải nhập OTP gửi tới email mới)
+type ChangeEmailByAdminRequest struct {
+	NewEmail string `json:"newEmail" binding:"required,email"`
+}
+
+type VerifyChangeEmailRequest struct {
+	OTP string `json:"otp" binding:"required,len=6"`
+}
+
 type UpdateUserByAdminRequest struct {
 	Name            *string `json:"name"`
 	Phone           *string `json:"phone"`
 	Avatar          *string `json:"avatar"`
 	Address         *string `json:"address"`
 	Gender          *string `json:"gender" binding:"omitempty,oneof=male female other"`
-	Role            *string `json:"role" binding:"omitempty,oneof=customer admin"`
+	Role            *string `json:"role" binding:"omitempty,max=50"` // Role.Name, cần quyền role:manage
 	IsActive        *bool   `json:"isActive"`
 	IsEmailVerified *bool   `json:"isEmailVerified"`
 }
@@ -43,14 +59,17 @@ type SearchUserRequest struct {
 	SortOrder      *string   `json:"sortOrder" binding:"omitempty,oneof=ASC DESC"`
 	Page           *int      `json:"page" binding:"omitempty,min=1"`
 	Limit          *int      `json:"limit" binding:"omitempty,min=1,max=1000"`
+	Cursor         *string   `json:"cursor"`       // Phân trang theo cursor: "" = trang đầu, các trang sau gửi nextCursor của response (bỏ qua page)
+	IncludeTotal   *bool     `json:"includeTotal"` // Đếm total/totalPages (mặc định true khi phân trang theo page, false khi dùng cursor)
 }
 
 type PaginationResponse struct {
 	Data       []UserResponse `json:"data"`
-	Total      int64          `json:"total"`
-	Page       int            `json:"page"`
+	Total      *int64         `json:"total,omitempty"` // nil khi không đếm (includeTotal = false)
+	Page       int            `json:"page,omitempty"`  // 0 khi phân trang theo cursor
 	Limit      int            `json:"limit"`
-	TotalPages int            `json:"totalPages"`
+	TotalPages *int           `json:"totalPages,omitempty"`
+	NextCursor *string        `json:"nextCursor,omitempty"` // nil khi đã hết dữ liệu
 }
 
 type CreateUserResponse struct {
@@ -69,10 +88,17 @@ type SearchUserResponse struct {
 	Success bool              `json:"success"`
 	Message string            `json:"message"`
 	Data    []UserResponse    `json:"data"`
-	Total   int64             `json:"total"`
-	Page    int               `json:"page"`
+	Total   *int64            `json:"total,omitempty"`
+	Page    int               `json:"page,omitempty"`
 	Limit   int               `json:"limit"`
-	TotalPages int            `json:"totalPages"`
+	TotalPages *int           `json:"totalPages,omitempty"`
+	NextCursor *string        `json:"nextCursor,omitempty"`
+}
+
+type ChangeEmailResponse struct {
+	Success bool   `json:"success"`
+	Message string `json:"message"`
+	Email   string `json:"email"` // Email mới đang chờ xác thực
 }
 
 type ChangePasswordResponse struct {
@@ -80,3 +106,12 @@ type ChangePasswordResponse struct {
 	Message string `json:"message"`
 }
 
+// LoginLockoutResponse trạng thái khóa đăng nhập của user (admin xem)
+type LoginLockoutResponse struct {
+	Email          string  `json:"email"`
+	Locked         bool    `json:"locked"`
+	LockedUntil    *string `json:"lockedUntil"`
+	RetryAfter     int     `json:"retryAfter"`     // Số giây còn bị khóa
+	FailedAttempts int64   `json:"failedAttempts"` // Số lần sai trong window hiện tại
+	LockoutCount   int64   `json:"lockoutCount"`   // Số lần bị khóa trong 24h (quyết định thời gian khóa tiếp theo)
+}
@@ -0,0 +1,20 @@
+package dto
+
+// AddToWishlistRequest - Request để thêm sản phẩm vào wishlist
+type AddToWishlistRequest struct {
+	ProductID uint `json:"productId" binding:"required"`
+}
+
+// MoveToCartRequest - Request để chuyển sản phẩm từ wishlist sang giỏ hàng
+type MoveToCartRequest struct {
+	Quantity int `json:"quantity" binding:"omitempty,min=1"` // Mặc định 1
+}
+
+// WishlistItemResponse - Response cho một sản phẩm trong wishlist
+type WishlistItemResponse struct {
+	ID        uint            `json:"id"`
+	UserID    uint            `json:"userId"`
+	ProductID uint            `json:"productId"`
+	Product   ProductResponse `json:"product"`
+	CreatedAt string          `json:"createdAt"`
+}
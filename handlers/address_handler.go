@@ -0,0 +1,193 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AddressHandler struct {
+	addressService *services.AddressService
+}
+
+func NewAddressHandler() *AddressHandler {
+	return &AddressHandler{
+		addressService: services.NewAddressService(),
+	}
+}
+
+// List lấy sổ địa chỉ của chính mình
+func (h *AddressHandler) List(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	addresses, err := h.addressService.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    addresses,
+	})
+}
+
+// FindOne lấy một địa chỉ
+func (h *AddressHandler) FindOne(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	address, err := h.addressService.FindOne(userID.(uint), uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    address,
+	})
+}
+
+// Create thêm địa chỉ mới
+func (h *AddressHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req dto.CreateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address, err := h.addressService.Create(userID.(uint), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Thêm địa chỉ thành công",
+		"data":    address,
+	})
+}
+
+// Update cập nhật địa chỉ
+func (h *AddressHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.UpdateAddressRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	address, err := h.addressService.Update(userID.(uint), uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật địa chỉ thành công",
+		"data":    address,
+	})
+}
+
+// SetDefault đặt địa chỉ làm mặc định
+func (h *AddressHandler) SetDefault(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	address, err := h.addressService.SetDefault(userID.(uint), uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đặt địa chỉ mặc định thành công",
+		"data":    address,
+	})
+}
+
+// Delete xóa địa chỉ
+func (h *AddressHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("addressId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	if err := h.addressService.Delete(userID.(uint), uint(id)); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Xóa địa chỉ thành công",
+	})
+}
@@ -0,0 +1,91 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AdminOrderHandler struct {
+	adminOrderService *services.AdminOrderService
+	lifecycleService  *services.OrderLifecycleService
+}
+
+func NewAdminOrderHandler() *AdminOrderHandler {
+	return &AdminOrderHandler{
+		adminOrderService: services.NewAdminOrderService(),
+		lifecycleService:  services.NewOrderLifecycleService(),
+	}
+}
+
+// Search tìm kiếm đơn hàng của tất cả user (Chỉ admin)
+// @Summary Quản lý đơn hàng
+// @Description Tìm kiếm đơn hàng theo trạng thái, trạng thái thanh toán, email khách hàng, mã đơn và khoảng tiền
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param search body dto.AdminSearchOrderRequest false "Điều kiện tìm kiếm"
+// @Success 200 {object} dto.AdminOrderPaginationResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/admin/orders/search [post]
+func (h *AdminOrderHandler) Search(c *gin.Context) {
+	var req dto.AdminSearchOrderRequest
+	// Cho phép body null/empty - nếu không có body thì lấy tất cả đơn hàng
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	result, err := h.adminOrderService.Search(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":      true,
+		"message":      "Tìm kiếm đơn hàng thành công",
+		"data":         result.Data,
+		"total":        result.Total,
+		"page":         result.Page,
+		"limit":        result.Limit,
+		"totalPages":   result.TotalPages,
+		"statusCounts": result.StatusCounts,
+	})
+}
+
+// BulkUpdateStatus chuyển trạng thái nhiều đơn hàng cùng lúc (Chỉ admin)
+// Đơn không chuyển được sẽ được báo lỗi riêng trong results, các đơn khác vẫn được cập nhật
+func (h *AdminOrderHandler) BulkUpdateStatus(c *gin.Context) {
+	currentUserID, _ := c.Get("userID")
+
+	var req dto.BulkUpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result := h.lifecycleService.BulkChangeStatus(req, currentUserID.(uint))
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": result.Failed == 0,
+		"message": "Đã xử lý cập nhật trạng thái đơn hàng",
+		"data":    result,
+	})
+}
@@ -1,24 +1,50 @@
 package handlers
 
 import (
+	"errors"
 	"net/http"
+	"strconv"
 
 	"ecommerce-be/dto"
 	"ecommerce-be/services"
+	"ecommerce-be/utils"
 
 	"github.com/gin-gonic/gin"
 )
 
 type AuthHandler struct {
-	authService *services.AuthService
+	authService  *services.AuthService
+	oauthService *services.OAuthService
 }
 
 func NewAuthHandler() *AuthHandler {
 	return &AuthHandler{
-		authService: services.NewAuthService(),
+		authService:  services.NewAuthService(),
+		oauthService: services.NewOAuthService(),
 	}
 }
 
+// clientInfo lấy thông tin thiết bị của request để lưu vào phiên đăng nhập
+func clientInfo(c *gin.Context) dto.ClientInfo {
+	return dto.ClientInfo{
+		IPAddress: c.ClientIP(),
+		UserAgent: c.Request.UserAgent(),
+	}
+}
+
+// respondAuthError trả lỗi xác thực; bị khóa do thử sai nhiều lần → 429 kèm header Retry-After
+func respondAuthError(c *gin.Context, status int, err error) {
+	var lockErr *services.LockoutError
+	if errors.As(err, &lockErr) {
+		c.Header("Retry-After", strconv.Itoa(lockErr.RetryAfterSeconds()))
+		status = http.StatusTooManyRequests
+	}
+	c.JSON(status, gin.H{
+		"success": false,
+		"error":   err.Error(),
+	})
+}
+
 // Login xử lý request đăng nhập
 // @Summary Đăng nhập
 // @Description Đăng nhập với email và password
@@ -44,7 +70,103 @@ func (h *AuthHandler) Login(c *gin.Context) {
 	}
 
 	// Gọi service
-	response, err := h.authService.Login(req)
+	response, err := h.authService.Login(req, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// OAuthLogin đăng nhập bằng ID token của Google/Apple
+// @Summary Đăng nhập mạng xã hội
+// @Description Xác thực ID token của provider (google, apple), tự liên kết hoặc tạo tài khoản
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param provider path string true "google hoặc apple"
+// @Param login body dto.OAuthLoginRequest true "ID token"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/oauth/{provider} [post]
+func (h *AuthHandler) OAuthLogin(c *gin.Context) {
+	var req dto.OAuthLoginRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.oauthService.Login(c.Param("provider"), req, clientInfo(c))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// VerifyTwoFactor hoàn tất đăng nhập bằng mã xác thực 2 lớp
+// @Summary Xác thực 2 lớp
+// @Description Dùng twoFactorToken từ bước đăng nhập và mã TOTP (hoặc mã khôi phục)
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorVerifyRequest true "Token và mã xác thực"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 401 {object} map[string]string
+// @Failure 429 {object} map[string]string
+// @Router /api/v1/auth/2fa/verify [post]
+func (h *AuthHandler) VerifyTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.VerifyTwoFactor(req, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// SetupTwoFactor thiết lập 2FA trong lúc đăng nhập (admin bị bắt buộc bật 2FA)
+// @Summary Thiết lập 2FA khi đăng nhập
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorTokenRequest true "Token từ bước đăng nhập"
+// @Success 200 {object} dto.TwoFactorSetupResponse
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/2fa/setup [post]
+func (h *AuthHandler) SetupTwoFactor(c *gin.Context) {
+	var req dto.TwoFactorTokenRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	setup, err := h.authService.SetupTwoFactor(req)
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
@@ -53,6 +175,38 @@ func (h *AuthHandler) Login(c *gin.Context) {
 		return
 	}
 
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    setup,
+	})
+}
+
+// ConfirmTwoFactorSetup xác nhận mã đầu tiên, bật 2FA và hoàn tất đăng nhập
+// @Summary Xác nhận thiết lập 2FA khi đăng nhập
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorVerifyRequest true "Token và mã TOTP"
+// @Success 200 {object} dto.LoginResponse
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/2fa/setup/confirm [post]
+func (h *AuthHandler) ConfirmTwoFactorSetup(c *gin.Context) {
+	var req dto.TwoFactorVerifyRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.ConfirmTwoFactorSetup(req, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusUnauthorized, err)
+		return
+	}
+
 	c.JSON(http.StatusOK, response)
 }
 
@@ -116,7 +270,7 @@ func (h *AuthHandler) RefreshToken(c *gin.Context) {
 	}
 
 	// Gọi service
-	response, err := h.authService.RefreshToken(req.RefreshToken)
+	response, err := h.authService.RefreshToken(req.RefreshToken, clientInfo(c))
 	if err != nil {
 		c.JSON(http.StatusUnauthorized, gin.H{
 			"success": false,
@@ -141,12 +295,9 @@ func (h *AuthHandler) VerifyOtp(c *gin.Context) {
 		return
 	}
 
-	response, err := h.authService.VerifyOtp(req)
+	response, err := h.authService.VerifyOtp(req, clientInfo(c))
 	if err != nil {
-		c.JSON(http.StatusBadRequest, gin.H{
-			"success": false,
-			"error":   err.Error(),
-		})
+		respondAuthError(c, http.StatusBadRequest, err)
 		return
 	}
 
@@ -177,3 +328,76 @@ func (h *AuthHandler) ResendOtp(c *gin.Context) {
 
 	c.JSON(http.StatusOK, response)
 }
+
+// ForgotPassword gửi OTP đặt lại mật khẩu
+func (h *AuthHandler) ForgotPassword(c *gin.Context) {
+	var req dto.ForgotPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.ForgotPassword(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ResetPassword đặt lại mật khẩu bằng OTP
+func (h *AuthHandler) ResetPassword(c *gin.Context) {
+	var req dto.ResetPasswordRequest
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.authService.ResetPassword(req, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// Logout đăng xuất thiết bị hiện tại
+// @Summary Đăng xuất
+// @Description Thu hồi access token đang dùng và refresh token của phiên hiện tại
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	claims, _ := c.Get("tokenClaims")
+
+	if err := h.authService.Logout(userID.(uint), claims.(*utils.Claims)); err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đăng xuất thành công",
+	})
+}
@@ -148,6 +148,7 @@ func (h *CategoryHandler) Search(c *gin.Context) {
 		Page:       result.Page,
 		Limit:      result.Limit,
 		TotalPages: result.TotalPages,
+		NextCursor: result.NextCursor,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -0,0 +1,361 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+	"ecommerce-be/services"
+	"ecommerce-be/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/gorilla/websocket"
+)
+
+var chatUpgrader = websocket.Upgrader{
+	ReadBufferSize:  1024,
+	WriteBufferSize: 1024,
+	CheckOrigin: func(r *http.Request) bool {
+		// Client không phải trình duyệt (mobile app) không gửi Origin
+		origin := r.Header.Get("Origin")
+		return origin == "" || middleware.IsAllowedOrigin(origin)
+	},
+}
+
+type ChatHandler struct {
+	chatService *services.ChatService
+}
+
+func NewChatHandler() *ChatHandler {
+	return &ChatHandler{
+		chatService: services.NewChatService(),
+	}
+}
+
+// chatCaller lấy userID và quyền hỗ trợ (chat:support) của người gọi (đã set bởi auth middleware)
+func chatCaller(c *gin.Context) (uint, bool) {
+	userID, _ := c.Get("userID")
+	return userID.(uint), middleware.HasPermission(c, models.PermissionChatSupport)
+}
+
+// Open mở cuộc trò chuyện hỗ trợ mới
+// @Summary Mở chat hỗ trợ
+// @Description Khách hàng mở cuộc trò chuyện mới, có thể kèm tin nhắn đầu tiên
+// @Tags chats
+// @Accept json
+// @Produce json
+// @Param chat body dto.OpenChatRequest true "Thông tin cuộc trò chuyện"
+// @Success 201 {object} dto.ChatResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/chats [post]
+func (h *ChatHandler) Open(c *gin.Context) {
+	userID, _ := chatCaller(c)
+
+	var req dto.OpenChatRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	chat, err := h.chatService.Open(userID, req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Mở cuộc trò chuyện thành công",
+		"data":    chat,
+	})
+}
+
+// List lấy danh sách cuộc trò chuyện
+// Query: status (open|pending|closed), assigned (me|unassigned - chỉ admin), page, limit
+func (h *ChatHandler) List(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	status := c.Query("status")
+	if status != "" && status != "open" && status != "pending" && status != "closed" {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "status phải là open, pending hoặc closed",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "20"))
+
+	result, err := h.chatService.List(userID, isAdmin, status, c.Query("assigned"), page, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":    true,
+		"data":       result.Data,
+		"total":      result.Total,
+		"page":       result.Page,
+		"limit":      result.Limit,
+		"totalPages": result.TotalPages,
+	})
+}
+
+// FindOne lấy thông tin cuộc trò chuyện
+func (h *ChatHandler) FindOne(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	chat, err := h.chatService.FindOne(userID, isAdmin, uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    chat,
+	})
+}
+
+// Close đóng cuộc trò chuyện
+func (h *ChatHandler) Close(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	chat, err := h.chatService.Close(userID, isAdmin, uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đóng cuộc trò chuyện thành công",
+		"data":    chat,
+	})
+}
+
+// Assign phân công admin phụ trách cuộc trò chuyện (Chỉ admin)
+func (h *ChatHandler) Assign(c *gin.Context) {
+	userID, _ := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.AssignChatRequest
+	// Body không bắt buộc - mặc định admin hiện tại tự nhận chat
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	chat, err := h.chatService.Assign(userID, uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Phân công cuộc trò chuyện thành công",
+		"data":    chat,
+	})
+}
+
+// ListMessages lấy tin nhắn của cuộc trò chuyện
+// Query: before (ID tin nhắn - tải tin cũ hơn), limit
+func (h *ChatHandler) ListMessages(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	before, _ := strconv.ParseUint(c.DefaultQuery("before", "0"), 10, 32)
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "50"))
+
+	messages, err := h.chatService.ListMessages(userID, isAdmin, uint(id), uint(before), limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    messages,
+	})
+}
+
+// SendMessage gửi tin nhắn qua REST (dành cho client không dùng WebSocket)
+func (h *ChatHandler) SendMessage(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.SendChatMessageRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	message, err := h.chatService.SendMessage(userID, isAdmin, uint(id), req.Content, req.Type)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"data":    message,
+	})
+}
+
+// MarkRead đánh dấu đã đọc toàn bộ tin nhắn của bên kia trong cuộc trò chuyện
+func (h *ChatHandler) MarkRead(c *gin.Context) {
+	userID, isAdmin := chatCaller(c)
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	receipt, err := h.chatService.MarkRead(userID, isAdmin, uint(id))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    receipt,
+	})
+}
+
+// IssueWSTicket đổi access token lấy ticket dùng một lần để mở WebSocket chat
+// @Summary Lấy ticket WebSocket
+// @Description Ticket sống 30 giây và chỉ dùng được một lần, truyền qua GET /chats/ws?ticket=...
+// @Tags chats
+// @Produce json
+// @Success 200 {object} dto.ChatWSTicketResponse
+// @Router /api/v1/chats/ws-ticket [post]
+func (h *ChatHandler) IssueWSTicket(c *gin.Context) {
+	claims, _ := c.Get("tokenClaims")
+
+	ticket, err := h.chatService.IssueWSTicket(claims.(*utils.Claims))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    ticket,
+	})
+}
+
+// ServeWS nâng cấp kết nối lên WebSocket để chat realtime
+// Xác thực bằng ticket dùng một lần (?ticket=) lấy từ POST /chats/ws-ticket, không nhận access token trong URL
+func (h *ChatHandler) ServeWS(c *gin.Context) {
+	auth, err := h.chatService.RedeemWSTicket(c.Query("ticket"))
+	if err != nil {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	conn, err := chatUpgrader.Upgrade(c.Writer, c.Request, nil)
+	if err != nil {
+		// Upgrader đã tự trả lỗi HTTP cho client
+		return
+	}
+
+	client := h.chatService.Connect(conn, auth)
+	go client.WritePump()
+	client.ReadPump(h.chatService.HandleClientMessage)
+}
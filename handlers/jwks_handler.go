@@ -0,0 +1,39 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ecommerce-be/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type JWKSHandler struct{}
+
+func NewJWKSHandler() *JWKSHandler {
+	return &JWKSHandler{}
+}
+
+// JWKS trả về public key dùng để xác thực access token
+// @Summary JSON Web Key Set
+// @Description Các public key đang được chấp nhận (khóa ký hiện tại và khóa cũ khi xoay khóa), chọn theo kid trong header token
+// @Tags auth
+// @Produce json
+// @Success 200 {object} map[string]interface{}
+// @Router /.well-known/jwks.json [get]
+func (h *JWKSHandler) JWKS(c *gin.Context) {
+	keys, err := utils.PublicJWKS()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   "Không thể lấy danh sách khóa",
+		})
+		return
+	}
+
+	// Cho phép cache ngắn để khóa mới được nhận nhanh khi xoay khóa
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, gin.H{
+		"keys": keys,
+	})
+}
@@ -0,0 +1,242 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type OrderHandler struct {
+	orderService     *services.OrderService
+	lifecycleService *services.OrderLifecycleService
+}
+
+func NewOrderHandler() *OrderHandler {
+	return &OrderHandler{
+		orderService:     services.NewOrderService(),
+		lifecycleService: services.NewOrderLifecycleService(),
+	}
+}
+
+// Checkout tạo đơn hàng từ giỏ hàng hiện tại
+// @Summary Đặt hàng
+// @Description Tạo đơn hàng từ giỏ hàng, trừ tồn kho và xóa giỏ hàng
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param checkout body dto.CheckoutRequest true "Thông tin đặt hàng"
+// @Success 201 {object} dto.OrderResponse
+// @Failure 400 {object} map[string]string
+// @Failure 404 {object} map[string]string
+// @Router /api/v1/orders/checkout [post]
+func (h *OrderHandler) Checkout(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req dto.CheckoutRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	order, err := h.orderService.Checkout(userID.(uint), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		switch {
+		case errors.Is(err, services.ErrShippingAddressNotFound):
+			status = http.StatusNotFound
+		case errors.Is(err, services.ErrCartEmpty),
+			errors.Is(err, services.ErrProductUnavailable),
+			errors.Is(err, services.ErrInsufficientStock):
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đặt hàng thành công",
+		"data":    order,
+	})
+}
+
+// Search tìm kiếm đơn hàng của user hiện tại
+// @Summary Lịch sử đơn hàng
+// @Description Tìm kiếm đơn hàng của user (phân trang, lọc theo trạng thái và khoảng thời gian)
+// @Tags orders
+// @Accept json
+// @Produce json
+// @Param search body dto.SearchOrderRequest false "Điều kiện tìm kiếm"
+// @Success 200 {object} dto.SearchOrderResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/orders/search [post]
+func (h *OrderHandler) Search(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	var req dto.SearchOrderRequest
+	// Cho phép body null/empty - nếu không có body thì lấy tất cả đơn hàng
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	result, err := h.orderService.Search(userID.(uint), req)
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrInvalidDateFilter) {
+			status = http.StatusBadRequest
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, dto.SearchOrderResponse{
+		Success:    true,
+		Message:    "Tìm kiếm đơn hàng thành công",
+		Data:       result.Data,
+		Total:      result.Total,
+		Page:       result.Page,
+		Limit:      result.Limit,
+		TotalPages: result.TotalPages,
+	})
+}
+
+// FindOne lấy chi tiết đơn hàng (chủ đơn hàng)
+func (h *OrderHandler) FindOne(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	order, err := h.orderService.FindOne(userID.(uint), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    order,
+	})
+}
+
+// GetHistory lấy lịch sử trạng thái của đơn hàng (chủ đơn hàng)
+func (h *OrderHandler) GetHistory(c *gin.Context) {
+	userID, exists := c.Get("userID")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{"error": "Unauthorized"})
+		return
+	}
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	history, err := h.lifecycleService.GetHistory(userID.(uint), uint(id))
+	if err != nil {
+		status := http.StatusInternalServerError
+		if errors.Is(err, services.ErrOrderNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    history,
+	})
+}
+
+// UpdateStatus chuyển trạng thái đơn hàng (Chỉ admin)
+func (h *OrderHandler) UpdateStatus(c *gin.Context) {
+	currentUserID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.UpdateOrderStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	order, err := h.lifecycleService.ChangeStatus(uint(id), req, currentUserID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật trạng thái đơn hàng thành công",
+		"data":    order,
+	})
+}
@@ -0,0 +1,215 @@
+package handlers
+
+import (
+	"errors"
+	"io"
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type PaymentHandler struct {
+	paymentService *services.PaymentService
+}
+
+func NewPaymentHandler() *PaymentHandler {
+	return &PaymentHandler{
+		paymentService: services.NewPaymentService(),
+	}
+}
+
+// CreatePayment tạo giao dịch thanh toán cho đơn hàng của chính mình
+// @Summary Thanh toán đơn hàng
+// @Description Tạo giao dịch thanh toán (COD, chuyển khoản...) cho đơn hàng
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param id path int true "Order ID"
+// @Param payment body dto.CreatePaymentRequest true "Thông tin thanh toán"
+// @Success 201 {object} dto.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/orders/{id}/payments [post]
+func (h *PaymentHandler) CreatePayment(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.CreatePaymentRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	payment, err := h.paymentService.CreatePayment(userID.(uint), uint(orderID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Tạo giao dịch thanh toán thành công",
+		"data":    payment,
+	})
+}
+
+// ListByOrder lấy danh sách giao dịch thanh toán của đơn hàng
+func (h *PaymentHandler) ListByOrder(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	orderID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	payments, err := h.paymentService.ListByOrder(userID.(uint), uint(orderID))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    payments,
+	})
+}
+
+// UpdateStatus xác nhận hoặc từ chối thanh toán (Chỉ admin)
+func (h *PaymentHandler) UpdateStatus(c *gin.Context) {
+	currentUserID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.UpdatePaymentStatusRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	payment, err := h.paymentService.UpdateStatus(uint(id), req, currentUserID.(uint))
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật trạng thái thanh toán thành công",
+		"data":    payment,
+	})
+}
+
+// Webhook nhận callback từ cổng thanh toán (Public - xác thực bằng chữ ký HMAC)
+// @Summary Webhook cổng thanh toán
+// @Description Nhận kết quả giao dịch từ cổng thanh toán, mỗi giao dịch chỉ được cập nhật một lần
+// @Tags payments
+// @Accept json
+// @Produce json
+// @Param provider path string true "Cổng thanh toán"
+// @Success 200 {object} dto.PaymentResponse
+// @Failure 400 {object} map[string]string
+// @Failure 401 {object} map[string]string
+// @Router /api/v1/payments/webhook/{provider} [post]
+func (h *PaymentHandler) Webhook(c *gin.Context) {
+	body, err := io.ReadAll(c.Request.Body)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "không thể đọc dữ liệu webhook",
+		})
+		return
+	}
+
+	payment, duplicate, err := h.paymentService.HandleWebhook(c.Param("provider"), c.Request.Header, body)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrInvalidWebhookSignature) {
+			status = http.StatusUnauthorized
+		} else if errors.Is(err, services.ErrUnknownPaymentProvider) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	message := "Đã cập nhật giao dịch"
+	if duplicate {
+		message = "Giao dịch đã được xử lý trước đó"
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":   true,
+		"message":   message,
+		"duplicate": duplicate,
+		"data":      payment,
+	})
+}
+
+// FakeGatewayPay giả lập kết quả thanh toán trên cổng giả lập (chỉ khi FAKE_GATEWAY_ENABLED=true)
+func (h *PaymentHandler) FakeGatewayPay(c *gin.Context) {
+	var req dto.FakeGatewayPayRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	result, err := h.paymentService.FakeGatewayPay(c.Param("transactionId"), req.Result == "success")
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    result,
+	})
+}
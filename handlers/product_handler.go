@@ -13,6 +13,7 @@ import (
 type ProductHandler struct {
 	productService   *services.ProductService
 	cloudinaryService *services.CloudinaryService
+	wishlistService  *services.WishlistService
 }
 
 func NewProductHandler() (*ProductHandler, error) {
@@ -24,6 +25,7 @@ func NewProductHandler() (*ProductHandler, error) {
 	return &ProductHandler{
 		productService:   services.NewProductService(),
 		cloudinaryService: cloudinaryService,
+		wishlistService:  services.NewWishlistService(),
 	}, nil
 }
 
@@ -111,6 +113,14 @@ func (h *ProductHandler) DeleteImage(c *gin.Context) {
 	c.JSON(http.StatusOK, deleteResult)
 }
 
+// searcherID định danh người tìm để thống kê từ khóa: user đã đăng nhập, ngược lại là IP
+func searcherID(c *gin.Context) string {
+	if userID, exists := c.Get("userID"); exists {
+		return "user:" + strconv.FormatUint(uint64(userID.(uint)), 10)
+	}
+	return "ip:" + c.ClientIP()
+}
+
 // Search tìm kiếm products (Public)
 // Gộp GET all vào POST search - nếu body null/empty thì hiển thị tất cả
 func (h *ProductHandler) Search(c *gin.Context) {
@@ -129,7 +139,7 @@ func (h *ProductHandler) Search(c *gin.Context) {
 		}
 	}
 
-	result, err := h.productService.Search(req, language)
+	result, err := h.productService.Search(req, language, searcherID(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -138,6 +148,17 @@ func (h *ProductHandler) Search(c *gin.Context) {
 		return
 	}
 
+	// Gắn cờ isWishlisted nếu người gọi đã đăng nhập
+	if userID, exists := c.Get("userID"); exists {
+		if err := h.wishlistService.MarkWishlisted(userID.(uint), result.Data); err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "không thể lấy danh sách yêu thích",
+			})
+			return
+		}
+	}
+
 	response := dto.SearchProductResponse{
 		Success:    true,
 		Message:    "Tìm kiếm sản phẩm thành công",
@@ -146,6 +167,8 @@ func (h *ProductHandler) Search(c *gin.Context) {
 		Page:       result.Page,
 		Limit:      result.Limit,
 		TotalPages: result.TotalPages,
+		NextCursor: result.NextCursor,
+		Facets:     result.Facets,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -488,7 +511,7 @@ func (h *ProductHandler) HardDelete(c *gin.Context) {
 
 // SearchSuggestions lấy danh sách gợi ý tìm kiếm dựa trên query (Public)
 // @Summary Lấy gợi ý tìm kiếm
-// @Description Lấy danh sách gợi ý tìm kiếm dựa trên query string
+// @Description Gợi ý từ khóa phổ biến, danh mục và sản phẩm (không phân biệt dấu, chấp nhận gõ sai), kèm vị trí tô sáng đoạn khớp
 // @Tags products
 // @Accept json
 // @Produce json
@@ -508,7 +531,7 @@ func (h *ProductHandler) SearchSuggestions(c *gin.Context) {
 		limit = l
 	}
 
-	suggestions, err := h.productService.GetSearchSuggestions(query, language, limit)
+	suggestions, err := h.productService.GetSearchSuggestions(query, language, limit, searcherID(c))
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
@@ -528,25 +551,29 @@ func (h *ProductHandler) SearchSuggestions(c *gin.Context) {
 
 // PopularSearches lấy danh sách từ khóa tìm kiếm phổ biến (Public)
 // @Summary Lấy từ khóa tìm kiếm phổ biến
-// @Description Lấy danh sách từ khóa tìm kiếm phổ biến nhất
+// @Description Lấy danh sách từ khóa người dùng tìm kiếm nhiều nhất trong khoảng thời gian gần đây
 // @Tags products
 // @Accept json
 // @Produce json
-// @Param language query string false "Ngôn ngữ (vi/en)" default(vi)
+// @Param hours query int false "Khoảng thời gian tính theo giờ (tối đa 168)" default(24)
 // @Param limit query int false "Số lượng searches" default(10)
 // @Success 200 {object} dto.PopularSearchesResponse
 // @Failure 400 {object} map[string]string
 // @Router /api/v1/products/popular-searches [get]
 func (h *ProductHandler) PopularSearches(c *gin.Context) {
-	language := c.DefaultQuery("language", "vi")
+	hoursStr := c.DefaultQuery("hours", "24")
 	limitStr := c.DefaultQuery("limit", "10")
 
+	hours := 24
+	if v, err := strconv.Atoi(hoursStr); err == nil {
+		hours = v
+	}
 	limit := 10
 	if l, err := strconv.Atoi(limitStr); err == nil {
 		limit = l
 	}
 
-	searches, err := h.productService.GetPopularSearches(language, limit)
+	searches, err := h.productService.GetPopularSearches(hours, limit)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
 			"success": false,
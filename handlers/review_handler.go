@@ -0,0 +1,189 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type ReviewHandler struct {
+	reviewService *services.ReviewService
+}
+
+func NewReviewHandler() *ReviewHandler {
+	return &ReviewHandler{
+		reviewService: services.NewReviewService(),
+	}
+}
+
+// ListByProduct lấy danh sách đánh giá của sản phẩm (Public)
+// @Summary Danh sách đánh giá sản phẩm
+// @Description Lấy đánh giá của sản phẩm (phân trang) kèm phân bố số sao
+// @Tags reviews
+// @Produce json
+// @Param id path int true "Product ID"
+// @Param rating query int false "Lọc theo số sao (1-5)"
+// @Param page query int false "Trang" default(1)
+// @Param limit query int false "Số lượng mỗi trang" default(10)
+// @Success 200 {object} dto.ReviewPaginationResponse
+// @Failure 400 {object} map[string]string
+// @Router /api/v1/products/{id}/reviews [get]
+func (h *ReviewHandler) ListByProduct(c *gin.Context) {
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	page, _ := strconv.Atoi(c.DefaultQuery("page", "1"))
+	limit, _ := strconv.Atoi(c.DefaultQuery("limit", "10"))
+
+	var rating *int
+	if ratingStr := c.Query("rating"); ratingStr != "" {
+		r, err := strconv.Atoi(ratingStr)
+		if err != nil || r < 1 || r > 5 {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "rating phải từ 1 đến 5",
+			})
+			return
+		}
+		rating = &r
+	}
+
+	result, err := h.reviewService.ListByProduct(uint(productID), rating, page, limit)
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success":            true,
+		"data":               result.Data,
+		"total":              result.Total,
+		"page":               result.Page,
+		"limit":              result.Limit,
+		"totalPages":         result.TotalPages,
+		"averageRating":      result.AverageRating,
+		"reviewCount":        result.ReviewCount,
+		"ratingDistribution": result.RatingDistribution,
+	})
+}
+
+// Create đánh giá sản phẩm
+func (h *ReviewHandler) Create(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	productID, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.CreateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	review, err := h.reviewService.Create(userID.(uint), uint(productID), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Đánh giá sản phẩm thành công",
+		"data":    review,
+	})
+}
+
+// Update sửa đánh giá của chính mình
+func (h *ReviewHandler) Update(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.UpdateReviewRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	review, err := h.reviewService.Update(userID.(uint), uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật đánh giá thành công",
+		"data":    review,
+	})
+}
+
+// Delete xóa đánh giá (chủ đánh giá hoặc admin)
+func (h *ReviewHandler) Delete(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	canModerate := middleware.HasPermission(c, models.PermissionReviewModerate)
+	if err := h.reviewService.Delete(userID.(uint), uint(id), canModerate); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Xóa đánh giá thành công",
+	})
+}
@@ -0,0 +1,236 @@
+package handlers
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoleHandler struct {
+	roleService *services.RoleService
+}
+
+func NewRoleHandler() *RoleHandler {
+	return &RoleHandler{
+		roleService: services.NewRoleService(),
+	}
+}
+
+// List lấy danh sách role kèm quyền
+// @Summary Danh sách role
+// @Tags admin
+// @Produce json
+// @Success 200 {array} dto.RoleResponse
+// @Router /api/v1/admin/roles [get]
+func (h *RoleHandler) List(c *gin.Context) {
+	roles, err := h.roleService.ListRoles()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    roles,
+	})
+}
+
+// ListPermissions lấy danh sách tất cả quyền có thể gán cho role
+// @Summary Danh sách quyền
+// @Tags admin
+// @Produce json
+// @Success 200 {array} dto.PermissionResponse
+// @Router /api/v1/admin/permissions [get]
+func (h *RoleHandler) ListPermissions(c *gin.Context) {
+	permissions, err := h.roleService.ListPermissions()
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    permissions,
+	})
+}
+
+// Create tạo role mới
+// @Summary Tạo role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param role body dto.CreateRoleRequest true "Thông tin role"
+// @Success 201 {object} dto.RoleResponse
+// @Router /api/v1/admin/roles [post]
+func (h *RoleHandler) Create(c *gin.Context) {
+	var req dto.CreateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	role, err := h.roleService.CreateRole(req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusCreated, gin.H{
+		"success": true,
+		"message": "Tạo role thành công",
+		"data":    role,
+	})
+}
+
+// Update cập nhật role và quyền của role
+// @Summary Cập nhật role
+// @Tags admin
+// @Accept json
+// @Produce json
+// @Param id path int true "Role ID"
+// @Param role body dto.UpdateRoleRequest true "Thông tin cập nhật"
+// @Success 200 {object} dto.RoleResponse
+// @Router /api/v1/admin/roles/{id} [patch]
+func (h *RoleHandler) Update(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.UpdateRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	role, err := h.roleService.UpdateRole(uint(id), req)
+	if err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrRoleNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật role thành công",
+		"data":    role,
+	})
+}
+
+// Delete xóa role không còn dùng
+// @Summary Xóa role
+// @Tags admin
+// @Produce json
+// @Param id path int true "Role ID"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/admin/roles/{id} [delete]
+func (h *RoleHandler) Delete(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	if err := h.roleService.DeleteRole(uint(id)); err != nil {
+		status := http.StatusBadRequest
+		if errors.Is(err, services.ErrRoleNotFound) {
+			status = http.StatusNotFound
+		}
+		c.JSON(status, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Xóa role thành công",
+	})
+}
+
+// AssignRole đổi role của user
+// @Summary Phân quyền cho user
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param id path int true "User ID"
+// @Param body body dto.AssignRoleRequest true "Role mới"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/users/{id}/role [put]
+func (h *RoleHandler) AssignRole(c *gin.Context) {
+	currentUserID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.AssignRoleRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.roleService.AssignRole(currentUserID.(uint), uint(id), req.Role)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Cập nhật role thành công",
+		"data": gin.H{
+			"id":    user.ID,
+			"email": user.Email,
+			"role":  user.Role,
+		},
+	})
+}
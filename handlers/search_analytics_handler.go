@@ -0,0 +1,54 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SearchAnalyticsHandler struct {
+	searchAnalyticsService *services.SearchAnalyticsService
+}
+
+func NewSearchAnalyticsHandler() *SearchAnalyticsHandler {
+	return &SearchAnalyticsHandler{
+		searchAnalyticsService: services.NewSearchAnalyticsService(),
+	}
+}
+
+// ZeroResults báo cáo các từ khóa khách tìm nhưng không có sản phẩm nào, để bổ sung/sửa catalog
+// @Summary Từ khóa tìm kiếm không có kết quả
+// @Tags admin
+// @Produce json
+// @Param hours query int false "Khoảng thời gian tính theo giờ (tối đa 168)" default(168)
+// @Param limit query int false "Số lượng từ khóa (tối đa 100)" default(50)
+// @Success 200 {array} dto.ZeroResultSearch
+// @Router /api/v1/admin/search/zero-results [get]
+func (h *SearchAnalyticsHandler) ZeroResults(c *gin.Context) {
+	hours, err := strconv.Atoi(c.DefaultQuery("hours", "168"))
+	if err != nil {
+		hours = 168
+	}
+	limit, err := strconv.Atoi(c.DefaultQuery("limit", "50"))
+	if err != nil {
+		limit = 50
+	}
+
+	searches, err := h.searchAnalyticsService.ZeroResults(hours, limit)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    searches,
+		"total":   len(searches),
+	})
+}
@@ -0,0 +1,95 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type SessionHandler struct {
+	sessionService *services.SessionService
+}
+
+func NewSessionHandler() *SessionHandler {
+	return &SessionHandler{
+		sessionService: services.NewSessionService(),
+	}
+}
+
+// List lấy danh sách thiết bị đang đăng nhập
+// @Summary Danh sách phiên đăng nhập
+// @Description Lấy các phiên đăng nhập còn hiệu lực của user, đánh dấu phiên hiện tại
+// @Tags users
+// @Produce json
+// @Success 200 {array} dto.SessionResponse
+// @Router /api/v1/users/sessions [get]
+func (h *SessionHandler) List(c *gin.Context) {
+	userID, _ := c.Get("userID")
+	sessionID := c.GetUint("sessionID")
+
+	sessions, err := h.sessionService.List(userID.(uint), sessionID)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    sessions,
+	})
+}
+
+// Revoke đăng xuất một thiết bị
+func (h *SessionHandler) Revoke(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	if err := h.sessionService.Revoke(userID.(uint), uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã đăng xuất thiết bị",
+	})
+}
+
+// RevokeAll đăng xuất khỏi tất cả thiết bị (kể cả thiết bị hiện tại)
+func (h *SessionHandler) RevokeAll(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	count, err := h.sessionService.RevokeAll(userID.(uint), services.SessionRevokedLogoutAll)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã đăng xuất khỏi tất cả thiết bị",
+		"data": gin.H{
+			"revoked": count,
+		},
+	})
+}
@@ -0,0 +1,173 @@
+package handlers
+
+import (
+	"net/http"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TwoFactorHandler struct {
+	twoFactorService *services.TwoFactorService
+}
+
+func NewTwoFactorHandler() *TwoFactorHandler {
+	return &TwoFactorHandler{
+		twoFactorService: services.NewTwoFactorService(),
+	}
+}
+
+// Status lấy trạng thái xác thực 2 lớp của tài khoản hiện tại
+// @Summary Trạng thái 2FA
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.TwoFactorStatusResponse
+// @Router /api/v1/users/2fa [get]
+func (h *TwoFactorHandler) Status(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	status, err := h.twoFactorService.Status(&user)
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    status,
+	})
+}
+
+// Setup tạo secret TOTP mới và trả về URI để quét QR code
+// @Summary Thiết lập 2FA
+// @Description Tạo secret (chưa bật), cần xác nhận bằng POST /users/2fa/enable
+// @Tags users
+// @Produce json
+// @Success 200 {object} dto.TwoFactorSetupResponse
+// @Router /api/v1/users/2fa/setup [post]
+func (h *TwoFactorHandler) Setup(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	setup, err := h.twoFactorService.Setup(&user)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    setup,
+	})
+}
+
+// Enable xác nhận mã TOTP đầu tiên để bật 2FA
+// @Summary Bật 2FA
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorCodeRequest true "Mã TOTP"
+// @Success 200 {object} dto.TwoFactorRecoveryCodesResponse
+// @Router /api/v1/users/2fa/enable [post]
+func (h *TwoFactorHandler) Enable(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.twoFactorService.Enable(&user, req.Code)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Bật xác thực 2 lớp thành công! Vui lòng lưu lại mã khôi phục.",
+		"data":    dto.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
+
+// Disable tắt 2FA (cần mã TOTP hoặc mã khôi phục)
+// @Summary Tắt 2FA
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorCodeRequest true "Mã TOTP hoặc mã khôi phục"
+// @Success 200 {object} map[string]interface{}
+// @Router /api/v1/users/2fa/disable [post]
+func (h *TwoFactorHandler) Disable(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	if err := h.twoFactorService.Disable(&user, req.Code, clientInfo(c)); err != nil {
+		respondAuthError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã tắt xác thực 2 lớp",
+	})
+}
+
+// RegenerateRecoveryCodes cấp bộ mã khôi phục mới, mã cũ không còn dùng được
+// @Summary Tạo lại mã khôi phục
+// @Tags users
+// @Accept json
+// @Produce json
+// @Param body body dto.TwoFactorCodeRequest true "Mã TOTP"
+// @Success 200 {object} dto.TwoFactorRecoveryCodesResponse
+// @Router /api/v1/users/2fa/recovery-codes [post]
+func (h *TwoFactorHandler) RegenerateRecoveryCodes(c *gin.Context) {
+	user := c.MustGet("user").(models.User)
+
+	var req dto.TwoFactorCodeRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	codes, err := h.twoFactorService.RegenerateRecoveryCodes(&user, req.Code, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã tạo mã khôi phục mới. Các mã cũ không còn hiệu lực.",
+		"data":    dto.TwoFactorRecoveryCodesResponse{RecoveryCodes: codes},
+	})
+}
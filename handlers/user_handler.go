@@ -5,6 +5,8 @@ import (
 	"strconv"
 
 	"ecommerce-be/dto"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
 	"ecommerce-be/services"
 
 	"github.com/gin-gonic/gin"
@@ -41,6 +43,15 @@ func (h *UserHandler) CreateUserByAdmin(c *gin.Context) {
 		return
 	}
 
+	// Gán role khác customer là phân quyền → cần quyền role:manage
+	if req.Role != nil && *req.Role != models.RoleCustomer && !middleware.HasPermission(c, models.PermissionRoleManage) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Bạn không có quyền phân role cho người dùng",
+		})
+		return
+	}
+
 	user, err := h.userService.CreateUserByAdmin(req)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -107,6 +118,7 @@ func (h *UserHandler) Search(c *gin.Context) {
 		Page:       result.Page,
 		Limit:      result.Limit,
 		TotalPages: result.TotalPages,
+		NextCursor: result.NextCursor,
 	}
 
 	c.JSON(http.StatusOK, response)
@@ -227,6 +239,75 @@ func (h *UserHandler) ChangePassword(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+// RequestEmailChange gửi OTP tới email mới của chính mình
+func (h *UserHandler) RequestEmailChange(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req dto.ChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.userService.RequestEmailChange(userID.(uint), req, clientInfo(c))
+	if err != nil {
+		respondAuthError(c, http.StatusBadRequest, err)
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// ConfirmEmailChange xác thực OTP và đổi email
+func (h *UserHandler) ConfirmEmailChange(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req dto.VerifyChangeEmailRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	user, err := h.userService.ConfirmEmailChange(userID.(uint), c.GetUint("sessionID"), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	response := dto.GetProfileResponse{
+		Success: true,
+		Message: "Đổi email thành công",
+		Data: dto.UserResponse{
+			ID:              user.ID,
+			Email:           user.Email,
+			Name:            user.Name,
+			Role:            user.Role,
+			Phone:           user.Phone,
+			Avatar:          user.Avatar,
+			Address:         user.Address,
+			Gender:          user.Gender,
+			IsEmailVerified: user.IsEmailVerified,
+			IsActive:        user.IsActive,
+			IsFirstLogin:    user.IsFirstLogin,
+			CreatedAt:       user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:       user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
 // UploadAvatar upload avatar cho chính mình
 func (h *UserHandler) UploadAvatar(c *gin.Context) {
 	if h.cloudinaryService == nil {
@@ -413,6 +494,14 @@ func (h *UserHandler) UpdateUserByAdmin(c *gin.Context) {
 		return
 	}
 
+	if req.Role != nil && !middleware.HasPermission(c, models.PermissionRoleManage) {
+		c.JSON(http.StatusForbidden, gin.H{
+			"success": false,
+			"error":   "Bạn không có quyền phân role cho người dùng",
+		})
+		return
+	}
+
 	user, err := h.userService.UpdateUserByAdmin(uint(id), req, currentUserIDUint)
 	if err != nil {
 		c.JSON(http.StatusBadRequest, gin.H{
@@ -559,3 +648,89 @@ func (h *UserHandler) ChangePasswordByAdmin(c *gin.Context) {
 	c.JSON(http.StatusOK, response)
 }
 
+
+// RequestEmailChangeByAdmin admin sửa email cho user: OTP được gửi tới email mới,
+// user nhập OTP (POST /users/change-email/verify) để hoàn tất
+func (h *UserHandler) RequestEmailChangeByAdmin(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.ChangeEmailByAdminRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	response, err := h.userService.RequestEmailChangeByAdmin(uint(id), req)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, response)
+}
+
+// GetLoginLockout admin xem trạng thái khóa đăng nhập của user
+func (h *UserHandler) GetLoginLockout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	lockout, err := h.userService.GetLoginLockout(uint(id))
+	if err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Lấy trạng thái khóa đăng nhập thành công",
+		"data":    lockout,
+	})
+}
+
+// ClearLoginLockout admin mở khóa đăng nhập cho user
+func (h *UserHandler) ClearLoginLockout(c *gin.Context) {
+	id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	if err := h.userService.ClearLoginLockout(uint(id)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã mở khóa đăng nhập cho người dùng",
+	})
+}
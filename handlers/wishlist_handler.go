@@ -0,0 +1,140 @@
+package handlers
+
+import (
+	"net/http"
+	"strconv"
+
+	"ecommerce-be/dto"
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+type WishlistHandler struct {
+	wishlistService *services.WishlistService
+}
+
+func NewWishlistHandler() *WishlistHandler {
+	return &WishlistHandler{
+		wishlistService: services.NewWishlistService(),
+	}
+}
+
+// List lấy danh sách sản phẩm yêu thích
+func (h *WishlistHandler) List(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	items, err := h.wishlistService.List(userID.(uint))
+	if err != nil {
+		c.JSON(http.StatusInternalServerError, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"data":    items,
+		"total":   len(items),
+	})
+}
+
+// Add thêm sản phẩm vào danh sách yêu thích
+func (h *WishlistHandler) Add(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	var req dto.AddToWishlistRequest
+	if err := c.ShouldBindJSON(&req); err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "Dữ liệu không hợp lệ",
+			"details": err.Error(),
+		})
+		return
+	}
+
+	item, err := h.wishlistService.Add(userID.(uint), req.ProductID)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã thêm sản phẩm vào danh sách yêu thích",
+		"data":    item,
+	})
+}
+
+// Remove xóa sản phẩm khỏi danh sách yêu thích
+func (h *WishlistHandler) Remove(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	if err := h.wishlistService.Remove(userID.(uint), uint(productID)); err != nil {
+		c.JSON(http.StatusNotFound, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã xóa sản phẩm khỏi danh sách yêu thích",
+	})
+}
+
+// MoveToCart chuyển sản phẩm từ danh sách yêu thích sang giỏ hàng
+func (h *WishlistHandler) MoveToCart(c *gin.Context) {
+	userID, _ := c.Get("userID")
+
+	productID, err := strconv.ParseUint(c.Param("productId"), 10, 32)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   "ID không hợp lệ",
+		})
+		return
+	}
+
+	var req dto.MoveToCartRequest
+	// Body không bắt buộc - mặc định chuyển 1 sản phẩm
+	if c.Request.ContentLength > 0 {
+		if err := c.ShouldBindJSON(&req); err != nil {
+			c.JSON(http.StatusBadRequest, gin.H{
+				"success": false,
+				"error":   "Dữ liệu không hợp lệ",
+				"details": err.Error(),
+			})
+			return
+		}
+	}
+
+	cartItem, err := h.wishlistService.MoveToCart(userID.(uint), uint(productID), req.Quantity)
+	if err != nil {
+		c.JSON(http.StatusBadRequest, gin.H{
+			"success": false,
+			"error":   err.Error(),
+		})
+		return
+	}
+
+	c.JSON(http.StatusOK, gin.H{
+		"success": true,
+		"message": "Đã chuyển sản phẩm vào giỏ hàng",
+		"data":    cartItem,
+	})
+}
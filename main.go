@@ -8,6 +8,7 @@ import (
 	"ecommerce-be/database"
 	"ecommerce-be/middleware"
 	"ecommerce-be/routes"
+	"ecommerce-be/utils"
 
 	"github.com/gin-gonic/gin"
 )
@@ -18,6 +19,11 @@ func main() {
 		log.Fatal("Failed to load config:", err)
 	}
 
+	// Load khóa ký JWT, khóa hash OTP, khóa mã hóa TOTP (production bắt buộc phải cấu hình đủ)
+	if err := utils.LoadSecrets(config.AppConfig.IsProduction()); err != nil {
+		log.Fatal("Failed to load secrets:", err)
+	}
+
 	// Connect to database
 	if err := database.ConnectDB(); err != nil {
 		log.Fatal("Failed to connect to database:", err)
@@ -1,9 +1,11 @@
 package middleware
 
 import (
+	"log"
 	"net/http"
 	"strings"
 
+	"ecommerce-be/cache"
 	"ecommerce-be/database"
 	"ecommerce-be/models"
 	"ecommerce-be/utils"
@@ -40,7 +42,7 @@ func AuthMiddleware() gin.HandlerFunc {
 
 		// Validate token
 		claims, err := utils.ValidateToken(token)
-		if err != nil {
+		if err != nil || claims.TokenType != utils.TokenTypeAccess { // Refresh token, token 2FA không được dùng để gọi API
 			c.JSON(http.StatusUnauthorized, gin.H{
 				"success": false,
 				"error":   "Token không hợp lệ hoặc đã hết hạn",
@@ -49,6 +51,21 @@ func AuthMiddleware() gin.HandlerFunc {
 			return
 		}
 
+		// Kiểm tra token đã bị thu hồi chưa (logout, thu hồi phiên, vô hiệu hóa tài khoản)
+		// Denylist lỗi thì vẫn cho qua: user vẫn được kiểm tra lại trong database bên dưới
+		denied, err := cache.IsTokenDenied(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAtTime())
+		if err != nil {
+			log.Printf("⚠️  Warning: Không thể kiểm tra token denylist: %v", err)
+		}
+		if denied {
+			c.JSON(http.StatusUnauthorized, gin.H{
+				"success": false,
+				"error":   "Token đã bị thu hồi, vui lòng đăng nhập lại",
+			})
+			c.Abort()
+			return
+		}
+
 		// Tìm user trong database
 		var user models.User
 		if err := database.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
@@ -75,12 +92,16 @@ func AuthMiddleware() gin.HandlerFunc {
 		c.Set("userEmail", user.Email)
 		c.Set("userRole", user.Role)
 		c.Set("user", user)
+		c.Set("sessionID", claims.SessionID)
+		c.Set("tokenClaims", claims)
 
 		c.Next()
 	}
 }
 
 // RoleMiddleware kiểm tra role của user
+//
+// Deprecated: dùng RequirePermission để kiểm tra theo quyền thay vì tên role
 func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 	return func(c *gin.Context) {
 		userRole, exists := c.Get("userRole")
@@ -114,3 +135,38 @@ func RoleMiddleware(allowedRoles ...string) gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// OptionalAuthMiddleware giống AuthMiddleware nhưng không bắt buộc đăng nhập
+// Nếu token hợp lệ thì lưu user vào context, ngược lại vẫn cho request đi tiếp như khách
+func OptionalAuthMiddleware() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		parts := strings.Split(c.GetHeader("Authorization"), " ")
+		if len(parts) != 2 || parts[0] != "Bearer" {
+			c.Next()
+			return
+		}
+
+		claims, err := utils.ValidateToken(parts[1])
+		if err != nil || claims.TokenType != utils.TokenTypeAccess {
+			c.Next()
+			return
+		}
+		if denied, _ := cache.IsTokenDenied(claims.ID, claims.SessionID, claims.UserID, claims.IssuedAtTime()); denied {
+			c.Next()
+			return
+		}
+
+		var user models.User
+		if err := database.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil || !user.IsActive {
+			c.Next()
+			return
+		}
+
+		c.Set("userID", user.ID)
+		c.Set("userEmail", user.Email)
+		c.Set("userRole", user.Role)
+		c.Set("user", user)
+
+		c.Next()
+	}
+}
@@ -12,34 +12,7 @@ func CORSMiddleware() gin.HandlerFunc {
 	return func(c *gin.Context) {
 		origin := c.Request.Header.Get("Origin")
 
-		// Danh sách các origin được phép
-		allowedOrigins := []string{
-			"http://localhost:5173", // Vite dev server
-			"http://localhost:3000", // React dev server
-			"http://localhost:8080", // Có thể cần cho testing
-		}
-
-		// Kiểm tra origin có trong danh sách được phép không
-		// Hoặc nếu là localhost/127.0.0.1/10.0.2.2 với bất kỳ port nào (cho development và Android emulator)
-		allowed := false
-		if origin != "" {
-			// Cho phép tất cả localhost trong development
-			if strings.HasPrefix(origin, "http://localhost:") ||
-				strings.HasPrefix(origin, "http://127.0.0.1:") ||
-				strings.HasPrefix(origin, "http://10.0.2.2:") { // Android emulator default IP
-				allowed = true
-			} else {
-				// Kiểm tra trong danh sách được phép
-				for _, allowedOrigin := range allowedOrigins {
-					if origin == allowedOrigin {
-						allowed = true
-						break
-					}
-				}
-			}
-		}
-
-		if allowed && origin != "" {
+		if origin != "" && IsAllowedOrigin(origin) {
 			c.Writer.Header().Set("Access-Control-Allow-Origin", origin)
 		}
 
@@ -56,3 +29,28 @@ func CORSMiddleware() gin.HandlerFunc {
 		c.Next()
 	}
 }
+
+// IsAllowedOrigin kiểm tra origin có được phép truy cập không (dùng chung cho CORS và WebSocket)
+func IsAllowedOrigin(origin string) bool {
+	// Danh sách các origin được phép
+	allowedOrigins := []string{
+		"http://localhost:5173", // Vite dev server
+		"http://localhost:3000", // React dev server
+		"http://localhost:8080", // Có thể cần cho testing
+	}
+
+	// Cho phép localhost/127.0.0.1/10.0.2.2 với bất kỳ port nào (cho development và Android emulator)
+	if strings.HasPrefix(origin, "http://localhost:") ||
+		strings.HasPrefix(origin, "http://127.0.0.1:") ||
+		strings.HasPrefix(origin, "http://10.0.2.2:") { // Android emulator default IP
+		return true
+	}
+
+	// Kiểm tra trong danh sách được phép
+	for _, allowedOrigin := range allowedOrigins {
+		if origin == allowedOrigin {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,145 @@
+package middleware
+
+import (
+	"log"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"ecommerce-be/services"
+
+	"github.com/gin-gonic/gin"
+)
+
+var roleService = services.NewRoleService()
+
+// RequirePermission kiểm tra role của user có đủ tất cả các quyền yêu cầu (dùng sau AuthMiddleware)
+// Ví dụ: RequirePermission("product:write")
+func RequirePermission(permissions ...string) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := callerRole(c)
+		if !ok {
+			return
+		}
+
+		granted, err := roleService.Permissions(role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Không thể kiểm tra quyền truy cập",
+			})
+			c.Abort()
+			return
+		}
+
+		for _, permission := range permissions {
+			if !granted[permission] {
+				c.JSON(http.StatusForbidden, gin.H{
+					"success": false,
+					"error":   "Bạn không có quyền truy cập. Yêu cầu quyền: " + strings.Join(permissions, ", "),
+				})
+				c.Abort()
+				return
+			}
+		}
+
+		c.Next()
+	}
+}
+
+// RequireStaff chỉ cho phép tài khoản nhân viên/admin (role có ít nhất một quyền quản trị)
+func RequireStaff() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := callerRole(c)
+		if !ok {
+			return
+		}
+
+		isStaff, err := roleService.IsStaff(role)
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Không thể kiểm tra quyền truy cập",
+			})
+			c.Abort()
+			return
+		}
+		if !isStaff {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Chức năng chỉ dành cho tài khoản quản trị",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// RequireManageableUser chặn sửa tài khoản nhân viên (user :id có quyền quản trị) nếu người gọi không có quyền role:manage
+func RequireManageableUser() gin.HandlerFunc {
+	return func(c *gin.Context) {
+		role, ok := callerRole(c)
+		if !ok {
+			return
+		}
+
+		id, err := strconv.ParseUint(c.Param("id"), 10, 32)
+		if err != nil {
+			c.Next() // Handler tự trả lỗi ID không hợp lệ
+			return
+		}
+
+		allowed, err := roleService.CanManageUser(role, uint(id))
+		if err != nil {
+			c.JSON(http.StatusInternalServerError, gin.H{
+				"success": false,
+				"error":   "Không thể kiểm tra quyền truy cập",
+			})
+			c.Abort()
+			return
+		}
+		if !allowed {
+			c.JSON(http.StatusForbidden, gin.H{
+				"success": false,
+				"error":   "Chỉ người có quyền quản lý role mới được sửa tài khoản nhân viên",
+			})
+			c.Abort()
+			return
+		}
+
+		c.Next()
+	}
+}
+
+// HasPermission kiểm tra quyền của user hiện tại trong handler (vd: chủ đánh giá hoặc người có quyền kiểm duyệt)
+func HasPermission(c *gin.Context, permission string) bool {
+	userRole, exists := c.Get("userRole")
+	if !exists {
+		return false
+	}
+	role, _ := userRole.(string)
+
+	granted, err := roleService.HasPermission(role, permission)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể kiểm tra quyền %s: %v", permission, err)
+		return false
+	}
+	return granted
+}
+
+// callerRole lấy role của user đã đăng nhập, trả 401 nếu chưa qua AuthMiddleware
+func callerRole(c *gin.Context) (string, bool) {
+	userRole, exists := c.Get("userRole")
+	if !exists {
+		c.JSON(http.StatusUnauthorized, gin.H{
+			"success": false,
+			"error":   "Bạn cần đăng nhập để truy cập tài nguyên này",
+		})
+		c.Abort()
+		return "", false
+	}
+	role, _ := userRole.(string)
+	return role, true
+}
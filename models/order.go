@@ -28,15 +28,17 @@ type Order struct {
 	Notes             *string        `json:"notes"` // Ghi chú của khách hàng
 	UserID            uint           `gorm:"not null" json:"userId"`
 	ShippingAddressID uint           `gorm:"not null" json:"shippingAddressId"`
+	ShippingSnapshot  *string        `gorm:"type:text" json:"shippingSnapshot"` // JSON snapshot địa chỉ giao hàng tại thời điểm đặt hàng
 	CreatedAt         time.Time      `json:"createdAt"`
 	UpdatedAt         time.Time      `json:"updatedAt"`
 	DeletedAt         gorm.DeletedAt `gorm:"index" json:"-"`
 
 	// Relationships
-	User            User        `gorm:"foreignKey:UserID" json:"user,omitempty"`
-	ShippingAddress Address     `gorm:"foreignKey:ShippingAddressID" json:"shippingAddress,omitempty"`
-	Items           []OrderItem `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
-	Payments        []Payment   `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	User            User                 `gorm:"foreignKey:UserID" json:"user,omitempty"`
+	ShippingAddress Address              `gorm:"foreignKey:ShippingAddressID" json:"shippingAddress,omitempty"`
+	Items           []OrderItem          `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"items,omitempty"`
+	Payments        []Payment            `gorm:"foreignKey:OrderID" json:"payments,omitempty"`
+	StatusHistory   []OrderStatusHistory `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"statusHistory,omitempty"`
 }
 
 func (Order) TableName() string {
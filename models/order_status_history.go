@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// OrderStatusHistory lưu lại mỗi lần chuyển trạng thái của đơn hàng
+type OrderStatusHistory struct {
+	ID          uint        `gorm:"primaryKey" json:"id"`
+	OrderID     uint        `gorm:"not null;index" json:"orderId"`
+	FromStatus  OrderStatus `gorm:"type:varchar(50)" json:"fromStatus"` // Rỗng nếu là trạng thái khởi tạo
+	ToStatus    OrderStatus `gorm:"type:varchar(50);not null" json:"toStatus"`
+	ChangedByID *uint       `json:"changedById"` // User thực hiện (nil nếu do hệ thống)
+	Reason      *string     `gorm:"type:text" json:"reason"`
+	CreatedAt   time.Time   `json:"createdAt"`
+
+	// Relationships
+	Order     Order `gorm:"foreignKey:OrderID;constraint:OnDelete:CASCADE" json:"order,omitempty"`
+	ChangedBy *User `gorm:"foreignKey:ChangedByID" json:"changedBy,omitempty"`
+}
+
+func (OrderStatusHistory) TableName() string {
+	return "order_status_histories"
+}
@@ -0,0 +1,53 @@
+package models
+
+import (
+	"time"
+)
+
+// Mã quyền dạng "resource:action", được gán cho role qua bảng role_permissions
+const (
+	PermissionProductWrite   = "product:write"   // Tạo/sửa/ẩn sản phẩm, upload ảnh sản phẩm
+	PermissionProductDelete  = "product:delete"  // Xóa vĩnh viễn sản phẩm
+	PermissionCategoryWrite  = "category:write"  // Tạo/sửa/ẩn danh mục, quản lý danh mục con
+	PermissionCategoryDelete = "category:delete" // Xóa vĩnh viễn danh mục
+	PermissionMediaUpload    = "media:upload"    // Upload/xóa ảnh qua Cloudinary
+	PermissionOrderRead      = "order:read"      // Xem và tìm kiếm đơn hàng của mọi user
+	PermissionOrderWrite     = "order:write"     // Chuyển trạng thái đơn hàng
+	PermissionPaymentWrite   = "payment:write"   // Xác nhận / từ chối thanh toán
+	PermissionReviewModerate = "review:moderate" // Xóa đánh giá của người khác
+	PermissionChatSupport    = "chat:support"    // Xem và trả lời mọi cuộc trò chuyện hỗ trợ
+	PermissionChatAssign     = "chat:assign"     // Phân công cuộc trò chuyện cho nhân viên hỗ trợ
+	PermissionUserRead       = "user:read"       // Xem và tìm kiếm user
+	PermissionUserWrite      = "user:write"      // Tạo/sửa user, đổi mật khẩu/email, mở khóa đăng nhập
+	PermissionRoleManage     = "role:manage"     // Quản lý role và phân quyền cho user
+)
+
+// PermissionCatalog là danh sách quyền hệ thống hỗ trợ (được đồng bộ vào bảng permissions khi migrate)
+var PermissionCatalog = []Permission{
+	{Code: PermissionProductWrite, Description: "Tạo, sửa, ẩn sản phẩm và upload ảnh sản phẩm"},
+	{Code: PermissionProductDelete, Description: "Xóa vĩnh viễn sản phẩm"},
+	{Code: PermissionCategoryWrite, Description: "Tạo, sửa, ẩn danh mục và quản lý danh mục con"},
+	{Code: PermissionCategoryDelete, Description: "Xóa vĩnh viễn danh mục"},
+	{Code: PermissionMediaUpload, Description: "Upload và xóa ảnh qua Cloudinary"},
+	{Code: PermissionOrderRead, Description: "Xem và tìm kiếm đơn hàng của mọi khách hàng"},
+	{Code: PermissionOrderWrite, Description: "Chuyển trạng thái đơn hàng"},
+	{Code: PermissionPaymentWrite, Description: "Xác nhận hoặc từ chối thanh toán"},
+	{Code: PermissionReviewModerate, Description: "Xóa đánh giá vi phạm của khách hàng"},
+	{Code: PermissionChatSupport, Description: "Xem và trả lời mọi cuộc trò chuyện hỗ trợ"},
+	{Code: PermissionChatAssign, Description: "Phân công cuộc trò chuyện cho nhân viên hỗ trợ"},
+	{Code: PermissionUserRead, Description: "Xem và tìm kiếm tài khoản người dùng"},
+	{Code: PermissionUserWrite, Description: "Tạo, sửa tài khoản người dùng, đổi mật khẩu/email, mở khóa đăng nhập"},
+	{Code: PermissionRoleManage, Description: "Quản lý role và phân quyền cho người dùng"},
+}
+
+type Permission struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Code        string    `gorm:"type:varchar(100);uniqueIndex;not null" json:"code"`
+	Description string    `gorm:"type:varchar(255)" json:"description"`
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+}
+
+func (Permission) TableName() string {
+	return "permissions"
+}
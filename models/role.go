@@ -0,0 +1,54 @@
+package models
+
+import (
+	"time"
+)
+
+// Role hệ thống: không xóa được. Admin luôn có toàn bộ quyền, customer không có quyền quản trị nào
+const (
+	RoleAdmin    = "admin"
+	RoleCustomer = "customer"
+)
+
+// DefaultRoles là các role được tạo sẵn khi migrate (chỉ tạo nếu chưa có, không ghi đè chỉnh sửa của admin)
+var DefaultRoles = []struct {
+	Name        string
+	DisplayName string
+	Permissions []string
+}{
+	{Name: RoleAdmin, DisplayName: "Quản trị viên"},
+	{Name: RoleCustomer, DisplayName: "Khách hàng"},
+	{
+		Name:        "catalog_manager",
+		DisplayName: "Quản lý sản phẩm",
+		Permissions: []string{PermissionProductWrite, PermissionProductDelete, PermissionCategoryWrite, PermissionCategoryDelete, PermissionMediaUpload, PermissionReviewModerate},
+	},
+	{
+		Name:        "order_operator",
+		DisplayName: "Nhân viên xử lý đơn hàng",
+		Permissions: []string{PermissionOrderRead, PermissionOrderWrite, PermissionPaymentWrite, PermissionUserRead},
+	},
+	{
+		Name:        "support_agent",
+		DisplayName: "Nhân viên hỗ trợ",
+		Permissions: []string{PermissionChatSupport, PermissionChatAssign, PermissionOrderRead, PermissionUserRead},
+	},
+}
+
+// Role - Vai trò của user (User.Role lưu Role.Name). Quyền của user là quyền của role
+type Role struct {
+	ID          uint      `gorm:"primaryKey" json:"id"`
+	Name        string    `gorm:"type:varchar(50);uniqueIndex;not null" json:"name"` // Key dùng trong User.Role, vd: catalog_manager
+	DisplayName string    `gorm:"type:varchar(100);not null" json:"displayName"`
+	Description *string   `gorm:"type:text" json:"description"`
+	IsSystem    bool      `gorm:"default:false" json:"isSystem"` // admin, customer: không xóa/đổi tên được
+	CreatedAt   time.Time `json:"createdAt"`
+	UpdatedAt   time.Time `json:"updatedAt"`
+
+	// Relationships
+	Permissions []Permission `gorm:"many2many:role_permissions;constraint:OnDelete:CASCADE" json:"permissions,omitempty"`
+}
+
+func (Role) TableName() string {
+	return "roles"
+}
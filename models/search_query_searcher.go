@@ -0,0 +1,20 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchQuerySearcher - Người tìm (hash IP/user) của từ khóa trong từng giờ, dùng để đếm số người tìm khác nhau
+// Chỉ dùng khi không có Redis (có Redis thì dùng HyperLogLog)
+type SearchQuerySearcher struct {
+	ID           uint      `gorm:"primaryKey" json:"id"`
+	Term         string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_search_query_searchers_bucket" json:"term"`
+	Source       string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_search_query_searchers_bucket" json:"source"`
+	Bucket       time.Time `gorm:"not null;uniqueIndex:idx_search_query_searchers_bucket;index" json:"bucket"` // Đầu giờ (UTC)
+	SearcherHash string    `gorm:"type:varchar(64);not null;uniqueIndex:idx_search_query_searchers_bucket" json:"-"`
+	CreatedAt    time.Time `json:"createdAt"`
+}
+
+func (SearchQuerySearcher) TableName() string {
+	return "search_query_searchers"
+}
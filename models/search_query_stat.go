@@ -0,0 +1,22 @@
+package models
+
+import (
+	"time"
+)
+
+// SearchQueryStat - Số lượt tìm kiếm theo từ khóa, gộp theo từng giờ
+// Chỉ dùng khi không có Redis (có Redis thì thống kê nằm trong sorted set)
+type SearchQueryStat struct {
+	ID              uint      `gorm:"primaryKey" json:"id"`
+	Term            string    `gorm:"type:varchar(100);not null;uniqueIndex:idx_search_query_stats_bucket" json:"term"`  // Từ khóa đã chuẩn hóa
+	Source          string    `gorm:"type:varchar(20);not null;uniqueIndex:idx_search_query_stats_bucket" json:"source"` // search, suggest
+	Bucket          time.Time `gorm:"not null;uniqueIndex:idx_search_query_stats_bucket;index" json:"bucket"`            // Đầu giờ (UTC)
+	SearchCount     int64     `gorm:"not null;default:0" json:"searchCount"`
+	ZeroResultCount int64     `gorm:"not null;default:0" json:"zeroResultCount"` // Số lượt không có kết quả
+	CreatedAt       time.Time `json:"createdAt"`
+	UpdatedAt       time.Time `json:"updatedAt"`
+}
+
+func (SearchQueryStat) TableName() string {
+	return "search_query_stats"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// UserIdentity - Tài khoản mạng xã hội (Google, Apple...) được liên kết với user
+// Một user có thể vừa đăng nhập bằng mật khẩu vừa bằng nhiều tài khoản mạng xã hội
+type UserIdentity struct {
+	ID        uint      `gorm:"primaryKey" json:"id"`
+	UserID    uint      `gorm:"not null;index" json:"userId"`
+	Provider  string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_user_identities_provider_subject" json:"provider"` // google, apple
+	Subject   string    `gorm:"type:varchar(255);not null;uniqueIndex:idx_user_identities_provider_subject" json:"subject"` // Claim "sub" trong ID token
+	Email     *string   `gorm:"type:varchar(255)" json:"email"`                                                             // Email provider trả về lúc liên kết
+	CreatedAt time.Time `json:"createdAt"`
+	UpdatedAt time.Time `json:"updatedAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (UserIdentity) TableName() string {
+	return "user_identities"
+}
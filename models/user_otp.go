@@ -0,0 +1,26 @@
+package models
+
+import (
+	"time"
+)
+
+// UserOtp - Mã OTP đang chờ xác thực của user, mỗi mục đích (purpose) một dòng
+// Chỉ lưu HMAC của mã, mã gốc chỉ được gửi qua email
+type UserOtp struct {
+	ID         uint      `gorm:"primaryKey" json:"id"`
+	UserID     uint      `gorm:"not null;uniqueIndex:idx_user_otps_user_purpose" json:"userId"`
+	Purpose    string    `gorm:"type:varchar(30);not null;uniqueIndex:idx_user_otps_user_purpose" json:"purpose"` // verify_email, reset_password, change_email
+	CodeHash   string    `gorm:"type:varchar(64);not null" json:"-"`                                              // HMAC-SHA256 của mã OTP
+	Target     *string   `gorm:"type:varchar(255)" json:"target"`                                                 // Giá trị chờ xác nhận (vd: email mới khi đổi email)
+	ExpiresAt  time.Time `gorm:"not null" json:"expiresAt"`
+	LastSentAt time.Time `gorm:"not null" json:"lastSentAt"`
+	CreatedAt  time.Time `json:"createdAt"`
+	UpdatedAt  time.Time `json:"updatedAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (UserOtp) TableName() string {
+	return "user_otps"
+}
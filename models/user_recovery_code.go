@@ -0,0 +1,21 @@
+package models
+
+import (
+	"time"
+)
+
+// UserRecoveryCode - Mã khôi phục dùng một lần khi mất thiết bị xác thực 2 lớp
+type UserRecoveryCode struct {
+	ID        uint       `gorm:"primaryKey" json:"id"`
+	UserID    uint       `gorm:"not null;index" json:"userId"`
+	CodeHash  string     `gorm:"type:varchar(64);not null" json:"-"` // SHA-256 của mã
+	UsedAt    *time.Time `json:"usedAt"`
+	CreatedAt time.Time  `json:"createdAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (UserRecoveryCode) TableName() string {
+	return "user_recovery_codes"
+}
@@ -0,0 +1,30 @@
+package models
+
+import (
+	"time"
+)
+
+// UserSession - Một phiên đăng nhập (một thiết bị), tương ứng với một refresh token
+// Mỗi lần refresh, token được rotate ngay trên dòng này. Token cũ bị dùng lại → thu hồi cả phiên
+type UserSession struct {
+	ID            uint       `gorm:"primaryKey" json:"id"`
+	UserID        uint       `gorm:"not null;index" json:"userId"`
+	TokenHash     string     `gorm:"type:varchar(64);uniqueIndex;not null" json:"-"` // SHA-256 của refresh token hiện tại
+	DeviceName    *string    `gorm:"type:varchar(255)" json:"deviceName"`
+	IPAddress     string     `gorm:"type:varchar(64)" json:"ipAddress"`
+	UserAgent     string     `gorm:"type:text" json:"userAgent"`
+	RotationCount int        `gorm:"default:0" json:"rotationCount"`
+	LastUsedAt    time.Time  `json:"lastUsedAt"`
+	ExpiresAt     time.Time  `gorm:"index" json:"expiresAt"`
+	RevokedAt     *time.Time `gorm:"index" json:"revokedAt"`
+	RevokedReason *string    `gorm:"type:varchar(100)" json:"revokedReason"` // logout, logout_all, reuse_detected...
+	CreatedAt     time.Time  `json:"createdAt"`
+	UpdatedAt     time.Time  `json:"updatedAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (UserSession) TableName() string {
+	return "user_sessions"
+}
@@ -0,0 +1,24 @@
+package models
+
+import (
+	"time"
+)
+
+// UserTwoFactor - Cấu hình xác thực 2 lớp (TOTP) của user
+// Secret được mã hóa (AES-GCM) trước khi lưu; EnabledAt = nil nghĩa là đang chờ xác nhận mã đầu tiên
+type UserTwoFactor struct {
+	ID              uint       `gorm:"primaryKey" json:"id"`
+	UserID          uint       `gorm:"not null;uniqueIndex" json:"userId"`
+	EncryptedSecret string     `gorm:"type:text;not null" json:"-"`
+	EnabledAt       *time.Time `json:"enabledAt"`
+	LastUsedStep    int64      `gorm:"default:0" json:"-"` // Bước thời gian của mã TOTP dùng gần nhất, chặn dùng lại cùng một mã
+	CreatedAt       time.Time  `json:"createdAt"`
+	UpdatedAt       time.Time  `json:"updatedAt"`
+
+	// Relationships
+	User User `gorm:"foreignKey:UserID" json:"user,omitempty"`
+}
+
+func (UserTwoFactor) TableName() string {
+	return "user_two_factors"
+}
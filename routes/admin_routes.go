@@ -0,0 +1,40 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupAdminRoutes - Thiết lập routes cho trang quản trị
+func SetupAdminRoutes(api *gin.RouterGroup) {
+	adminOrderHandler := handlers.NewAdminOrderHandler()
+	roleHandler := handlers.NewRoleHandler()
+	searchAnalyticsHandler := handlers.NewSearchAnalyticsHandler()
+
+	admin := api.Group("/admin")
+	admin.Use(middleware.AuthMiddleware())
+	{
+		orders := admin.Group("/orders")
+		{
+			orders.POST("/search", middleware.RequirePermission(models.PermissionOrderRead), adminOrderHandler.Search)             // Tìm kiếm đơn hàng + số đơn theo trạng thái
+			orders.PATCH("/status", middleware.RequirePermission(models.PermissionOrderWrite), adminOrderHandler.BulkUpdateStatus) // Chuyển trạng thái nhiều đơn hàng
+		}
+
+		// Quản lý role và quyền
+		roles := admin.Group("/roles")
+		roles.Use(middleware.RequirePermission(models.PermissionRoleManage))
+		{
+			roles.GET("", roleHandler.List)
+			roles.POST("", roleHandler.Create)
+			roles.PATCH("/:id", roleHandler.Update)
+			roles.DELETE("/:id", roleHandler.Delete)
+		}
+		admin.GET("/permissions", middleware.RequirePermission(models.PermissionRoleManage), roleHandler.ListPermissions)
+
+		// Thống kê tìm kiếm - từ khóa không có kết quả để bổ sung catalog
+		admin.GET("/search/zero-results", middleware.RequirePermission(models.PermissionProductWrite), searchAnalyticsHandler.ZeroResults)
+	}
+}
@@ -2,6 +2,7 @@ package routes
 
 import (
 	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
 
 	"github.com/gin-gonic/gin"
 )
@@ -12,9 +13,16 @@ func SetupAuthRoutes(api *gin.RouterGroup) {
 	{
 		auth.POST("/register", authHandler.Register)
 		auth.POST("/login", authHandler.Login)
+		auth.POST("/oauth/:provider", authHandler.OAuthLogin)
 		auth.POST("/verify-otp", authHandler.VerifyOtp)
+		auth.POST("/2fa/verify", authHandler.VerifyTwoFactor)
+		auth.POST("/2fa/setup", authHandler.SetupTwoFactor)
+		auth.POST("/2fa/setup/confirm", authHandler.ConfirmTwoFactorSetup)
 		auth.POST("/resend-otp", authHandler.ResendOtp)
 		auth.POST("/refresh", authHandler.RefreshToken)
+		auth.POST("/forgot-password", authHandler.ForgotPassword)
+		auth.POST("/reset-password", authHandler.ResetPassword)
+		auth.POST("/logout", middleware.AuthMiddleware(), authHandler.Logout)
 	}
 }
 
@@ -5,6 +5,7 @@ import (
 
 	"ecommerce-be/handlers"
 	"ecommerce-be/middleware"
+	"ecommerce-be/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -28,21 +29,20 @@ func SetupCategoryRoutes(api *gin.RouterGroup) {
 		categories.GET("/:id/children", categoryHandler.GetChildren) // Lấy danh sách children của một parent
 		categories.GET("/:id", categoryHandler.FindOne)              // Lấy một category theo ID
 
-		// Admin only routes (yêu cầu auth + admin role)
+		// Quản lý danh mục (yêu cầu auth + quyền category:write / category:delete)
 		adminRoutes := categories.Group("")
 		adminRoutes.Use(middleware.AuthMiddleware())
-		adminRoutes.Use(middleware.RoleMiddleware("admin"))
 		{
-			adminRoutes.POST("/upload-image", categoryHandler.UploadImage)
-			adminRoutes.DELETE("/delete-image", categoryHandler.DeleteImage)
-			adminRoutes.POST("", categoryHandler.Create)
-			adminRoutes.PATCH("/:id", categoryHandler.Update)
-			adminRoutes.PUT("/:id", categoryHandler.Replace)
-			adminRoutes.DELETE("/:id", categoryHandler.Remove)
-			adminRoutes.DELETE("/:id/hard", categoryHandler.HardDelete)
+			adminRoutes.POST("/upload-image", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.UploadImage)
+			adminRoutes.DELETE("/delete-image", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.DeleteImage)
+			adminRoutes.POST("", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.Create)
+			adminRoutes.PATCH("/:id", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.Update)
+			adminRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.Replace)
+			adminRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.Remove)
+			adminRoutes.DELETE("/:id/hard", middleware.RequirePermission(models.PermissionCategoryDelete), categoryHandler.HardDelete)
 			// Quản lý children
-			adminRoutes.POST("/:id/children", categoryHandler.AddChild)
-			adminRoutes.DELETE("/:id/children", categoryHandler.RemoveChild)
+			adminRoutes.POST("/:id/children", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.AddChild)
+			adminRoutes.DELETE("/:id/children", middleware.RequirePermission(models.PermissionCategoryWrite), categoryHandler.RemoveChild)
 		}
 	}
 }
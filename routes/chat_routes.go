@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupChatRoutes - Thiết lập routes cho chat hỗ trợ khách hàng
+func SetupChatRoutes(api *gin.RouterGroup) {
+	chatHandler := handlers.NewChatHandler()
+
+	chats := api.Group("/chats")
+
+	// WebSocket - trình duyệt không gửi được header nên xác thực bằng ticket dùng một lần (?ticket=) lấy từ /ws-ticket
+	chats.GET("/ws", chatHandler.ServeWS)
+
+	authRoutes := chats.Group("")
+	authRoutes.Use(middleware.AuthMiddleware()) // Yêu cầu đăng nhập
+	{
+		authRoutes.POST("/ws-ticket", chatHandler.IssueWSTicket)  // Lấy ticket mở WebSocket
+		authRoutes.POST("", chatHandler.Open)                     // Mở cuộc trò chuyện
+		authRoutes.GET("", chatHandler.List)                      // Danh sách cuộc trò chuyện
+		authRoutes.GET("/:id", chatHandler.FindOne)               // Chi tiết cuộc trò chuyện
+		authRoutes.PATCH("/:id/close", chatHandler.Close)         // Đóng cuộc trò chuyện
+		authRoutes.GET("/:id/messages", chatHandler.ListMessages) // Lịch sử tin nhắn
+		authRoutes.POST("/:id/messages", chatHandler.SendMessage) // Gửi tin nhắn (REST)
+		authRoutes.PATCH("/:id/read", chatHandler.MarkRead)       // Đánh dấu đã đọc
+
+		// Phân công nhân viên hỗ trợ
+		authRoutes.PATCH("/:id/assign", middleware.RequirePermission(models.PermissionChatAssign), chatHandler.Assign)
+	}
+}
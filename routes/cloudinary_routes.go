@@ -5,6 +5,7 @@ import (
 
 	"ecommerce-be/handlers"
 	"ecommerce-be/middleware"
+	"ecommerce-be/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,7 +20,7 @@ func SetupCloudinaryRoutes(api *gin.RouterGroup) {
 
 	cloudinary := api.Group("/cloudinary")
 	cloudinary.Use(middleware.AuthMiddleware()) // Yêu cầu đăng nhập
-	cloudinary.Use(middleware.RoleMiddleware("admin")) // Chỉ admin
+	cloudinary.Use(middleware.RequirePermission(models.PermissionMediaUpload))
 	{
 		cloudinary.POST("/upload-image", cloudinaryHandler.UploadImage)
 		cloudinary.DELETE("/delete-image", cloudinaryHandler.DeleteImage)
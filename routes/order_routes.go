@@ -0,0 +1,29 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupOrderRoutes - Thiết lập routes cho orders
+func SetupOrderRoutes(api *gin.RouterGroup) {
+	orderHandler := handlers.NewOrderHandler()
+	paymentHandler := handlers.NewPaymentHandler()
+
+	orders := api.Group("/orders")
+	orders.Use(middleware.AuthMiddleware()) // Yêu cầu đăng nhập
+	{
+		orders.POST("/checkout", orderHandler.Checkout)     // Đặt hàng từ giỏ hàng
+		orders.POST("/search", orderHandler.Search)         // Danh sách đơn hàng của user
+		orders.GET("/:id", orderHandler.FindOne)            // Chi tiết đơn hàng
+		orders.GET("/:id/history", orderHandler.GetHistory) // Lịch sử trạng thái đơn hàng
+		orders.POST("/:id/payments", paymentHandler.CreatePayment)
+		orders.GET("/:id/payments", paymentHandler.ListByOrder)
+
+		// Nhân viên xử lý đơn hàng
+		orders.PATCH("/:id/status", middleware.RequirePermission(models.PermissionOrderWrite), orderHandler.UpdateStatus)
+	}
+}
@@ -0,0 +1,35 @@
+package routes
+
+import (
+	"os"
+
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+	"ecommerce-be/models"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupPaymentRoutes - Thiết lập routes cho payments
+func SetupPaymentRoutes(api *gin.RouterGroup) {
+	paymentHandler := handlers.NewPaymentHandler()
+
+	payments := api.Group("/payments")
+	{
+		// Public routes - cổng thanh toán gọi về, xác thực bằng chữ ký
+		payments.POST("/webhook/:provider", paymentHandler.Webhook)
+
+		// Cổng thanh toán giả lập để test round trip (không bật trên production)
+		if os.Getenv("FAKE_GATEWAY_ENABLED") == "true" {
+			payments.POST("/fake-gateway/:transactionId", paymentHandler.FakeGatewayPay)
+		}
+
+		// Nhân viên xác nhận thanh toán
+		adminRoutes := payments.Group("")
+		adminRoutes.Use(middleware.AuthMiddleware())
+		adminRoutes.Use(middleware.RequirePermission(models.PermissionPaymentWrite))
+		{
+			adminRoutes.PATCH("/:id/status", paymentHandler.UpdateStatus) // Xác nhận / từ chối thanh toán
+		}
+	}
+}
@@ -5,6 +5,7 @@ import (
 
 	"ecommerce-be/handlers"
 	"ecommerce-be/middleware"
+	"ecommerce-be/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -19,23 +20,22 @@ func SetupProductRoutes(api *gin.RouterGroup) {
 	products := api.Group("/products")
 	{
 		// Public routes (không yêu cầu auth)
-		products.POST("/search", productHandler.Search)
+		products.POST("/search", middleware.OptionalAuthMiddleware(), productHandler.Search) // isWishlisted khi đã đăng nhập
 		products.GET("/search-suggestions", productHandler.SearchSuggestions)
 		products.GET("/popular-searches", productHandler.PopularSearches)
 		products.GET("/:id", productHandler.FindOne)
 
-		// Admin only routes (yêu cầu auth + admin role)
+		// Quản lý sản phẩm (yêu cầu auth + quyền product:write / product:delete)
 		adminRoutes := products.Group("")
 		adminRoutes.Use(middleware.AuthMiddleware())
-		adminRoutes.Use(middleware.RoleMiddleware("admin"))
 		{
-			adminRoutes.POST("/upload-image", productHandler.UploadImage)
-			adminRoutes.DELETE("/delete-image", productHandler.DeleteImage)
-			adminRoutes.POST("", productHandler.Create)
-			adminRoutes.PATCH("/:id", productHandler.Update)
-			adminRoutes.PUT("/:id", productHandler.Replace)
-			adminRoutes.DELETE("/:id", productHandler.Remove)
-			adminRoutes.DELETE("/:id/hard", productHandler.HardDelete)
+			adminRoutes.POST("/upload-image", middleware.RequirePermission(models.PermissionProductWrite), productHandler.UploadImage)
+			adminRoutes.DELETE("/delete-image", middleware.RequirePermission(models.PermissionProductWrite), productHandler.DeleteImage)
+			adminRoutes.POST("", middleware.RequirePermission(models.PermissionProductWrite), productHandler.Create)
+			adminRoutes.PATCH("/:id", middleware.RequirePermission(models.PermissionProductWrite), productHandler.Update)
+			adminRoutes.PUT("/:id", middleware.RequirePermission(models.PermissionProductWrite), productHandler.Replace)
+			adminRoutes.DELETE("/:id", middleware.RequirePermission(models.PermissionProductWrite), productHandler.Remove)
+			adminRoutes.DELETE("/:id/hard", middleware.RequirePermission(models.PermissionProductDelete), productHandler.HardDelete)
 		}
 	}
 }
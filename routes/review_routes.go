@@ -0,0 +1,24 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupReviewRoutes - Thiết lập routes cho đánh giá sản phẩm
+func SetupReviewRoutes(api *gin.RouterGroup) {
+	reviewHandler := handlers.NewReviewHandler()
+
+	// Đánh giá theo sản phẩm
+	api.GET("/products/:id/reviews", reviewHandler.ListByProduct)                        // Public
+	api.POST("/products/:id/reviews", middleware.AuthMiddleware(), reviewHandler.Create) // Yêu cầu đăng nhập
+
+	reviews := api.Group("/reviews")
+	reviews.Use(middleware.AuthMiddleware()) // Yêu cầu đăng nhập
+	{
+		reviews.PATCH("/:id", reviewHandler.Update)  // Chủ đánh giá
+		reviews.DELETE("/:id", reviewHandler.Delete) // Chủ đánh giá hoặc admin
+	}
+}
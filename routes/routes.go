@@ -16,6 +16,9 @@ func SetupRoutes(r *gin.Engine) {
 		})
 	})
 
+	// Public key để service khác xác thực JWT (không cần chia sẻ secret)
+	SetupWellKnownRoutes(r)
+
 	// API routes
 	api := r.Group("/api/v1")
 	{
@@ -32,5 +35,11 @@ func SetupRoutes(r *gin.Engine) {
 		SetupCategoryRoutes(api)
 		SetupProductRoutes(api)
 		SetupCartRoutes(api) // Cart routes
+		SetupOrderRoutes(api)
+		SetupPaymentRoutes(api)
+		SetupReviewRoutes(api)
+		SetupWishlistRoutes(api)
+		SetupChatRoutes(api)
+		SetupAdminRoutes(api)
 	}
 }
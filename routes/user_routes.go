@@ -5,6 +5,7 @@ import (
 
 	"ecommerce-be/handlers"
 	"ecommerce-be/middleware"
+	"ecommerce-be/models"
 
 	"github.com/gin-gonic/gin"
 )
@@ -16,6 +17,11 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 		return
 	}
 
+	addressHandler := handlers.NewAddressHandler()
+	sessionHandler := handlers.NewSessionHandler()
+	twoFactorHandler := handlers.NewTwoFactorHandler()
+	roleHandler := handlers.NewRoleHandler()
+
 	users := api.Group("/users")
 	users.Use(middleware.AuthMiddleware()) // Tất cả routes đều yêu cầu auth
 	{
@@ -23,15 +29,45 @@ func SetupUserRoutes(api *gin.RouterGroup) {
 		users.GET("/profile", userHandler.GetProfile)
 		users.PATCH("/profile", userHandler.UpdateProfile)
 		users.PATCH("/change-password", userHandler.ChangePassword)
+		users.POST("/change-email", userHandler.RequestEmailChange)
+		users.POST("/change-email/verify", userHandler.ConfirmEmailChange)
 		users.POST("/upload-avatar", userHandler.UploadAvatar)
 		users.DELETE("/delete-avatar", userHandler.DeleteAvatar)
 
-		// Admin only routes
-		users.POST("", middleware.RoleMiddleware("admin"), userHandler.CreateUserByAdmin)
-		users.POST("/search", middleware.RoleMiddleware("admin"), userHandler.Search)
-		users.GET("/:id", middleware.RoleMiddleware("admin"), userHandler.GetUserById)
-		users.PATCH("/:id", middleware.RoleMiddleware("admin"), userHandler.UpdateUserByAdmin)
-		users.POST("/:id/upload-avatar", middleware.RoleMiddleware("admin"), userHandler.UploadAvatarByAdmin)
-		users.PATCH("/:id/change-password", middleware.RoleMiddleware("admin"), userHandler.ChangePasswordByAdmin)
+		// Sổ địa chỉ
+		users.GET("/addresses", addressHandler.List)
+		users.POST("/addresses", addressHandler.Create)
+		users.GET("/addresses/:addressId", addressHandler.FindOne)
+		users.PATCH("/addresses/:addressId", addressHandler.Update)
+		users.PATCH("/addresses/:addressId/default", addressHandler.SetDefault)
+		users.DELETE("/addresses/:addressId", addressHandler.Delete)
+
+		// Phiên đăng nhập (thiết bị)
+		users.GET("/sessions", sessionHandler.List)
+		users.DELETE("/sessions", sessionHandler.RevokeAll) // Đăng xuất khỏi tất cả thiết bị
+		users.DELETE("/sessions/:id", sessionHandler.Revoke)
+
+		// Xác thực 2 lớp (TOTP) cho tài khoản admin/nhân viên
+		twoFactor := users.Group("/2fa", middleware.RequireStaff())
+		twoFactor.GET("", twoFactorHandler.Status)
+		twoFactor.POST("/setup", twoFactorHandler.Setup)
+		twoFactor.POST("/enable", twoFactorHandler.Enable)
+		twoFactor.POST("/disable", twoFactorHandler.Disable)
+		twoFactor.POST("/recovery-codes", twoFactorHandler.RegenerateRecoveryCodes)
+
+		// Quản lý người dùng (sửa tài khoản nhân viên cần thêm quyền role:manage)
+		canRead := middleware.RequirePermission(models.PermissionUserRead)
+		canWrite := middleware.RequirePermission(models.PermissionUserWrite)
+		manageable := middleware.RequireManageableUser()
+		users.POST("", canWrite, userHandler.CreateUserByAdmin)
+		users.POST("/search", canRead, userHandler.Search)
+		users.GET("/:id", canRead, userHandler.GetUserById)
+		users.PATCH("/:id", canWrite, manageable, userHandler.UpdateUserByAdmin)
+		users.POST("/:id/upload-avatar", canWrite, manageable, userHandler.UploadAvatarByAdmin)
+		users.PATCH("/:id/change-password", canWrite, manageable, userHandler.ChangePasswordByAdmin)
+		users.POST("/:id/change-email", canWrite, manageable, userHandler.RequestEmailChangeByAdmin)
+		users.GET("/:id/lockout", canRead, userHandler.GetLoginLockout)
+		users.DELETE("/:id/lockout", canWrite, manageable, userHandler.ClearLoginLockout)
+		users.PUT("/:id/role", middleware.RequirePermission(models.PermissionRoleManage), roleHandler.AssignRole)
 	}
 }
@@ -0,0 +1,12 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+
+	"github.com/gin-gonic/gin"
+)
+
+func SetupWellKnownRoutes(r *gin.Engine) {
+	jwksHandler := handlers.NewJWKSHandler()
+	r.GET("/.well-known/jwks.json", jwksHandler.JWKS)
+}
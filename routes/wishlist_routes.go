@@ -0,0 +1,22 @@
+package routes
+
+import (
+	"ecommerce-be/handlers"
+	"ecommerce-be/middleware"
+
+	"github.com/gin-gonic/gin"
+)
+
+// SetupWishlistRoutes - Thiết lập routes cho danh sách yêu thích
+func SetupWishlistRoutes(api *gin.RouterGroup) {
+	wishlistHandler := handlers.NewWishlistHandler()
+
+	wishlist := api.Group("/wishlist")
+	wishlist.Use(middleware.AuthMiddleware()) // Yêu cầu đăng nhập
+	{
+		wishlist.GET("", wishlistHandler.List)                                // Danh sách yêu thích
+		wishlist.POST("", wishlistHandler.Add)                                // Thêm sản phẩm
+		wishlist.DELETE("/:productId", wishlistHandler.Remove)                // Xóa sản phẩm
+		wishlist.POST("/:productId/move-to-cart", wishlistHandler.MoveToCart) // Chuyển sang giỏ hàng
+	}
+}
@@ -0,0 +1,248 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type AddressService struct{}
+
+func NewAddressService() *AddressService {
+	return &AddressService{}
+}
+
+// List lấy sổ địa chỉ của user (địa chỉ mặc định lên đầu)
+func (s *AddressService) List(userID uint) ([]dto.AddressResponse, error) {
+	var addresses []models.Address
+	if err := database.DB.Where("user_id = ?", userID).
+		Order("is_default DESC, created_at DESC").
+		Find(&addresses).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách địa chỉ")
+	}
+
+	responses := make([]dto.AddressResponse, len(addresses))
+	for i := range addresses {
+		responses[i] = *mapAddressToResponse(&addresses[i])
+	}
+	return responses, nil
+}
+
+// FindOne lấy một địa chỉ của user
+func (s *AddressService) FindOne(userID, addressID uint) (*dto.AddressResponse, error) {
+	var address models.Address
+	if err := database.DB.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("không tìm thấy địa chỉ")
+		}
+		return nil, err
+	}
+	return mapAddressToResponse(&address), nil
+}
+
+// Create thêm địa chỉ mới
+// Địa chỉ đầu tiên của user luôn là mặc định
+func (s *AddressService) Create(userID uint, req dto.CreateAddressRequest) (*dto.AddressResponse, error) {
+	address := models.Address{
+		FullName: strings.TrimSpace(req.FullName),
+		Phone:    strings.TrimSpace(req.Phone),
+		Address:  strings.TrimSpace(req.Address),
+		Ward:     req.Ward,
+		District: req.District,
+		City:     req.City,
+		UserID:   userID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		count, err := s.lockUserAddresses(tx, userID)
+		if err != nil {
+			return err
+		}
+
+		makeDefault := count == 0 || (req.IsDefault != nil && *req.IsDefault)
+		if makeDefault {
+			if err := s.clearDefault(tx, userID); err != nil {
+				return err
+			}
+		}
+		address.IsDefault = makeDefault
+
+		if err := tx.Create(&address).Error; err != nil {
+			return errors.New("không thể tạo địa chỉ")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAddressToResponse(&address), nil
+}
+
+// Update cập nhật địa chỉ
+// Không cho phép bỏ mặc định trực tiếp - phải chọn địa chỉ khác làm mặc định
+func (s *AddressService) Update(userID, addressID uint, req dto.UpdateAddressRequest) (*dto.AddressResponse, error) {
+	var address models.Address
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.lockUserAddresses(tx, userID); err != nil {
+			return err
+		}
+
+		if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("không tìm thấy địa chỉ")
+			}
+			return err
+		}
+
+		if req.FullName != nil {
+			address.FullName = strings.TrimSpace(*req.FullName)
+		}
+		if req.Phone != nil {
+			address.Phone = strings.TrimSpace(*req.Phone)
+		}
+		if req.Address != nil {
+			address.Address = strings.TrimSpace(*req.Address)
+		}
+		if req.Ward != nil {
+			address.Ward = req.Ward
+		}
+		if req.District != nil {
+			address.District = req.District
+		}
+		if req.City != nil {
+			address.City = req.City
+		}
+		if req.IsDefault != nil {
+			if !*req.IsDefault && address.IsDefault {
+				return errors.New("vui lòng chọn địa chỉ khác làm mặc định thay vì bỏ mặc định địa chỉ này")
+			}
+			if *req.IsDefault && !address.IsDefault {
+				if err := s.clearDefault(tx, userID); err != nil {
+					return err
+				}
+				address.IsDefault = true
+			}
+		}
+
+		if err := tx.Save(&address).Error; err != nil {
+			return errors.New("không thể cập nhật địa chỉ")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return mapAddressToResponse(&address), nil
+}
+
+// SetDefault đặt một địa chỉ làm mặc định
+func (s *AddressService) SetDefault(userID, addressID uint) (*dto.AddressResponse, error) {
+	isDefault := true
+	return s.Update(userID, addressID, dto.UpdateAddressRequest{IsDefault: &isDefault})
+}
+
+// Delete xóa địa chỉ
+// Địa chỉ đã được dùng trong đơn hàng chỉ bị soft delete để giữ liên kết với orders.shipping_address_id
+func (s *AddressService) Delete(userID, addressID uint) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.lockUserAddresses(tx, userID); err != nil {
+			return err
+		}
+
+		var address models.Address
+		if err := tx.Where("id = ? AND user_id = ?", addressID, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("không tìm thấy địa chỉ")
+			}
+			return err
+		}
+
+		var orderCount int64
+		if err := tx.Model(&models.Order{}).Unscoped().
+			Where("shipping_address_id = ?", address.ID).
+			Count(&orderCount).Error; err != nil {
+			return err
+		}
+
+		deleteQuery := tx
+		if orderCount == 0 {
+			deleteQuery = tx.Unscoped()
+		}
+		if err := deleteQuery.Delete(&address).Error; err != nil {
+			return errors.New("không thể xóa địa chỉ")
+		}
+
+		// Nếu xóa địa chỉ mặc định → chọn địa chỉ mới nhất còn lại làm mặc định
+		if address.IsDefault {
+			var next models.Address
+			err := tx.Where("user_id = ?", userID).Order("created_at DESC").First(&next).Error
+			if err == nil {
+				if err := tx.Model(&next).Update("is_default", true).Error; err != nil {
+					return errors.New("không thể cập nhật địa chỉ mặc định")
+				}
+			} else if !errors.Is(err, gorm.ErrRecordNotFound) {
+				return err
+			}
+		}
+
+		return nil
+	})
+}
+
+// lockUserAddresses khóa (FOR UPDATE) toàn bộ địa chỉ của user để các thao tác đổi mặc định không chạy chồng lên nhau
+// Trả về số địa chỉ hiện có
+func (s *AddressService) lockUserAddresses(tx *gorm.DB, userID uint) (int, error) {
+	// Khóa dòng user để serialize cả trường hợp user chưa có địa chỉ nào
+	var user models.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Select("id").First(&user, userID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return 0, errors.New("không tìm thấy người dùng")
+		}
+		return 0, err
+	}
+
+	var addresses []models.Address
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Select("id").
+		Where("user_id = ?", userID).
+		Find(&addresses).Error; err != nil {
+		return 0, err
+	}
+	return len(addresses), nil
+}
+
+// clearDefault bỏ cờ mặc định của tất cả địa chỉ của user
+func (s *AddressService) clearDefault(tx *gorm.DB, userID uint) error {
+	if err := tx.Model(&models.Address{}).
+		Where("user_id = ? AND is_default = ?", userID, true).
+		Update("is_default", false).Error; err != nil {
+		return errors.New("không thể cập nhật địa chỉ mặc định")
+	}
+	return nil
+}
+
+// Helper function để map Address sang AddressResponse
+func mapAddressToResponse(address *models.Address) *dto.AddressResponse {
+	return &dto.AddressResponse{
+		ID:        address.ID,
+		FullName:  address.FullName,
+		Phone:     address.Phone,
+		Address:   address.Address,
+		Ward:      address.Ward,
+		District:  address.District,
+		City:      address.City,
+		IsDefault: address.IsDefault,
+		UserID:    address.UserID,
+		CreatedAt: address.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt: address.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
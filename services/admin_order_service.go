@@ -0,0 +1,166 @@
+package services
+
+import (
+	"errors"
+	"math"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+)
+
+// latestPaymentStatusSQL lấy trạng thái giao dịch gần nhất của đơn hàng
+const latestPaymentStatusSQL = `(SELECT p.status FROM payments p
+	WHERE p.order_id = orders.id AND p.deleted_at IS NULL
+	ORDER BY p.created_at DESC, p.id DESC LIMIT 1)`
+
+type AdminOrderService struct {
+	orderService *OrderService
+}
+
+func NewAdminOrderService() *AdminOrderService {
+	return &AdminOrderService{
+		orderService: NewOrderService(),
+	}
+}
+
+// Search tìm kiếm đơn hàng của tất cả user (Chỉ admin)
+// Trả về kèm số đơn theo từng trạng thái để hiển thị badge trên dashboard
+func (s *AdminOrderService) Search(req dto.AdminSearchOrderRequest) (*dto.AdminOrderPaginationResponse, error) {
+	// Default values
+	sortBy := "createdAt"
+	sortOrder := "DESC"
+	page := 1
+	limit := 20
+
+	if req.SortBy != nil {
+		sortBy = *req.SortBy
+	}
+	if req.SortOrder != nil {
+		sortOrder = *req.SortOrder
+	}
+	if req.Page != nil {
+		page = *req.Page
+	}
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	// Số đơn theo trạng thái: áp dụng mọi filter trừ status để các badge không phụ thuộc tab đang chọn
+	countQuery, err := s.applyFilters(database.DB.Model(&models.Order{}), req)
+	if err != nil {
+		return nil, err
+	}
+	var buckets []struct {
+		Status string
+		Count  int64
+	}
+	if err := countQuery.Select("status, COUNT(*) AS count").Group("status").Scan(&buckets).Error; err != nil {
+		return nil, errors.New("không thể thống kê đơn hàng")
+	}
+	statusCounts := make(map[string]int64, len(orderTransitions))
+	for status := range orderTransitions {
+		statusCounts[string(status)] = 0
+	}
+	for _, bucket := range buckets {
+		statusCounts[bucket.Status] = bucket.Count
+	}
+
+	query, err := s.applyFilters(database.DB.Model(&models.Order{}), req)
+	if err != nil {
+		return nil, err
+	}
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("không thể đếm số lượng đơn hàng")
+	}
+
+	// Pagination
+	offset := (page - 1) * limit
+	var orders []models.Order
+	if err := query.Preload("User").
+		Preload("Items.Product").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		Order(s.orderService.orderBy(sortBy, sortOrder)).
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách đơn hàng")
+	}
+
+	orderResponses := make([]dto.OrderResponse, len(orders))
+	for i := range orders {
+		orderResponses[i] = *mapOrderToResponse(&orders[i])
+	}
+
+	return &dto.AdminOrderPaginationResponse{
+		Data:         orderResponses,
+		Total:        total,
+		Page:         page,
+		Limit:        limit,
+		TotalPages:   int(math.Ceil(float64(total) / float64(limit))),
+		StatusCounts: statusCounts,
+	}, nil
+}
+
+// applyFilters áp dụng các điều kiện lọc (trừ status) lên query
+func (s *AdminOrderService) applyFilters(query *gorm.DB, req dto.AdminSearchOrderRequest) (*gorm.DB, error) {
+	if req.PaymentStatus != nil {
+		if *req.PaymentStatus == "unpaid" {
+			query = query.Where(latestPaymentStatusSQL + " IS NULL")
+		} else {
+			query = query.Where(latestPaymentStatusSQL+" = ?", *req.PaymentStatus)
+		}
+	}
+
+	// Escape ký tự đại diện của LIKE để %, _ trong từ khóa được so khớp đúng nghĩa đen
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+	if req.Email != nil && strings.TrimSpace(*req.Email) != "" {
+		email := "%" + escaper.Replace(strings.TrimSpace(*req.Email)) + "%"
+		query = query.Where(`user_id IN (SELECT id FROM users WHERE email ILIKE ? ESCAPE '\')`, email)
+	}
+
+	if req.OrderNumber != nil && strings.TrimSpace(*req.OrderNumber) != "" {
+		orderNumber := "%" + escaper.Replace(strings.TrimSpace(*req.OrderNumber)) + "%"
+		query = query.Where(`order_number ILIKE ? ESCAPE '\'`, orderNumber)
+	}
+
+	if req.MinAmount != nil {
+		query = query.Where("total_amount >= ?", *req.MinAmount)
+	}
+	if req.MaxAmount != nil {
+		query = query.Where("total_amount <= ?", *req.MaxAmount)
+	}
+
+	if req.FromDate != nil && *req.FromDate != "" {
+		from, _, err := parseDateFilter(*req.FromDate)
+		if err != nil {
+			return nil, errors.New("fromDate không hợp lệ (YYYY-MM-DD hoặc RFC3339)")
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+	if req.ToDate != nil && *req.ToDate != "" {
+		to, dateOnly, err := parseDateFilter(*req.ToDate)
+		if err != nil {
+			return nil, errors.New("toDate không hợp lệ (YYYY-MM-DD hoặc RFC3339)")
+		}
+		if dateOnly {
+			query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			query = query.Where("created_at <= ?", to)
+		}
+	}
+
+	return query, nil
+}
@@ -3,9 +3,11 @@ package services
 import (
 	"errors"
 	"fmt"
+	"log"
 	"strings"
 	"time"
 
+	"ecommerce-be/cache"
 	"ecommerce-be/database"
 	"ecommerce-be/dto"
 	"ecommerce-be/models"
@@ -15,35 +17,48 @@ import (
 )
 
 type AuthService struct {
-	otpService   *OtpService
-	emailService *EmailService
+	otpService          *OtpService
+	emailService        *EmailService
+	sessionService      *SessionService
+	loginAttemptService *LoginAttemptService
+	twoFactorService    *TwoFactorService
 }
 
 func NewAuthService() *AuthService {
 	return &AuthService{
-		otpService:   NewOtpService(),
-		emailService: NewEmailService(),
+		otpService:          NewOtpService(),
+		emailService:        NewEmailService(),
+		sessionService:      NewSessionService(),
+		loginAttemptService: NewLoginAttemptService(),
+		twoFactorService:    NewTwoFactorService(),
 	}
 }
 
 // Login xử lý đăng nhập
-func (s *AuthService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
+func (s *AuthService) Login(req dto.LoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
 	// Normalize email
 	email := strings.ToLower(strings.TrimSpace(req.Email))
 
+	// Chống brute-force: email hoặc IP đang bị khóa thì không kiểm tra mật khẩu
+	if err := s.loginAttemptService.CheckLocked(email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
 	// Tìm user theo email
 	var user models.User
 	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("email hoặc mật khẩu không đúng")
+			// Vẫn đếm để không phân biệt được email có tồn tại hay không
+			return nil, s.loginFailed(email, client.IPAddress, errors.New("email hoặc mật khẩu không đúng"))
 		}
 		return nil, err
 	}
 
 	// Kiểm tra password
 	if !utils.CheckPassword(req.Password, user.Password) {
-		return nil, errors.New("email hoặc mật khẩu không đúng")
+		return nil, s.loginFailed(email, client.IPAddress, errors.New("email hoặc mật khẩu không đúng"))
 	}
+	s.loginAttemptService.RegisterSuccess(email)
 
 	// Kiểm tra user có active không
 	if !user.IsActive {
@@ -52,27 +67,27 @@ func (s *AuthService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 
 	// Nếu email chưa được verify → gửi OTP và yêu cầu verify
 	if !user.IsEmailVerified {
+		currentOtp, err := s.otpService.Find(user.ID, OtpPurposeVerifyEmail)
+		if err != nil {
+			return nil, errors.New("không thể kiểm tra OTP")
+		}
+
 		// Kiểm tra xem OTP đã từng được set chưa
-		hasOtpBeenSet := user.OTP != nil && user.OTPExpiresAt != nil
+		hasOtpBeenSet := currentOtp != nil
 
 		// Kiểm tra OTP hiện tại có hết hạn không (chỉ khi đã có OTP)
-		isOtpExpired := hasOtpBeenSet && time.Now().After(*user.OTPExpiresAt)
+		isOtpExpired := hasOtpBeenSet && time.Now().After(currentOtp.ExpiresAt)
 
 		// Nếu OTP hết hạn hoặc không có OTP → gửi OTP mới
 		if isOtpExpired || !hasOtpBeenSet {
-			// Generate OTP mới
-			otp := s.otpService.GenerateOtp()
-			otpExpiresAt := s.otpService.GetOtpExpiry()
-			now := time.Now()
-
-			// Lưu OTP vào database
-			user.OTP = &otp
-			user.OTPExpiresAt = &otpExpiresAt
-			user.LastOTPSentAt = &now
-			if err := database.DB.Save(&user).Error; err != nil {
-				return nil, errors.New("không thể cập nhật OTP")
+			// Generate và lưu OTP mới
+			otp, err := s.otpService.Issue(database.DB, user.ID, OtpPurposeVerifyEmail)
+			if err != nil {
+				return nil, err
 			}
 
+			s.loginAttemptService.ClearOtpFailures(OtpPurposeVerifyEmail, email)
+
 			// Gửi OTP qua email
 			if err := s.emailService.SendOtpEmail(user.Email, otp, user.Name); err != nil {
 				return nil, fmt.Errorf("không thể gửi email OTP: %v", err)
@@ -115,16 +130,20 @@ func (s *AuthService) Login(req dto.LoginRequest) (*dto.LoginResponse, error) {
 		}, nil
 	}
 
-	// Tạo tokens
-	accessToken, refreshToken, err := utils.GenerateTokens(user.ID, user.Email)
+	// Đã bật 2FA (hoặc admin bị bắt buộc bật) → chưa tạo phiên, yêu cầu thêm bước xác thực 2 lớp
+	challenge, err := s.twoFactorService.Challenge(&user)
 	if err != nil {
-		return nil, errors.New("không thể tạo token")
+		return nil, err
+	}
+	if challenge != nil {
+		return twoFactorLoginResponse(challenge), nil
 	}
 
-	// Lưu refresh token vào database
-	user.RefreshToken = &refreshToken
-	if err := database.DB.Save(&user).Error; err != nil {
-		return nil, errors.New("không thể lưu refresh token")
+	// Tạo phiên đăng nhập mới (mỗi thiết bị một phiên, không ảnh hưởng các thiết bị khác)
+	client.DeviceName = req.DeviceName
+	accessToken, refreshToken, err := s.sessionService.Create(&user, client)
+	if err != nil {
+		return nil, err
 	}
 
 	// Tạo response
@@ -166,10 +185,6 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.RegisterResponse,
 			return nil, errors.New("email đã được sử dụng")
 		}
 		// User tồn tại nhưng chưa verify - cập nhật OTP
-		otp := s.otpService.GenerateOtp()
-		otpExpiresAt := s.otpService.GetOtpExpiry()
-		now := time.Now()
-
 		// Hash password mới nếu có thay đổi
 		hashedPassword, err := utils.HashPassword(req.Password)
 		if err != nil {
@@ -179,14 +194,20 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.RegisterResponse,
 		// Cập nhật thông tin user và OTP
 		existingUser.Password = hashedPassword
 		existingUser.Name = strings.TrimSpace(req.Name)
-		existingUser.OTP = &otp
-		existingUser.OTPExpiresAt = &otpExpiresAt
-		existingUser.LastOTPSentAt = &now
 
-		if err := database.DB.Save(&existingUser).Error; err != nil {
-			return nil, errors.New("không thể cập nhật tài khoản")
+		var otp string
+		if err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Save(&existingUser).Error; err != nil {
+				return errors.New("không thể cập nhật tài khoản")
+			}
+			otp, err = s.otpService.Issue(tx, existingUser.ID, OtpPurposeVerifyEmail)
+			return err
+		}); err != nil {
+			return nil, err
 		}
 
+		s.loginAttemptService.ClearOtpFailures(OtpPurposeVerifyEmail, email)
+
 		// Gửi OTP qua email
 		if err := s.emailService.SendOtpEmail(email, otp, existingUser.Name); err != nil {
 			return nil, fmt.Errorf("không thể gửi email OTP: %v", err)
@@ -205,11 +226,6 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.RegisterResponse,
 		return nil, errors.New("không thể hash password")
 	}
 
-	// Generate OTP
-	otp := s.otpService.GenerateOtp()
-	otpExpiresAt := s.otpService.GetOtpExpiry()
-	now := time.Now()
-
 	// Tạo user mới (chưa verified)
 	user := models.User{
 		Email:           email,
@@ -219,16 +235,21 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.RegisterResponse,
 		IsEmailVerified: false,
 		IsActive:        false, // Chưa active cho đến khi verify
 		IsFirstLogin:    false,
-		OTP:             &otp,
-		OTPExpiresAt:    &otpExpiresAt,
-		LastOTPSentAt:   &now,
 	}
 
-	if err := database.DB.Create(&user).Error; err != nil {
-		if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
-			return nil, errors.New("email đã được sử dụng")
+	// Tạo user và OTP trong cùng transaction
+	var otp string
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&user).Error; err != nil {
+			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
+				return errors.New("email đã được sử dụng")
+			}
+			return errors.New("không thể tạo tài khoản")
 		}
-		return nil, errors.New("không thể tạo tài khoản")
+		otp, err = s.otpService.Issue(tx, user.ID, OtpPurposeVerifyEmail)
+		return err
+	}); err != nil {
+		return nil, err
 	}
 
 	// Gửi OTP qua email
@@ -245,38 +266,29 @@ func (s *AuthService) Register(req dto.RegisterRequest) (*dto.RegisterResponse,
 	}, nil
 }
 
-// RefreshToken làm mới access token bằng refresh token
-func (s *AuthService) RefreshToken(refreshToken string) (*dto.RefreshTokenResponse, error) {
-	// Validate refresh token
-	claims, err := utils.ValidateToken(refreshToken)
-	if err != nil {
-		return nil, errors.New("refresh token không hợp lệ hoặc đã hết hạn")
+// Logout đăng xuất phiên hiện tại: thu hồi access token đang dùng và refresh token của phiên
+func (s *AuthService) Logout(userID uint, claims *utils.Claims) error {
+	if claims.ExpiresAt != nil {
+		if err := cache.DenyToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			return errors.New("không thể thu hồi token")
+		}
 	}
 
-	// Tìm user theo refresh token trong database
-	var user models.User
-	if err := database.DB.Where("refresh_token = ? AND id = ?", refreshToken, claims.UserID).First(&user).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return nil, errors.New("refresh token không hợp lệ")
+	if claims.SessionID != 0 {
+		// Phiên đã bị thu hồi trước đó (vd: từ thiết bị khác) thì vẫn coi như đăng xuất thành công
+		if err := s.sessionService.Revoke(userID, claims.SessionID); err != nil && !errors.Is(err, ErrSessionNotFound) {
+			return err
 		}
-		return nil, err
 	}
 
-	// Kiểm tra user có active không
-	if !user.IsActive {
-		return nil, errors.New("tài khoản đã bị vô hiệu hóa")
-	}
+	return nil
+}
 
-	// Tạo tokens mới
-	accessToken, newRefreshToken, err := utils.GenerateTokens(user.ID, user.Email)
+// RefreshToken làm mới access token bằng refresh token (rotate refresh token của phiên)
+func (s *AuthService) RefreshToken(refreshToken string, client dto.ClientInfo) (*dto.RefreshTokenResponse, error) {
+	user, accessToken, newRefreshToken, err := s.sessionService.Rotate(refreshToken, client)
 	if err != nil {
-		return nil, errors.New("không thể tạo token")
-	}
-
-	// Lưu refresh token mới vào database
-	user.RefreshToken = &newRefreshToken
-	if err := database.DB.Save(&user).Error; err != nil {
-		return nil, errors.New("không thể lưu refresh token")
+		return nil, err
 	}
 
 	return &dto.RefreshTokenResponse{
@@ -301,10 +313,14 @@ func (s *AuthService) RefreshToken(refreshToken string) (*dto.RefreshTokenRespon
 }
 
 // VerifyOtp xác thực OTP và kích hoạt tài khoản
-func (s *AuthService) VerifyOtp(req dto.VerifyOtpRequest) (*dto.VerifyOtpResponse, error) {
+func (s *AuthService) VerifyOtp(req dto.VerifyOtpRequest, client dto.ClientInfo) (*dto.VerifyOtpResponse, error) {
 	// Normalize email
 	email := strings.ToLower(strings.TrimSpace(req.Email))
 
+	if err := s.loginAttemptService.CheckLocked("", client.IPAddress); err != nil {
+		return nil, err
+	}
+
 	// Tìm user
 	var user models.User
 	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
@@ -319,32 +335,46 @@ func (s *AuthService) VerifyOtp(req dto.VerifyOtpRequest) (*dto.VerifyOtpRespons
 		return nil, errors.New("email đã được xác thực")
 	}
 
-	// Kiểm tra OTP
-	if !s.otpService.IsOtpValid(req.OTP, *user.OTP, user.OTPExpiresAt) {
-		return nil, errors.New("mã OTP không hợp lệ hoặc đã hết hạn")
-	}
-
-	// Xác thực email và kích hoạt tài khoản
+	// Kiểm tra OTP và xác thực email, kích hoạt tài khoản
 	user.IsEmailVerified = true
 	user.IsActive = true
 	user.IsFirstLogin = false
-	user.OTP = nil
-	user.OTPExpiresAt = nil
 
-	if err := database.DB.Save(&user).Error; err != nil {
-		return nil, errors.New("không thể cập nhật tài khoản")
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.otpService.Consume(tx, user.ID, OtpPurposeVerifyEmail, req.OTP); err != nil {
+			return err
+		}
+		if err := tx.Save(&user).Error; err != nil {
+			return errors.New("không thể cập nhật tài khoản")
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, ErrInvalidOtp) {
+			return nil, s.otpFailed(&user, OtpPurposeVerifyEmail, client.IPAddress)
+		}
+		return nil, err
 	}
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeVerifyEmail, email)
 
-	// Tạo tokens
-	accessToken, refreshToken, err := utils.GenerateTokens(user.ID, user.Email)
+	// Admin bị bắt buộc bật 2FA → thiết lập 2FA trước khi cấp phiên đăng nhập
+	challenge, err := s.twoFactorService.Challenge(&user)
 	if err != nil {
-		return nil, errors.New("không thể tạo token")
+		return nil, err
+	}
+	if challenge != nil {
+		return &dto.VerifyOtpResponse{
+			Success:                true,
+			Message:                "Xác thực email thành công. Vui lòng thiết lập xác thực 2 lớp để tiếp tục.",
+			RequiresTwoFactorSetup: challenge.RequiresTwoFactorSetup,
+			TwoFactorToken:         challenge.TwoFactorToken,
+		}, nil
 	}
 
-	// Lưu refresh token
-	user.RefreshToken = &refreshToken
-	if err := database.DB.Save(&user).Error; err != nil {
-		return nil, errors.New("không thể lưu refresh token")
+	// Tạo phiên đăng nhập
+	client.DeviceName = req.DeviceName
+	accessToken, refreshToken, err := s.sessionService.Create(&user, client)
+	if err != nil {
+		return nil, err
 	}
 
 	return &dto.VerifyOtpResponse{
@@ -390,31 +420,18 @@ func (s *AuthService) ResendOtp(req dto.ResendOtpRequest) (*dto.ResendOtpRespons
 	}
 
 	// Rate limiting: Kiểm tra cooldown 60 giây
-	if user.LastOTPSentAt != nil {
-		now := time.Now()
-		secondsSinceLastSent := int(now.Sub(*user.LastOTPSentAt).Seconds())
-		cooldownSeconds := 60
-
-		if secondsSinceLastSent < cooldownSeconds {
-			remainingSeconds := cooldownSeconds - secondsSinceLastSent
-			return nil, fmt.Errorf("vui lòng đợi %d giây trước khi yêu cầu gửi lại OTP", remainingSeconds)
-		}
+	if err := s.otpService.CheckCooldown(user.ID, OtpPurposeVerifyEmail); err != nil {
+		return nil, err
 	}
 
-	// Generate OTP mới
-	otp := s.otpService.GenerateOtp()
-	otpExpiresAt := s.otpService.GetOtpExpiry()
-	now := time.Now()
-
-	// Cập nhật OTP
-	user.OTP = &otp
-	user.OTPExpiresAt = &otpExpiresAt
-	user.LastOTPSentAt = &now
-
-	if err := database.DB.Save(&user).Error; err != nil {
-		return nil, errors.New("không thể cập nhật OTP")
+	// Generate và lưu OTP mới
+	otp, err := s.otpService.Issue(database.DB, user.ID, OtpPurposeVerifyEmail)
+	if err != nil {
+		return nil, err
 	}
 
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeVerifyEmail, email)
+
 	// Gửi OTP qua email
 	if err := s.emailService.SendOtpEmail(email, otp, user.Name); err != nil {
 		return nil, fmt.Errorf("không thể gửi email OTP: %v", err)
@@ -426,3 +443,250 @@ func (s *AuthService) ResendOtp(req dto.ResendOtpRequest) (*dto.ResendOtpRespons
 		Email:   email,
 	}, nil
 }
+
+// ForgotPassword gửi OTP đặt lại mật khẩu qua email
+// Email không tồn tại vẫn trả về thành công để không lộ thông tin tài khoản
+func (s *AuthService) ForgotPassword(req dto.ForgotPasswordRequest) (*dto.ForgotPasswordResponse, error) {
+	// Normalize email
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	response := &dto.ForgotPasswordResponse{
+		Success: true,
+		Message: "Nếu email tồn tại trong hệ thống, mã OTP đặt lại mật khẩu đã được gửi. Vui lòng kiểm tra email của bạn.",
+		Email:   email,
+	}
+
+	// Tìm user
+	var user models.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response, nil
+		}
+		return nil, err
+	}
+
+	// Rate limiting: Kiểm tra cooldown 60 giây
+	// Đang cooldown thì không gửi nhưng vẫn trả response như trên để không lộ email nào tồn tại
+	if err := s.otpService.CheckCooldown(user.ID, OtpPurposeResetPassword); err != nil {
+		log.Printf("⚠️  Warning: Bỏ qua gửi OTP đặt lại mật khẩu cho user %d: %v", user.ID, err)
+		return response, nil
+	}
+
+	// Generate OTP đặt lại mật khẩu (không ghi đè OTP xác thực email)
+	otp, err := s.otpService.Issue(database.DB, user.ID, OtpPurposeResetPassword)
+	if err != nil {
+		return nil, err
+	}
+
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeResetPassword, email)
+
+	// Gửi OTP qua email (lỗi chỉ ghi log, response giống trường hợp email không tồn tại)
+	if err := s.emailService.SendPasswordResetEmail(email, otp, user.Name); err != nil {
+		log.Printf("⚠️  Warning: Không thể gửi email OTP đặt lại mật khẩu cho user %d: %v", user.ID, err)
+	}
+
+	return response, nil
+}
+
+// ResetPassword đặt lại mật khẩu bằng OTP và thu hồi mọi phiên đăng nhập hiện có
+func (s *AuthService) ResetPassword(req dto.ResetPasswordRequest, client dto.ClientInfo) (*dto.ResetPasswordResponse, error) {
+	// Normalize email
+	email := strings.ToLower(strings.TrimSpace(req.Email))
+
+	if err := s.loginAttemptService.CheckLocked("", client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	// Tìm user
+	var user models.User
+	if err := database.DB.Where("email = ?", email).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, s.loginFailed("", client.IPAddress, errors.New("mã OTP không hợp lệ hoặc đã hết hạn"))
+		}
+		return nil, err
+	}
+
+	// Hash mật khẩu mới
+	hashedPassword, err := utils.HashPassword(req.NewPassword)
+	if err != nil {
+		return nil, errors.New("không thể hash password")
+	}
+
+	// Kiểm tra OTP và cập nhật mật khẩu (OTP bị xóa, mỗi mã chỉ dùng được 1 lần)
+	if err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if _, err := s.otpService.Consume(tx, user.ID, OtpPurposeResetPassword, req.OTP); err != nil {
+			return err
+		}
+		if err := tx.Model(&user).Update("password", hashedPassword).Error; err != nil {
+			return errors.New("không thể cập nhật mật khẩu")
+		}
+		return nil
+	}); err != nil {
+		if errors.Is(err, ErrInvalidOtp) {
+			return nil, s.otpFailed(&user, OtpPurposeResetPassword, client.IPAddress)
+		}
+		return nil, err
+	}
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeResetPassword, email)
+
+	// Mật khẩu mới → mở khóa đăng nhập do nhập sai mật khẩu cũ
+	s.loginAttemptService.RegisterSuccess(email)
+
+	// Đăng xuất khỏi mọi thiết bị: refresh token cũ không còn dùng được
+	if _, err := s.sessionService.RevokeAll(user.ID, SessionRevokedPasswordReset); err != nil {
+		return nil, err
+	}
+
+	return &dto.ResetPasswordResponse{
+		Success: true,
+		Message: "Đặt lại mật khẩu thành công. Vui lòng đăng nhập lại với mật khẩu mới.",
+	}, nil
+}
+
+// VerifyTwoFactor hoàn tất đăng nhập bằng mã TOTP hoặc mã khôi phục
+func (s *AuthService) VerifyTwoFactor(req dto.TwoFactorVerifyRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	user, claims, err := s.parseTwoFactorToken(req.TwoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	// Nhập sai mã 2FA được đếm chung với nhập sai mật khẩu
+	if err := s.loginAttemptService.CheckLocked(user.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if err := s.twoFactorService.Verify(user.ID, req.Code); err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, s.loginFailed(user.Email, client.IPAddress, err)
+		}
+		return nil, err
+	}
+	s.loginAttemptService.RegisterSuccess(user.Email)
+
+	return s.completeTwoFactorLogin(user, claims, req.DeviceName, client)
+}
+
+// SetupTwoFactor tạo secret 2FA cho admin bị bắt buộc bật 2FA nhưng chưa thiết lập (dùng token từ bước đăng nhập)
+func (s *AuthService) SetupTwoFactor(req dto.TwoFactorTokenRequest) (*dto.TwoFactorSetupResponse, error) {
+	user, _, err := s.parseTwoFactorToken(req.TwoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+	return s.twoFactorService.Setup(user)
+}
+
+// ConfirmTwoFactorSetup xác nhận mã đầu tiên để bật 2FA rồi hoàn tất đăng nhập
+func (s *AuthService) ConfirmTwoFactorSetup(req dto.TwoFactorVerifyRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	user, claims, err := s.parseTwoFactorToken(req.TwoFactorToken)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginAttemptService.CheckLocked(user.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	recoveryCodes, err := s.twoFactorService.Enable(user, req.Code)
+	if err != nil {
+		if errors.Is(err, ErrInvalidTwoFactorCode) {
+			return nil, s.loginFailed(user.Email, client.IPAddress, err)
+		}
+		return nil, err
+	}
+	s.loginAttemptService.RegisterSuccess(user.Email)
+
+	response, err := s.completeTwoFactorLogin(user, claims, req.DeviceName, client)
+	if err != nil {
+		return nil, err
+	}
+	response.Message = "Bật xác thực 2 lớp thành công! Vui lòng lưu lại mã khôi phục."
+	response.RecoveryCodes = recoveryCodes
+	return response, nil
+}
+
+// parseTwoFactorToken kiểm tra token tạm của bước 2FA và trả về user tương ứng
+func (s *AuthService) parseTwoFactorToken(token string) (*models.User, *utils.Claims, error) {
+	invalid := errors.New("phiên xác thực 2 lớp không hợp lệ hoặc đã hết hạn, vui lòng đăng nhập lại")
+
+	claims, err := utils.ValidateToken(token)
+	if err != nil || claims.TokenType != utils.TokenTypeTwoFactor {
+		return nil, nil, invalid
+	}
+
+	if denied, _ := cache.IsTokenDenied(claims.ID, 0, claims.UserID, claims.IssuedAtTime()); denied {
+		return nil, nil, invalid
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, invalid
+		}
+		return nil, nil, err
+	}
+	if !user.IsActive {
+		return nil, nil, errors.New("tài khoản đã bị vô hiệu hóa")
+	}
+	return &user, claims, nil
+}
+
+// completeTwoFactorLogin hủy token tạm (chỉ dùng một lần) và tạo phiên đăng nhập
+func (s *AuthService) completeTwoFactorLogin(user *models.User, claims *utils.Claims, deviceName *string, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	if claims.ExpiresAt != nil {
+		if err := cache.DenyToken(claims.ID, claims.ExpiresAt.Time); err != nil {
+			return nil, errors.New("không thể thu hồi token")
+		}
+	}
+
+	client.DeviceName = deviceName
+	accessToken, refreshToken, err := s.sessionService.Create(user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Success:      true,
+		Message:      "Đăng nhập thành công!",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User: &dto.UserResponse{
+			ID:              user.ID,
+			Email:           user.Email,
+			Name:            user.Name,
+			Role:            user.Role,
+			Phone:           user.Phone,
+			Avatar:          user.Avatar,
+			Address:         user.Address,
+			Gender:          user.Gender,
+			IsEmailVerified: user.IsEmailVerified,
+			IsActive:        user.IsActive,
+			IsFirstLogin:    user.IsFirstLogin,
+			CreatedAt:       user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:       user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+	}, nil
+}
+
+// loginFailed ghi nhận lần đăng nhập sai, trả về lỗi khóa nếu vừa vượt ngưỡng
+func (s *AuthService) loginFailed(email, ip string, err error) error {
+	if lockErr := s.loginAttemptService.RegisterFailure(email, ip); lockErr != nil {
+		return lockErr
+	}
+	return err
+}
+
+// otpFailed ghi nhận lần nhập sai OTP; sai quá nhiều lần thì hủy OTP, user phải yêu cầu mã mới
+func (s *AuthService) otpFailed(user *models.User, purpose, ip string) error {
+	if lockErr := s.loginAttemptService.RegisterFailure("", ip); lockErr != nil {
+		return lockErr
+	}
+
+	if !s.loginAttemptService.RegisterOtpFailure(purpose, user.Email) {
+		return ErrInvalidOtp
+	}
+
+	if err := s.otpService.Invalidate(user.ID, purpose); err != nil {
+		return errors.New("không thể hủy mã OTP")
+	}
+	return errors.New("bạn đã nhập sai OTP quá nhiều lần. Mã OTP đã bị hủy, vui lòng yêu cầu mã mới")
+}
@@ -13,9 +13,14 @@ import (
 
 // AddToCart - Thêm sản phẩm vào giỏ hàng
 func AddToCart(userID uint, req dto.AddToCartRequest) (*dto.CartItemResponse, error) {
+	return addToCart(database.DB, userID, req)
+}
+
+// addToCart thêm sản phẩm vào giỏ trong tx (dùng chung khi cần ghép với thao tác khác trong cùng transaction)
+func addToCart(tx *gorm.DB, userID uint, req dto.AddToCartRequest) (*dto.CartItemResponse, error) {
 	// Kiểm tra sản phẩm có tồn tại không
 	var product models.Product
-	if err := database.DB.Where("id = ? AND is_active = ?", req.ProductID, true).First(&product).Error; err != nil {
+	if err := tx.Where("id = ? AND is_active = ?", req.ProductID, true).First(&product).Error; err != nil {
 		if errors.Is(err, gorm.ErrRecordNotFound) {
 			return nil, errors.New("sản phẩm không tồn tại hoặc không khả dụng")
 		}
@@ -29,7 +34,7 @@ func AddToCart(userID uint, req dto.AddToCartRequest) (*dto.CartItemResponse, er
 
 	// Kiểm tra xem sản phẩm đã có trong giỏ hàng chưa
 	var existingCartItem models.CartItem
-	result := database.DB.Where("user_id = ? AND product_id = ?", userID, req.ProductID).First(&existingCartItem)
+	result := tx.Where("user_id = ? AND product_id = ?", userID, req.ProductID).First(&existingCartItem)
 
 	if result.Error == nil {
 		// Đã có trong giỏ → Cập nhật số lượng
@@ -41,12 +46,12 @@ func AddToCart(userID uint, req dto.AddToCartRequest) (*dto.CartItemResponse, er
 		}
 
 		existingCartItem.Quantity = newQuantity
-		if err := database.DB.Save(&existingCartItem).Error; err != nil {
+		if err := tx.Save(&existingCartItem).Error; err != nil {
 			return nil, err
 		}
 
 		// Preload product để trả về
-		database.DB.Preload("Product").First(&existingCartItem, existingCartItem.ID)
+		tx.Preload("Product").First(&existingCartItem, existingCartItem.ID)
 
 		return mapCartItemToResponse(&existingCartItem), nil
 	}
@@ -58,12 +63,12 @@ func AddToCart(userID uint, req dto.AddToCartRequest) (*dto.CartItemResponse, er
 		Quantity:  req.Quantity,
 	}
 
-	if err := database.DB.Create(&cartItem).Error; err != nil {
+	if err := tx.Create(&cartItem).Error; err != nil {
 		return nil, err
 	}
 
 	// Preload product để trả về
-	database.DB.Preload("Product").First(&cartItem, cartItem.ID)
+	tx.Preload("Product").First(&cartItem, cartItem.ID)
 
 	return mapCartItemToResponse(&cartItem), nil
 }
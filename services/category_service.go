@@ -367,37 +367,15 @@ func (s *CategoryService) Search(req dto.SearchCategoryRequest, language string)
 	// Sử dụng LEFT JOIN để xử lý trường hợp không có child nào
 	query = query.Where("NOT EXISTS (SELECT 1 FROM category_children WHERE category_children.child_id = categories.id AND category_children.deleted_at IS NULL)")
 
-	// Filter theo name (partial match, case-insensitive)
-	if req.Name != nil && *req.Name != "" {
-		normalizedSearch := s.normalizeForSearch(*req.Name)
-
-		// Lấy tất cả ROOT categories để filter trong memory (vì PostgreSQL không có built-in function để xóa dấu tiếng Việt)
-		// CHỈ lấy root categories (không có trong category_children như child)
-		var allCategories []models.Category
-		if err := database.DB.Select("id", "name", "name_en").
-			Where("NOT EXISTS (SELECT 1 FROM category_children WHERE category_children.child_id = categories.id AND category_children.deleted_at IS NULL)").
-			Find(&allCategories).Error; err != nil {
-			return nil, errors.New("không thể tìm kiếm danh mục")
-		}
-
-		// Filter categories có tên chứa search term
-		var matchedIDs []uint
-		for _, cat := range allCategories {
-			nameVi := s.normalizeForSearch(cat.Name)
-			nameEn := ""
-			if cat.NameEn != nil {
-				nameEn = s.normalizeForSearch(*cat.NameEn)
-			}
-			if strings.Contains(nameVi, normalizedSearch) || (nameEn != "" && strings.Contains(nameEn, normalizedSearch)) {
-				matchedIDs = append(matchedIDs, cat.ID)
-			}
-		}
-
-		if len(matchedIDs) > 0 {
-			query = query.Where("id IN ?", matchedIDs)
-		} else {
-			// Nếu không tìm thấy → trả về empty result
-			query = query.Where("1 = 0") // Always false condition
+	// Search theo tên/mô tả (partial match, case-insensitive, không phân biệt dấu)
+	var search *textSearch
+	if req.Name != nil {
+		search = newTextSearch(*req.Name)
+	}
+	if search != nil {
+		query = search.apply(query)
+		if req.SortBy == nil {
+			sortBy = SortByRelevance
 		}
 	}
 
@@ -445,23 +423,39 @@ func (s *CategoryService) Search(req dto.SearchCategoryRequest, language string)
 		}
 	}
 
-	// Count total
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("không thể đếm số lượng danh mục")
+	// Sort - map field names to database columns (giống NestJS: category.${sortBy})
+	key := s.sortKey(sortBy, sortOrder)
+	if sortBy == SortByRelevance && search != nil {
+		key = search.relevanceKey(sortOrder)
+	}
+	pagination, err := newPageRequest(key, page, limit, req.Cursor, req.IncludeTotal)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort - map field names to database columns (giống NestJS: category.${sortBy})
-	sortByColumn := s.mapSortFieldToColumn(sortBy)
-	orderBy := fmt.Sprintf("%s %s", sortByColumn, sortOrder)
-	query = query.Order(orderBy)
+	// Count total (tùy chọn - bỏ qua mặc định khi phân trang theo cursor)
+	var total int64
+	if pagination.IncludeTotal {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, errors.New("không thể đếm số lượng danh mục")
+		}
+	}
 
-	// Pagination
-	offset := (page - 1) * limit
+	// Pagination - theo page (offset) hoặc theo cursor (keyset)
 	var categories []models.Category
-	if err := query.Offset(offset).Limit(limit).Find(&categories).Error; err != nil {
+	if err := pagination.paginate(query, key).Find(&categories).Error; err != nil {
 		return nil, errors.New("không thể lấy danh sách danh mục")
 	}
+	hasMore := len(categories) > limit
+	if hasMore {
+		categories = categories[:limit]
+	}
+	var nextCursor *string
+	if len(categories) > 0 {
+		if nextCursor, err = pagination.next(key, "categories", hasMore, categories[len(categories)-1].ID); err != nil {
+			return nil, errors.New("không thể tạo cursor trang tiếp theo")
+		}
+	}
 
 	// Transform với language nếu có
 	if language == "en" || language == "vi" {
@@ -519,14 +513,15 @@ func (s *CategoryService) Search(req dto.SearchCategoryRequest, language string)
 		}
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	totalCount, totalPages := pagination.totals(total)
 
 	return &dto.CategoryPaginationResponse{
 		Data:       categoryResponses,
-		Total:      total,
-		Page:       page,
+		Total:      totalCount,
+		Page:       pagination.Page,
 		Limit:      limit,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
@@ -706,13 +701,6 @@ func (s *CategoryService) transformCategory(cat models.Category, language string
 	return cat
 }
 
-// normalizeForSearch normalize string để tìm kiếm (xóa dấu, lowercase)
-func (s *CategoryService) normalizeForSearch(str string) string {
-	// Đơn giản hóa: chỉ lowercase và trim
-	// Trong production có thể dùng thư viện để xóa dấu tiếng Việt
-	return strings.ToLower(strings.TrimSpace(str))
-}
-
 // invalidateCategoryCache xóa tất cả cache liên quan đến categories
 func (s *CategoryService) invalidateCategoryCache() {
 	if cache.RedisClient == nil {
@@ -731,18 +719,18 @@ func (s *CategoryService) invalidateCategoryCacheByID(id uint) {
 	cache.DeletePattern(fmt.Sprintf("%s%d:*", cache.CategoryKeyPrefix, id))
 }
 
-// mapSortFieldToColumn map sort field name to database column name
-func (s *CategoryService) mapSortFieldToColumn(field string) string {
-	fieldMap := map[string]string{
-		"id":        "id",
-		"name":      "name",
-		"createdAt": "created_at",
-		"updatedAt": "updated_at",
+// sortKey map sort field name to database column name (kèm kiểu dữ liệu để tạo cursor)
+func (s *CategoryService) sortKey(field, order string) sortKey {
+	fieldMap := map[string]sortKey{
+		"id":        newSortKey("id", "id", cursorInt, order),
+		"name":      newSortKey("name", "name", cursorString, order),
+		"createdAt": newSortKey("createdAt", "created_at", cursorTime, order),
+		"updatedAt": newSortKey("updatedAt", "updated_at", cursorTime, order),
 	}
-	if column, ok := fieldMap[field]; ok {
-		return column
+	if key, ok := fieldMap[field]; ok {
+		return key
 	}
-	return "created_at" // default
+	return fieldMap["createdAt"] // default
 }
 
 // isAncestorOf kiểm tra xem ancestorID có phải là ancestor của descendantID không
@@ -963,10 +951,10 @@ func (s *CategoryService) SearchChildren(req dto.SearchCategoryChildRequest, lan
 		// Không có quan hệ nào → trả về empty
 		return &dto.CategoryPaginationResponse{
 			Data:       []dto.CategoryResponse{},
-			Total:      0,
+			Total:      new(int64),
 			Page:       page,
 			Limit:      limit,
-			TotalPages: 0,
+			TotalPages: new(int),
 		}, nil
 	}
 
@@ -979,34 +967,15 @@ func (s *CategoryService) SearchChildren(req dto.SearchCategoryChildRequest, lan
 	// Bước 2: Query categories với các childIDs này
 	query := database.DB.Model(&models.Category{}).Where("id IN ?", childIDs)
 
-	// Filter theo name (partial match, case-insensitive)
-	if req.Name != nil && *req.Name != "" {
-		normalizedSearch := s.normalizeForSearch(*req.Name)
-
-		// Lấy tất cả categories để filter trong memory
-		var allCategories []models.Category
-		if err := database.DB.Select("id", "name", "name_en").Where("id IN ?", childIDs).Find(&allCategories).Error; err != nil {
-			return nil, errors.New("không thể tìm kiếm danh mục con")
-		}
-
-		// Filter categories có tên chứa search term
-		var matchedIDs []uint
-		for _, cat := range allCategories {
-			nameVi := s.normalizeForSearch(cat.Name)
-			nameEn := ""
-			if cat.NameEn != nil {
-				nameEn = s.normalizeForSearch(*cat.NameEn)
-			}
-			if strings.Contains(nameVi, normalizedSearch) || (nameEn != "" && strings.Contains(nameEn, normalizedSearch)) {
-				matchedIDs = append(matchedIDs, cat.ID)
-			}
-		}
-
-		if len(matchedIDs) > 0 {
-			query = query.Where("id IN ?", matchedIDs)
-		} else {
-			// Nếu không tìm thấy → trả về empty result
-			query = query.Where("1 = 0") // Always false condition
+	// Search theo tên/mô tả (partial match, case-insensitive, không phân biệt dấu)
+	var search *textSearch
+	if req.Name != nil {
+		search = newTextSearch(*req.Name)
+	}
+	if search != nil {
+		query = search.apply(query)
+		if req.SortBy == nil {
+			sortBy = SortByRelevance
 		}
 	}
 
@@ -1053,10 +1022,12 @@ func (s *CategoryService) SearchChildren(req dto.SearchCategoryChildRequest, lan
 		return nil, errors.New("không thể đếm số lượng danh mục con")
 	}
 
-	// Sort - map field names to database columns
-	sortByColumn := s.mapSortFieldToColumn(sortBy)
-	orderBy := fmt.Sprintf("%s %s", sortByColumn, sortOrder)
-	query = query.Order(orderBy)
+	// Sort - map field names to database columns (relevance chỉ có ý nghĩa khi có từ khóa)
+	key := s.sortKey(sortBy, sortOrder)
+	if sortBy == SortByRelevance && search != nil {
+		key = search.relevanceKey(sortOrder)
+	}
+	query = key.apply(query, nil)
 
 	// Pagination
 	offset := (page - 1) * limit
@@ -1125,10 +1096,10 @@ func (s *CategoryService) SearchChildren(req dto.SearchCategoryChildRequest, lan
 
 	return &dto.CategoryPaginationResponse{
 		Data:       childrenResponses,
-		Total:      total,
+		Total:      &total,
 		Page:       page,
 		Limit:      limit,
-		TotalPages: totalPages,
+		TotalPages: &totalPages,
 	}, nil
 }
 
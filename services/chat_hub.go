@@ -0,0 +1,300 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"log"
+	"sync"
+	"time"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/dto"
+
+	"github.com/gorilla/websocket"
+)
+
+const (
+	// chatEventsChannel là kênh Redis pub/sub dùng để fan-out sự kiện chat giữa các instance
+	chatEventsChannel = "chat:events"
+
+	chatWriteWait      = 10 * time.Second
+	chatPongWait       = 60 * time.Second
+	chatPingPeriod     = (chatPongWait * 9) / 10
+	chatMaxMessageSize = 8192
+	chatSendBufferSize = 64
+)
+
+// chatEnvelope là sự kiện đã được encode, gửi qua Redis hoặc phát trực tiếp trong process
+type chatEnvelope struct {
+	ChatID       uint            `json:"chatId"`
+	NotifyAdmins bool            `json:"notifyAdmins"` // Gửi cho mọi admin đang online (vd: có chat mới)
+	Payload      json.RawMessage `json:"payload"`
+}
+
+// ChatHub quản lý các kết nối WebSocket và phòng chat trong process
+// Khi có Redis, mọi sự kiện đi qua Redis pub/sub để client ở instance khác cũng nhận được
+type ChatHub struct {
+	mu          sync.RWMutex
+	rooms       map[uint]map[*ChatClient]bool
+	clientRooms map[*ChatClient]map[uint]bool
+	admins      map[*ChatClient]bool
+}
+
+var (
+	chatHub     *ChatHub
+	chatHubOnce sync.Once
+)
+
+// GetChatHub trả về hub dùng chung của process (khởi tạo lần đầu khi được gọi)
+func GetChatHub() *ChatHub {
+	chatHubOnce.Do(func() {
+		chatHub = &ChatHub{
+			rooms:       make(map[uint]map[*ChatClient]bool),
+			clientRooms: make(map[*ChatClient]map[uint]bool),
+			admins:      make(map[*ChatClient]bool),
+		}
+		if cache.RedisClient != nil {
+			go chatHub.subscribe()
+		}
+	})
+	return chatHub
+}
+
+// Register đăng ký một kết nối WebSocket mới được mở bằng access token auth
+// revalidate được gọi ở mỗi lần ping, trả về false để đóng kết nối (token bị thu hồi, mất quyền...)
+func (h *ChatHub) Register(conn *websocket.Conn, auth ChatClientAuth, revalidate func(client *ChatClient) bool) *ChatClient {
+	client := &ChatClient{
+		UserID:     auth.UserID,
+		IsAdmin:    auth.IsAdmin,
+		auth:       auth,
+		revalidate: revalidate,
+		hub:        h,
+		conn:       conn,
+		send:       make(chan []byte, chatSendBufferSize),
+		done:       make(chan struct{}),
+	}
+
+	h.mu.Lock()
+	h.clientRooms[client] = make(map[uint]bool)
+	if auth.IsAdmin {
+		h.admins[client] = true
+	}
+	h.mu.Unlock()
+
+	return client
+}
+
+// Join thêm client vào phòng chat (caller phải kiểm tra quyền trước)
+func (h *ChatHub) Join(client *ChatClient, chatID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	rooms, ok := h.clientRooms[client]
+	if !ok {
+		return // client đã ngắt kết nối
+	}
+	if h.rooms[chatID] == nil {
+		h.rooms[chatID] = make(map[*ChatClient]bool)
+	}
+	h.rooms[chatID][client] = true
+	rooms[chatID] = true
+}
+
+// Leave xóa client khỏi phòng chat
+func (h *ChatHub) Leave(client *ChatClient, chatID uint) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.removeFromRoom(client, chatID)
+}
+
+func (h *ChatHub) unregister(client *ChatClient) {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	for chatID := range h.clientRooms[client] {
+		h.removeFromRoom(client, chatID)
+	}
+	delete(h.clientRooms, client)
+	delete(h.admins, client)
+}
+
+// removeFromRoom phải được gọi khi đang giữ h.mu
+func (h *ChatHub) removeFromRoom(client *ChatClient, chatID uint) {
+	if members, ok := h.rooms[chatID]; ok {
+		delete(members, client)
+		if len(members) == 0 {
+			delete(h.rooms, chatID)
+		}
+	}
+	if rooms, ok := h.clientRooms[client]; ok {
+		delete(rooms, chatID)
+	}
+}
+
+// Publish phát sự kiện tới mọi client trong phòng chat (và tới admin nếu notifyAdmins)
+func (h *ChatHub) Publish(event dto.ChatEvent, notifyAdmins bool) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể encode sự kiện chat: %v", err)
+		return
+	}
+	envelope := chatEnvelope{
+		ChatID:       event.ChatID,
+		NotifyAdmins: notifyAdmins,
+		Payload:      payload,
+	}
+
+	if cache.RedisClient != nil {
+		data, err := json.Marshal(envelope)
+		if err == nil {
+			err = cache.RedisClient.Publish(context.Background(), chatEventsChannel, data).Err()
+		}
+		if err == nil {
+			return
+		}
+		// Redis lỗi → vẫn phát cho client trong process hiện tại
+		log.Printf("⚠️  Warning: Không thể publish sự kiện chat qua Redis: %v", err)
+	}
+
+	h.dispatch(envelope)
+}
+
+// subscribe nhận sự kiện từ Redis và phát cho các client của process hiện tại
+func (h *ChatHub) subscribe() {
+	pubsub := cache.RedisClient.Subscribe(context.Background(), chatEventsChannel)
+	defer pubsub.Close()
+
+	for msg := range pubsub.Channel() {
+		var envelope chatEnvelope
+		if err := json.Unmarshal([]byte(msg.Payload), &envelope); err != nil {
+			log.Printf("⚠️  Warning: Sự kiện chat không hợp lệ từ Redis: %v", err)
+			continue
+		}
+		h.dispatch(envelope)
+	}
+}
+
+func (h *ChatHub) dispatch(envelope chatEnvelope) {
+	h.mu.RLock()
+	targets := make(map[*ChatClient]bool, len(h.rooms[envelope.ChatID]))
+	for client := range h.rooms[envelope.ChatID] {
+		targets[client] = true
+	}
+	if envelope.NotifyAdmins {
+		for client := range h.admins {
+			targets[client] = true
+		}
+	}
+	h.mu.RUnlock()
+
+	for client := range targets {
+		client.enqueue(envelope.Payload)
+	}
+}
+
+// ChatClient là một kết nối WebSocket của user
+// UserID và IsAdmin cố định trong suốt kết nối, quyền thay đổi thì kết nối bị đóng để client mở lại
+type ChatClient struct {
+	UserID  uint
+	IsAdmin bool
+
+	auth       ChatClientAuth // jti, sid, exp của access token đã dùng để mở kết nối
+	revalidate func(client *ChatClient) bool
+	hub        *ChatHub
+	conn       *websocket.Conn
+	send       chan []byte
+	done       chan struct{}
+	closeOnce  sync.Once
+}
+
+// SendEvent gửi sự kiện chỉ cho client này
+func (c *ChatClient) SendEvent(event dto.ChatEvent) {
+	payload, err := json.Marshal(event)
+	if err != nil {
+		return
+	}
+	c.enqueue(payload)
+}
+
+func (c *ChatClient) enqueue(payload []byte) {
+	select {
+	case <-c.done:
+	case c.send <- payload:
+	default:
+		// Client đọc quá chậm → ngắt kết nối thay vì chặn cả hub
+		go c.Close()
+	}
+}
+
+// Close ngắt kết nối và hủy đăng ký client khỏi hub (gọi nhiều lần vẫn an toàn)
+func (c *ChatClient) Close() {
+	c.closeOnce.Do(func() {
+		c.hub.unregister(c)
+		close(c.done)
+		c.conn.Close()
+	})
+}
+
+// ReadPump đọc các frame từ client và chuyển cho handle cho tới khi kết nối đóng
+func (c *ChatClient) ReadPump(handle func(client *ChatClient, data []byte)) {
+	defer c.Close()
+
+	c.conn.SetReadLimit(chatMaxMessageSize)
+	c.conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	c.conn.SetPongHandler(func(string) error {
+		return c.conn.SetReadDeadline(time.Now().Add(chatPongWait))
+	})
+
+	for {
+		_, data, err := c.conn.ReadMessage()
+		if err != nil {
+			if websocket.IsUnexpectedCloseError(err, websocket.CloseGoingAway, websocket.CloseNormalClosure) {
+				log.Printf("⚠️  Warning: Kết nối chat của user %d bị đóng bất thường: %v", c.UserID, err)
+			}
+			return
+		}
+		handle(c, data)
+	}
+}
+
+// WritePump ghi các sự kiện xuống client và gửi ping định kỳ để giữ kết nối
+// Kết nối bị đóng khi access token hết hạn hoặc khi kiểm tra lại ở mỗi lần ping thất bại
+func (c *ChatClient) WritePump() {
+	ticker := time.NewTicker(chatPingPeriod)
+	expiry := time.NewTimer(time.Until(c.auth.ExpiresAt))
+	defer func() {
+		ticker.Stop()
+		expiry.Stop()
+		c.Close()
+	}()
+
+	for {
+		select {
+		case <-c.done:
+			return
+		case payload := <-c.send:
+			c.conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := c.conn.WriteMessage(websocket.TextMessage, payload); err != nil {
+				return
+			}
+		case <-expiry.C:
+			c.writeClose(websocket.ClosePolicyViolation, "token đã hết hạn")
+			return
+		case <-ticker.C:
+			if c.revalidate != nil && !c.revalidate(c) {
+				c.writeClose(websocket.ClosePolicyViolation, "phiên đăng nhập đã bị thu hồi hoặc quyền đã thay đổi")
+				return
+			}
+			c.conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+			if err := c.conn.WriteMessage(websocket.PingMessage, nil); err != nil {
+				return
+			}
+		}
+	}
+}
+
+// writeClose gửi close frame kèm lý do trước khi đóng kết nối (client dựa vào đó để lấy ticket mới)
+func (c *ChatClient) writeClose(code int, reason string) {
+	c.conn.SetWriteDeadline(time.Now().Add(chatWriteWait))
+	c.conn.WriteMessage(websocket.CloseMessage, websocket.FormatCloseMessage(code, reason))
+}
@@ -0,0 +1,523 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ChatService struct {
+	hub         *ChatHub
+	roleService *RoleService
+}
+
+func NewChatService() *ChatService {
+	return &ChatService{
+		hub:         GetChatHub(),
+		roleService: NewRoleService(),
+	}
+}
+
+// Open khách hàng mở cuộc trò chuyện hỗ trợ mới (trạng thái pending cho tới khi có admin nhận)
+func (s *ChatService) Open(customerID uint, req dto.OpenChatRequest) (*dto.ChatResponse, error) {
+	subject := strings.TrimSpace(req.Subject)
+	if subject == "" {
+		return nil, errors.New("chủ đề không được để trống")
+	}
+
+	chat := models.Chat{
+		Subject:    subject,
+		Status:     models.ChatStatusPending,
+		CustomerID: customerID,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(&chat).Error; err != nil {
+			return errors.New("không thể tạo cuộc trò chuyện")
+		}
+
+		if req.Message != nil && strings.TrimSpace(*req.Message) != "" {
+			message := models.ChatMessage{
+				Content:  strings.TrimSpace(*req.Message),
+				Type:     models.MessageTypeText,
+				ChatID:   chat.ID,
+				SenderID: customerID,
+			}
+			if err := tx.Create(&message).Error; err != nil {
+				return errors.New("không thể gửi tin nhắn")
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response, err := s.FindOne(customerID, false, chat.ID)
+	if err != nil {
+		return nil, err
+	}
+
+	// Báo cho các admin đang online có chat mới
+	s.hub.Publish(dto.ChatEvent{Type: "chat_opened", ChatID: chat.ID, Data: response}, true)
+	return response, nil
+}
+
+// List lấy danh sách cuộc trò chuyện
+// Khách hàng chỉ thấy chat của mình; admin thấy tất cả (có thể lọc assigned = me | unassigned)
+func (s *ChatService) List(userID uint, isAdmin bool, status, assigned string, page, limit int) (*dto.ChatPaginationResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 20
+	}
+
+	query := database.DB.Model(&models.Chat{})
+	if !isAdmin {
+		query = query.Where("customer_id = ?", userID)
+	} else {
+		switch assigned {
+		case "me":
+			query = query.Where("admin_id = ?", userID)
+		case "unassigned":
+			query = query.Where("admin_id IS NULL")
+		}
+	}
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("không thể đếm số lượng cuộc trò chuyện")
+	}
+
+	var chats []models.Chat
+	if err := query.Preload("Customer").
+		Preload("Admin").
+		Order("updated_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&chats).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách cuộc trò chuyện")
+	}
+
+	responses, err := s.mapChatsWithSummary(chats, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.ChatPaginationResponse{
+		Data:       responses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
+
+// FindOne lấy thông tin một cuộc trò chuyện
+func (s *ChatService) FindOne(userID uint, isAdmin bool, chatID uint) (*dto.ChatResponse, error) {
+	chat, err := s.findAccessibleChat(database.DB, userID, isAdmin, chatID)
+	if err != nil {
+		return nil, err
+	}
+	if err := database.DB.Preload("Customer").Preload("Admin").First(chat, chat.ID).Error; err != nil {
+		return nil, err
+	}
+
+	responses, err := s.mapChatsWithSummary([]models.Chat{*chat}, userID)
+	if err != nil {
+		return nil, err
+	}
+	return &responses[0], nil
+}
+
+// Close đóng cuộc trò chuyện (khách hàng hoặc admin)
+func (s *ChatService) Close(userID uint, isAdmin bool, chatID uint) (*dto.ChatResponse, error) {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		chat, err := s.findAccessibleChat(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, isAdmin, chatID)
+		if err != nil {
+			return err
+		}
+		if chat.Status == models.ChatStatusClosed {
+			return errors.New("cuộc trò chuyện đã được đóng")
+		}
+
+		if err := tx.Model(chat).Update("status", models.ChatStatusClosed).Error; err != nil {
+			return errors.New("không thể đóng cuộc trò chuyện")
+		}
+
+		return s.createSystemMessage(tx, chat.ID, userID, "Cuộc trò chuyện đã được đóng")
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publishChatUpdated(userID, isAdmin, chatID)
+}
+
+// Assign phân công admin phụ trách cuộc trò chuyện (Chỉ admin)
+// Không truyền adminId = admin hiện tại tự nhận chat
+func (s *ChatService) Assign(actorID, chatID uint, req dto.AssignChatRequest) (*dto.ChatResponse, error) {
+	adminID := actorID
+	if req.AdminID != nil {
+		adminID = *req.AdminID
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var admin models.User
+		if err := tx.Where("id = ? AND is_active = ?", adminID, true).First(&admin).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("không tìm thấy admin với ID %d", adminID)
+			}
+			return err
+		}
+		canSupport, err := s.roleService.HasPermission(admin.Role, models.PermissionChatSupport)
+		if err != nil {
+			return err
+		}
+		if !canSupport {
+			return errors.New("chỉ có thể phân công cho tài khoản có quyền hỗ trợ khách hàng")
+		}
+
+		chat, err := s.findAccessibleChat(tx.Clauses(clause.Locking{Strength: "UPDATE"}), actorID, true, chatID)
+		if err != nil {
+			return err
+		}
+		if chat.Status == models.ChatStatusClosed {
+			return errors.New("cuộc trò chuyện đã được đóng")
+		}
+
+		if err := tx.Model(chat).Updates(map[string]interface{}{
+			"admin_id": adminID,
+			"status":   models.ChatStatusOpen,
+		}).Error; err != nil {
+			return errors.New("không thể phân công cuộc trò chuyện")
+		}
+
+		return s.createSystemMessage(tx, chat.ID, actorID, fmt.Sprintf("%s đã tham gia cuộc trò chuyện", admin.Name))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return s.publishChatUpdated(actorID, true, chatID)
+}
+
+// ListMessages lấy tin nhắn của cuộc trò chuyện (cũ → mới)
+// beforeID > 0 để tải các tin nhắn cũ hơn (cuộn lên)
+func (s *ChatService) ListMessages(userID uint, isAdmin bool, chatID, beforeID uint, limit int) ([]dto.ChatMessageResponse, error) {
+	if limit < 1 || limit > 100 {
+		limit = 50
+	}
+
+	if _, err := s.findAccessibleChat(database.DB, userID, isAdmin, chatID); err != nil {
+		return nil, err
+	}
+
+	query := database.DB.Where("chat_id = ?", chatID)
+	if beforeID > 0 {
+		query = query.Where("id < ?", beforeID)
+	}
+
+	var messages []models.ChatMessage
+	if err := query.Preload("Sender").
+		Order("id DESC").
+		Limit(limit).
+		Find(&messages).Error; err != nil {
+		return nil, errors.New("không thể lấy tin nhắn")
+	}
+
+	responses := make([]dto.ChatMessageResponse, len(messages))
+	for i := range messages {
+		responses[len(messages)-1-i] = *mapChatMessageToResponse(&messages[i])
+	}
+	return responses, nil
+}
+
+// SendMessage gửi tin nhắn vào cuộc trò chuyện và phát tới các client đang tham gia
+// Admin trả lời chat chưa có người phụ trách sẽ tự động nhận chat
+func (s *ChatService) SendMessage(userID uint, isAdmin bool, chatID uint, content string, messageType string) (*dto.ChatMessageResponse, error) {
+	content = strings.TrimSpace(content)
+	if content == "" {
+		return nil, errors.New("nội dung tin nhắn không được để trống")
+	}
+	if len(content) > 5000 {
+		return nil, errors.New("tin nhắn quá dài (tối đa 5000 ký tự)")
+	}
+	switch models.MessageType(messageType) {
+	case "":
+		messageType = string(models.MessageTypeText)
+	case models.MessageTypeText, models.MessageTypeImage, models.MessageTypeFile:
+	default:
+		return nil, fmt.Errorf("loại tin nhắn không hợp lệ: %s", messageType)
+	}
+
+	var message models.ChatMessage
+	var assigned bool
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		chat, err := s.findAccessibleChat(tx.Clauses(clause.Locking{Strength: "UPDATE"}), userID, isAdmin, chatID)
+		if err != nil {
+			return err
+		}
+		if chat.Status == models.ChatStatusClosed {
+			return errors.New("cuộc trò chuyện đã được đóng")
+		}
+
+		updates := map[string]interface{}{"updated_at": time.Now()} // Đưa chat lên đầu danh sách
+		if isAdmin && chat.AdminID == nil && chat.CustomerID != userID {
+			updates["admin_id"] = userID
+			updates["status"] = models.ChatStatusOpen
+			assigned = true
+		}
+		if err := tx.Model(chat).Updates(updates).Error; err != nil {
+			return errors.New("không thể cập nhật cuộc trò chuyện")
+		}
+
+		message = models.ChatMessage{
+			Content:  content,
+			Type:     models.MessageType(messageType),
+			ChatID:   chat.ID,
+			SenderID: userID,
+		}
+		if err := tx.Create(&message).Error; err != nil {
+			return errors.New("không thể gửi tin nhắn")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	database.DB.Preload("Sender").First(&message, message.ID)
+	response := mapChatMessageToResponse(&message)
+
+	s.hub.Publish(dto.ChatEvent{Type: "message", ChatID: chatID, Data: response}, false)
+	if assigned {
+		s.publishChatUpdated(userID, isAdmin, chatID)
+	}
+	return response, nil
+}
+
+// MarkRead đánh dấu đã đọc các tin nhắn của bên kia và gửi read receipt
+func (s *ChatService) MarkRead(userID uint, isAdmin bool, chatID uint) (*dto.ChatReadReceipt, error) {
+	if _, err := s.findAccessibleChat(database.DB, userID, isAdmin, chatID); err != nil {
+		return nil, err
+	}
+
+	var messages []models.ChatMessage
+	if err := database.DB.Model(&messages).
+		Clauses(clause.Returning{Columns: []clause.Column{{Name: "id"}}}).
+		Where("chat_id = ? AND sender_id <> ? AND is_read = ?", chatID, userID, false).
+		Update("is_read", true).Error; err != nil {
+		return nil, errors.New("không thể đánh dấu đã đọc")
+	}
+
+	receipt := &dto.ChatReadReceipt{
+		ReaderID:   userID,
+		MessageIDs: make([]uint, len(messages)),
+	}
+	for i, message := range messages {
+		receipt.MessageIDs[i] = message.ID
+	}
+
+	if len(receipt.MessageIDs) > 0 {
+		s.hub.Publish(dto.ChatEvent{Type: "read", ChatID: chatID, Data: receipt}, false)
+	}
+	return receipt, nil
+}
+
+// HandleClientMessage xử lý một frame client gửi lên qua WebSocket
+func (s *ChatService) HandleClientMessage(client *ChatClient, data []byte) {
+	var msg dto.ChatClientMessage
+	if err := json.Unmarshal(data, &msg); err != nil {
+		client.SendEvent(dto.ChatEvent{Type: "error", Error: "dữ liệu không hợp lệ"})
+		return
+	}
+
+	sendError := func(err error) {
+		client.SendEvent(dto.ChatEvent{Type: "error", ChatID: msg.ChatID, Error: err.Error()})
+	}
+
+	switch msg.Type {
+	case "join":
+		if _, err := s.findAccessibleChat(database.DB, client.UserID, client.IsAdmin, msg.ChatID); err != nil {
+			sendError(err)
+			return
+		}
+		s.hub.Join(client, msg.ChatID)
+		client.SendEvent(dto.ChatEvent{Type: "joined", ChatID: msg.ChatID})
+	case "leave":
+		s.hub.Leave(client, msg.ChatID)
+		client.SendEvent(dto.ChatEvent{Type: "left", ChatID: msg.ChatID})
+	case "message":
+		// Tự động tham gia phòng để nhận lại tin nhắn (ack) và các trả lời
+		if _, err := s.findAccessibleChat(database.DB, client.UserID, client.IsAdmin, msg.ChatID); err != nil {
+			sendError(err)
+			return
+		}
+		s.hub.Join(client, msg.ChatID)
+		if _, err := s.SendMessage(client.UserID, client.IsAdmin, msg.ChatID, msg.Content, msg.MessageType); err != nil {
+			sendError(err)
+		}
+	case "read":
+		if _, err := s.MarkRead(client.UserID, client.IsAdmin, msg.ChatID); err != nil {
+			sendError(err)
+		}
+	case "typing":
+		if _, err := s.findAccessibleChat(database.DB, client.UserID, client.IsAdmin, msg.ChatID); err != nil {
+			sendError(err)
+			return
+		}
+		s.hub.Publish(dto.ChatEvent{Type: "typing", ChatID: msg.ChatID, Data: map[string]interface{}{"userId": client.UserID}}, false)
+	default:
+		sendError(fmt.Errorf("loại frame không hợp lệ: %s", msg.Type))
+	}
+}
+
+// findAccessibleChat lấy chat nếu user là khách hàng của chat hoặc là admin
+func (s *ChatService) findAccessibleChat(db *gorm.DB, userID uint, isAdmin bool, chatID uint) (*models.Chat, error) {
+	var chat models.Chat
+	query := db.Where("id = ?", chatID)
+	if !isAdmin {
+		query = query.Where("customer_id = ?", userID)
+	}
+	if err := query.First(&chat).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("không tìm thấy cuộc trò chuyện")
+		}
+		return nil, err
+	}
+	return &chat, nil
+}
+
+func (s *ChatService) createSystemMessage(tx *gorm.DB, chatID, senderID uint, content string) error {
+	message := models.ChatMessage{
+		Content:  content,
+		Type:     models.MessageTypeSystem,
+		IsRead:   true,
+		ChatID:   chatID,
+		SenderID: senderID,
+	}
+	if err := tx.Create(&message).Error; err != nil {
+		return errors.New("không thể tạo tin nhắn hệ thống")
+	}
+	return nil
+}
+
+// publishChatUpdated gửi trạng thái mới của chat tới phòng chat và các admin
+func (s *ChatService) publishChatUpdated(userID uint, isAdmin bool, chatID uint) (*dto.ChatResponse, error) {
+	response, err := s.FindOne(userID, isAdmin, chatID)
+	if err != nil {
+		return nil, err
+	}
+	s.hub.Publish(dto.ChatEvent{Type: "chat_updated", ChatID: chatID, Data: response}, true)
+	return response, nil
+}
+
+// mapChatsWithSummary map chat kèm tin nhắn cuối và số tin chưa đọc của user
+func (s *ChatService) mapChatsWithSummary(chats []models.Chat, userID uint) ([]dto.ChatResponse, error) {
+	responses := make([]dto.ChatResponse, len(chats))
+	if len(chats) == 0 {
+		return responses, nil
+	}
+
+	chatIDs := make([]uint, len(chats))
+	for i, chat := range chats {
+		chatIDs[i] = chat.ID
+	}
+
+	var lastMessages []models.ChatMessage
+	if err := database.DB.Raw(`
+		SELECT DISTINCT ON (chat_id) * FROM chat_messages
+		WHERE chat_id IN ? AND deleted_at IS NULL
+		ORDER BY chat_id, id DESC
+	`, chatIDs).Scan(&lastMessages).Error; err != nil {
+		return nil, errors.New("không thể lấy tin nhắn cuối")
+	}
+	lastByChat := make(map[uint]*models.ChatMessage, len(lastMessages))
+	for i := range lastMessages {
+		lastByChat[lastMessages[i].ChatID] = &lastMessages[i]
+	}
+
+	var unread []struct {
+		ChatID uint
+		Count  int64
+	}
+	if err := database.DB.Model(&models.ChatMessage{}).
+		Select("chat_id, COUNT(*) AS count").
+		Where("chat_id IN ? AND sender_id <> ? AND is_read = ?", chatIDs, userID, false).
+		Group("chat_id").
+		Scan(&unread).Error; err != nil {
+		return nil, errors.New("không thể đếm tin nhắn chưa đọc")
+	}
+	unreadByChat := make(map[uint]int64, len(unread))
+	for _, row := range unread {
+		unreadByChat[row.ChatID] = row.Count
+	}
+
+	for i := range chats {
+		responses[i] = *mapChatToResponse(&chats[i])
+		if last, ok := lastByChat[chats[i].ID]; ok {
+			responses[i].LastMessage = mapChatMessageToResponse(last)
+		}
+		responses[i].UnreadCount = unreadByChat[chats[i].ID]
+	}
+	return responses, nil
+}
+
+// Helper function để map Chat sang ChatResponse
+func mapChatToResponse(chat *models.Chat) *dto.ChatResponse {
+	response := &dto.ChatResponse{
+		ID:         chat.ID,
+		Subject:    chat.Subject,
+		Status:     string(chat.Status),
+		CustomerID: chat.CustomerID,
+		AdminID:    chat.AdminID,
+		CreatedAt:  chat.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  chat.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+	if chat.Customer.ID > 0 {
+		response.Customer = mapChatUser(&chat.Customer)
+	}
+	if chat.Admin != nil && chat.Admin.ID > 0 {
+		response.Admin = mapChatUser(chat.Admin)
+	}
+	return response
+}
+
+func mapChatUser(user *models.User) *dto.ChatUser {
+	return &dto.ChatUser{
+		ID:     user.ID,
+		Name:   user.Name,
+		Email:  user.Email,
+		Avatar: user.Avatar,
+	}
+}
+
+// Helper function để map ChatMessage sang ChatMessageResponse
+func mapChatMessageToResponse(message *models.ChatMessage) *dto.ChatMessageResponse {
+	return &dto.ChatMessageResponse{
+		ID:         message.ID,
+		ChatID:     message.ChatID,
+		SenderID:   message.SenderID,
+		SenderName: message.Sender.Name,
+		Content:    message.Content,
+		Type:       string(message.Type),
+		IsRead:     message.IsRead,
+		CreatedAt:  message.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
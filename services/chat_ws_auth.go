@@ -0,0 +1,142 @@
+package services
+
+import (
+	"encoding/json"
+	"errors"
+	"log"
+	"time"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+	"ecommerce-be/utils"
+
+	"github.com/gorilla/websocket"
+	"gorm.io/gorm"
+)
+
+// ChatWSTicketTTL thời gian sống của ticket WebSocket (client phải mở kết nối ngay sau khi lấy ticket)
+const ChatWSTicketTTL = 30 * time.Second
+
+// ErrInvalidChatTicket - Ticket WebSocket không tồn tại, đã dùng hoặc đã hết hạn
+var ErrInvalidChatTicket = errors.New("ticket không hợp lệ hoặc đã hết hạn")
+
+// errChatAccessRevoked - Access token của kết nối đã bị thu hồi hoặc tài khoản không còn hoạt động
+var errChatAccessRevoked = errors.New("phiên đăng nhập đã bị thu hồi hoặc tài khoản đã bị vô hiệu hóa")
+
+// ChatClientAuth thông tin access token mà kết nối WebSocket được mở bằng
+// Được lưu trên ChatClient để đóng kết nối khi token hết hạn hoặc bị thu hồi
+type ChatClientAuth struct {
+	UserID    uint      `json:"userId"`
+	SessionID uint      `json:"sessionId"`
+	TokenID   string    `json:"jti"`
+	IssuedAt  time.Time `json:"iat"`
+	ExpiresAt time.Time `json:"exp"`
+	IsAdmin   bool      `json:"-"`
+}
+
+// IssueWSTicket đổi access token (đã xác thực bởi AuthMiddleware) lấy ticket dùng một lần để mở WebSocket
+// Ticket được truyền qua query nên không bao giờ đưa access token vào URL (tránh lộ trong access log)
+func (s *ChatService) IssueWSTicket(claims *utils.Claims) (*dto.ChatWSTicketResponse, error) {
+	auth := ChatClientAuth{
+		UserID:    claims.UserID,
+		SessionID: claims.SessionID,
+		TokenID:   claims.ID,
+		IssuedAt:  claims.IssuedAtTime(),
+	}
+	if claims.ExpiresAt != nil {
+		auth.ExpiresAt = claims.ExpiresAt.Time
+	}
+
+	value, err := json.Marshal(auth)
+	if err != nil {
+		return nil, errors.New("không thể tạo ticket")
+	}
+	ticket, err := generateRandomHex(32)
+	if err != nil {
+		return nil, errors.New("không thể tạo ticket")
+	}
+	if err := cache.StoreWSTicket(ticket, string(value), ChatWSTicketTTL); err != nil {
+		log.Printf("⚠️  Warning: Không thể lưu ticket WebSocket: %v", err)
+		return nil, errors.New("không thể tạo ticket")
+	}
+
+	return &dto.ChatWSTicketResponse{
+		Ticket:    ticket,
+		ExpiresIn: int(ChatWSTicketTTL.Seconds()),
+	}, nil
+}
+
+// RedeemWSTicket dùng ticket (chỉ một lần) và kiểm tra lại access token, tài khoản và quyền chat:support của user
+func (s *ChatService) RedeemWSTicket(ticket string) (*ChatClientAuth, error) {
+	if ticket == "" {
+		return nil, ErrInvalidChatTicket
+	}
+
+	value, err := cache.ConsumeWSTicket(ticket)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể đọc ticket WebSocket: %v", err)
+		return nil, ErrInvalidChatTicket
+	}
+	if value == "" {
+		return nil, ErrInvalidChatTicket
+	}
+
+	var auth ChatClientAuth
+	if err := json.Unmarshal([]byte(value), &auth); err != nil || !time.Now().Before(auth.ExpiresAt) {
+		return nil, ErrInvalidChatTicket
+	}
+
+	isAdmin, err := s.authorizeChatClient(&auth)
+	if err != nil {
+		return nil, err
+	}
+	auth.IsAdmin = isAdmin
+	return &auth, nil
+}
+
+// Connect đăng ký kết nối WebSocket đã xác thực bằng ticket vào hub
+func (s *ChatService) Connect(conn *websocket.Conn, auth *ChatClientAuth) *ChatClient {
+	return s.hub.Register(conn, *auth, s.revalidateChatClient)
+}
+
+// authorizeChatClient kiểm tra access token chưa bị thu hồi, user còn hoạt động và trả về quyền chat:support hiện tại
+// Lỗi denylist được bỏ qua giống AuthMiddleware vì user vẫn được kiểm tra lại trong database
+func (s *ChatService) authorizeChatClient(auth *ChatClientAuth) (bool, error) {
+	denied, err := cache.IsTokenDenied(auth.TokenID, auth.SessionID, auth.UserID, auth.IssuedAt)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể kiểm tra token denylist: %v", err)
+	}
+	if denied {
+		return false, errChatAccessRevoked
+	}
+
+	var user models.User
+	if err := database.DB.Select("id", "role", "is_active").First(&user, auth.UserID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, errChatAccessRevoked
+		}
+		return false, err
+	}
+	if !user.IsActive {
+		return false, errChatAccessRevoked
+	}
+
+	return s.roleService.HasPermission(user.Role, models.PermissionChatSupport)
+}
+
+// revalidateChatClient được hub gọi định kỳ cho mỗi kết nối đang mở
+// Trả về false nếu phải đóng kết nối: token bị thu hồi, tài khoản bị vô hiệu hóa hoặc quyền chat:support đã thay đổi
+// (client mở lại kết nối bằng ticket mới để nhận đúng quyền). Lỗi tạm thời của database thì giữ kết nối
+func (s *ChatService) revalidateChatClient(client *ChatClient) bool {
+	isAdmin, err := s.authorizeChatClient(&client.auth)
+	if err != nil {
+		if errors.Is(err, errChatAccessRevoked) {
+			return false
+		}
+		log.Printf("⚠️  Warning: Không thể kiểm tra lại quyền kết nối chat của user %d: %v", client.UserID, err)
+		return true
+	}
+	return isAdmin == client.IsAdmin
+}
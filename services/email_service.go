@@ -82,6 +82,112 @@ func (s *EmailService) SendOtpEmail(email, otp, name string) error {
 
 	m.SetBody("text/html", htmlBody)
 
+	return s.send(m)
+}
+
+// SendPasswordResetEmail gửi OTP đặt lại mật khẩu qua email
+func (s *EmailService) SendPasswordResetEmail(email, otp, name string) error {
+	// Log OTP ra terminal để test
+	fmt.Printf("\n📧 ===== PASSWORD RESET EMAIL =====\n")
+	fmt.Printf("📨 Gửi đến: %s\n", email)
+	fmt.Printf("👤 Người nhận: %s\n", name)
+	fmt.Printf("🔑 Mã OTP: %s\n", otp)
+	fmt.Printf("⏰ Thời gian: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Printf("⏳ Hết hạn sau: 5 phút\n")
+	fmt.Printf("===================================\n\n")
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.fromEmail)
+	m.SetHeader("To", email)
+	m.SetHeader("Subject", "Mã OTP đặt lại mật khẩu")
+
+	htmlBody := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2 style="color: #333;">Đặt lại mật khẩu</h2>
+			<p>Xin chào <strong>%s</strong>,</p>
+			<p>Chúng tôi đã nhận được yêu cầu đặt lại mật khẩu cho tài khoản của bạn. Vui lòng sử dụng mã OTP sau:</p>
+			<div style="background-color: #f4f4f4; padding: 20px; text-align: center; margin: 20px 0;">
+				<h1 style="color: #dc3545; font-size: 32px; margin: 0;">%s</h1>
+			</div>
+			<p>Mã OTP này sẽ hết hạn sau <strong>5 phút</strong>.</p>
+			<p>Sau khi đặt lại mật khẩu, bạn sẽ bị đăng xuất khỏi tất cả thiết bị.</p>
+			<p>Nếu bạn không yêu cầu đặt lại mật khẩu, vui lòng bỏ qua email này. Mật khẩu của bạn sẽ không thay đổi.</p>
+			<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+			<p style="color: #666; font-size: 12px;">Đây là email tự động, vui lòng không trả lời.</p>
+		</div>
+	`, name, otp)
+
+	m.SetBody("text/html", htmlBody)
+
+	return s.send(m)
+}
+
+// SendEmailChangeOtpEmail gửi OTP xác thực tới email mới khi đổi email
+func (s *EmailService) SendEmailChangeOtpEmail(email, otp, name string) error {
+	// Log OTP ra terminal để test
+	fmt.Printf("\n📧 ===== CHANGE EMAIL OTP =====\n")
+	fmt.Printf("📨 Gửi đến: %s\n", email)
+	fmt.Printf("👤 Người nhận: %s\n", name)
+	fmt.Printf("🔑 Mã OTP: %s\n", otp)
+	fmt.Printf("⏰ Thời gian: %s\n", time.Now().Format("2006-01-02 15:04:05"))
+	fmt.Printf("⏳ Hết hạn sau: 5 phút\n")
+	fmt.Printf("===============================\n\n")
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.fromEmail)
+	m.SetHeader("To", email)
+	m.SetHeader("Subject", "Mã OTP xác thực email mới")
+
+	htmlBody := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2 style="color: #333;">Xác thực email mới</h2>
+			<p>Xin chào <strong>%s</strong>,</p>
+			<p>Bạn đã yêu cầu đổi email tài khoản sang địa chỉ này. Vui lòng sử dụng mã OTP sau để xác nhận:</p>
+			<div style="background-color: #f4f4f4; padding: 20px; text-align: center; margin: 20px 0;">
+				<h1 style="color: #007bff; font-size: 32px; margin: 0;">%s</h1>
+			</div>
+			<p>Mã OTP này sẽ hết hạn sau <strong>5 phút</strong>.</p>
+			<p>Nếu bạn không yêu cầu đổi email, vui lòng bỏ qua email này.</p>
+			<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+			<p style="color: #666; font-size: 12px;">Đây là email tự động, vui lòng không trả lời.</p>
+		</div>
+	`, name, otp)
+
+	m.SetBody("text/html", htmlBody)
+
+	return s.send(m)
+}
+
+// SendEmailChangedNotification thông báo tới email cũ rằng email tài khoản đã được đổi
+func (s *EmailService) SendEmailChangedNotification(oldEmail, newEmail, name string) error {
+	fmt.Printf("\n📧 ===== EMAIL CHANGED NOTIFICATION =====\n")
+	fmt.Printf("📨 Gửi đến: %s\n", oldEmail)
+	fmt.Printf("✉️  Email mới: %s\n", newEmail)
+	fmt.Printf("==========================================\n\n")
+
+	m := gomail.NewMessage()
+	m.SetHeader("From", s.fromEmail)
+	m.SetHeader("To", oldEmail)
+	m.SetHeader("Subject", "Email tài khoản của bạn đã được thay đổi")
+
+	htmlBody := fmt.Sprintf(`
+		<div style="font-family: Arial, sans-serif; max-width: 600px; margin: 0 auto;">
+			<h2 style="color: #333;">Email tài khoản đã được thay đổi</h2>
+			<p>Xin chào <strong>%s</strong>,</p>
+			<p>Email đăng nhập của tài khoản đã được đổi từ <strong>%s</strong> sang <strong>%s</strong> vào lúc %s.</p>
+			<p>Từ bây giờ, vui lòng đăng nhập bằng email mới.</p>
+			<p style="color: #dc3545;">Nếu bạn không thực hiện thay đổi này, vui lòng liên hệ bộ phận hỗ trợ ngay.</p>
+			<hr style="border: none; border-top: 1px solid #eee; margin: 20px 0;">
+			<p style="color: #666; font-size: 12px;">Đây là email tự động, vui lòng không trả lời.</p>
+		</div>
+	`, name, oldEmail, newEmail, time.Now().Format("2006-01-02 15:04:05"))
+
+	m.SetBody("text/html", htmlBody)
+
+	return s.send(m)
+}
+
+func (s *EmailService) send(m *gomail.Message) error {
 	// Tạo dialer
 	d := gomail.NewDialer(s.smtpHost, s.smtpPort, s.smtpUser, s.smtpPassword)
 
@@ -0,0 +1,230 @@
+package services
+
+import (
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math"
+	"time"
+
+	"ecommerce-be/database"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+var ErrInvalidCursor = errors.New("cursor không hợp lệ hoặc không khớp với cách sắp xếp hiện tại")
+
+// cursorKind kiểu dữ liệu của cột sort, dùng để đọc lại giá trị từ cursor đúng kiểu cho Postgres
+type cursorKind int
+
+const (
+	cursorString cursorKind = iota
+	cursorInt
+	cursorFloat
+	cursorTime
+)
+
+// sortKey cột (hoặc biểu thức) đang dùng để sắp xếp kết quả tìm kiếm, luôn kèm id làm tiebreak
+type sortKey struct {
+	Field string        // Tên field trong request (createdAt, price, relevance...)
+	SQL   string        // Cột/biểu thức SQL tương ứng
+	Vars  []interface{} // Tham số của biểu thức (vd: từ khóa khi sort theo relevance)
+	Kind  cursorKind
+	Order string // ASC hoặc DESC
+}
+
+// pageCursor vị trí bản ghi cuối của trang trước, mã hóa base64 để client coi như chuỗi opaque
+type pageCursor struct {
+	SortBy    string          `json:"s"`
+	SortOrder string          `json:"o"`
+	Value     json.RawMessage `json:"v"`
+	ID        uint            `json:"id"`
+
+	value interface{} // Value đã đọc đúng kiểu theo sortKey
+}
+
+// newSortKey tạo sortKey cho một cột của bảng
+func newSortKey(field, column string, kind cursorKind, order string) sortKey {
+	if order != "ASC" {
+		order = "DESC"
+	}
+	return sortKey{Field: field, SQL: column, Kind: kind, Order: order}
+}
+
+// isID sort theo chính id thì không cần tiebreak
+func (k sortKey) isID() bool {
+	return k.SQL == "id"
+}
+
+// apply thêm ORDER BY (và điều kiện keyset nếu có cursor) vào query
+func (k sortKey) apply(query *gorm.DB, cursor *pageCursor) *gorm.DB {
+	if cursor != nil {
+		operator := "<"
+		if k.Order == "ASC" {
+			operator = ">"
+		}
+		if k.isID() {
+			query = query.Where("id "+operator+" ?", cursor.ID)
+		} else {
+			vars := append(append([]interface{}{}, k.Vars...), cursor.value, cursor.ID)
+			query = query.Where("("+k.SQL+", id) "+operator+" (?, ?)", vars...)
+		}
+	}
+
+	orderBy := k.SQL + " " + k.Order
+	if !k.isID() {
+		orderBy += ", id " + k.Order
+	}
+	return query.Clauses(clause.OrderBy{
+		Expression: clause.Expr{SQL: orderBy, Vars: k.Vars, WithoutParentheses: true},
+	})
+}
+
+// decodeCursor đọc cursor client gửi lên, cursor rỗng nghĩa là trang đầu tiên
+func (k sortKey) decodeCursor(raw string) (*pageCursor, error) {
+	if raw == "" {
+		return nil, nil
+	}
+
+	data, err := base64.RawURLEncoding.DecodeString(raw)
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+	var cursor pageCursor
+	if err := json.Unmarshal(data, &cursor); err != nil {
+		return nil, ErrInvalidCursor
+	}
+	if cursor.SortBy != k.Field || cursor.SortOrder != k.Order {
+		return nil, ErrInvalidCursor
+	}
+	if k.isID() {
+		return &cursor, nil
+	}
+
+	switch k.Kind {
+	case cursorInt:
+		var v int64
+		err = json.Unmarshal(cursor.Value, &v)
+		cursor.value = v
+	case cursorFloat:
+		var v float64
+		err = json.Unmarshal(cursor.Value, &v)
+		cursor.value = v
+	case cursorTime:
+		var v time.Time
+		err = json.Unmarshal(cursor.Value, &v)
+		cursor.value = v
+	default:
+		var v string
+		err = json.Unmarshal(cursor.Value, &v)
+		cursor.value = v
+	}
+	if err != nil {
+		return nil, ErrInvalidCursor
+	}
+
+	return &cursor, nil
+}
+
+// nextCursor tạo cursor trỏ tới bản ghi cuối của trang hiện tại
+// Giá trị sort được đọc lại từ database để dùng chung cho cả cột thường lẫn biểu thức (relevance)
+func (k sortKey) nextCursor(table string, lastID uint) (string, error) {
+	cursor := pageCursor{SortBy: k.Field, SortOrder: k.Order, ID: lastID, Value: json.RawMessage("null")}
+
+	if !k.isID() {
+		var value interface{}
+		switch k.Kind {
+		case cursorInt:
+			value = new(int64)
+		case cursorFloat:
+			value = new(float64)
+		case cursorTime:
+			value = new(time.Time)
+		default:
+			value = new(string)
+		}
+		row := database.DB.Unscoped().Table(table).Select(k.SQL, k.Vars...).Where("id = ?", lastID).Row()
+		if err := row.Scan(value); err != nil {
+			return "", err
+		}
+		encoded, err := json.Marshal(value)
+		if err != nil {
+			return "", err
+		}
+		cursor.Value = encoded
+	}
+
+	data, err := json.Marshal(cursor)
+	if err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(data), nil
+}
+
+// pageRequest phân trang theo page/offset (mặc định) hoặc theo cursor (keyset) khi client gửi cursor
+type pageRequest struct {
+	Page         int // 0 khi dùng cursor
+	Limit        int
+	Cursor       *pageCursor
+	CursorMode   bool
+	IncludeTotal bool
+}
+
+// newPageRequest đọc tham số phân trang: cursor "" là trang đầu của chế độ cursor, page bị bỏ qua.
+// Mặc định chỉ đếm total khi phân trang theo page, includeTotal ghi đè lựa chọn này.
+func newPageRequest(key sortKey, page, limit int, cursor *string, includeTotal *bool) (*pageRequest, error) {
+	p := &pageRequest{Page: page, Limit: limit, IncludeTotal: true}
+	if cursor != nil {
+		decoded, err := key.decodeCursor(*cursor)
+		if err != nil {
+			return nil, err
+		}
+		p.Page = 0
+		p.Cursor = decoded
+		p.CursorMode = true
+		p.IncludeTotal = false
+	}
+	if includeTotal != nil {
+		p.IncludeTotal = *includeTotal
+	}
+	return p, nil
+}
+
+// isFirstPage trang đầu tiên của kết quả (page 1 hoặc cursor rỗng)
+func (p *pageRequest) isFirstPage() bool {
+	if p.CursorMode {
+		return p.Cursor == nil
+	}
+	return p.Page == 1
+}
+
+// paginate sắp xếp, áp dụng cursor/offset và lấy dư 1 bản ghi để biết còn trang sau không
+func (p *pageRequest) paginate(query *gorm.DB, key sortKey) *gorm.DB {
+	query = key.apply(query, p.Cursor)
+	if !p.CursorMode {
+		query = query.Offset((p.Page - 1) * p.Limit)
+	}
+	return query.Limit(p.Limit + 1)
+}
+
+// next trả về nextCursor trỏ sau bản ghi cuối cùng nếu còn trang sau
+func (p *pageRequest) next(key sortKey, table string, hasMore bool, lastID uint) (*string, error) {
+	if !hasMore {
+		return nil, nil
+	}
+	cursor, err := key.nextCursor(table, lastID)
+	if err != nil {
+		return nil, err
+	}
+	return &cursor, nil
+}
+
+// totals trả về total và totalPages, nil nếu không đếm
+func (p *pageRequest) totals(total int64) (*int64, *int) {
+	if !p.IncludeTotal {
+		return nil, nil
+	}
+	totalPages := int(math.Ceil(float64(total) / float64(p.Limit)))
+	return &total, &totalPages
+}
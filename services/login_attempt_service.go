@@ -0,0 +1,217 @@
+package services
+
+import (
+	"fmt"
+	"log"
+	"math"
+	"time"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/dto"
+)
+
+// Chính sách chống brute-force cho đăng nhập và xác thực OTP
+const (
+	loginFailureWindow       = 15 * time.Minute // Bộ đếm sai tự reset sau khoảng này
+	maxLoginFailuresPerEmail = 5
+	maxAuthFailuresPerIP     = 20
+	lockoutBaseDuration      = time.Minute // Lần khóa đầu tiên, các lần sau nhân đôi
+	lockoutMaxDuration       = time.Hour
+	lockoutLevelTTL          = 24 * time.Hour // Số lần bị khóa được ghi nhớ trong 24h để tính backoff
+	maxOtpFailures           = 5              // Sai quá số lần này thì OTP bị hủy, phải yêu cầu mã mới
+)
+
+// Cache keys cho bộ đếm đăng nhập
+const (
+	loginFailEmailPrefix  = "auth:fail:email:"
+	loginFailIPPrefix     = "auth:fail:ip:"
+	loginLockEmailPrefix  = "auth:lock:email:"
+	loginLockIPPrefix     = "auth:lock:ip:"
+	loginLevelEmailPrefix = "auth:lockcount:email:"
+	loginLevelIPPrefix    = "auth:lockcount:ip:"
+	otpFailPrefix         = "auth:otp:fail:"
+)
+
+// LockoutError - Tài khoản hoặc IP đang bị khóa tạm thời
+type LockoutError struct {
+	RetryAfter time.Duration
+}
+
+func (e *LockoutError) Error() string {
+	return fmt.Sprintf("bạn đã thử sai quá nhiều lần. Vui lòng thử lại sau %d giây", e.RetryAfterSeconds())
+}
+
+// RetryAfterSeconds trả về số giây phải chờ (làm tròn lên, dùng cho header Retry-After)
+func (e *LockoutError) RetryAfterSeconds() int {
+	return int(math.Ceil(e.RetryAfter.Seconds()))
+}
+
+// LoginAttemptService đếm số lần đăng nhập/nhập OTP sai theo email và IP
+// Dùng Redis khi có, ngược lại dùng bộ nhớ của process. Lỗi Redis không chặn đăng nhập (fail open)
+type LoginAttemptService struct{}
+
+func NewLoginAttemptService() *LoginAttemptService {
+	return &LoginAttemptService{}
+}
+
+// CheckLocked trả về *LockoutError nếu email hoặc IP đang bị khóa
+func (s *LoginAttemptService) CheckLocked(email, ip string) error {
+	var keys []string
+	if email != "" {
+		keys = append(keys, loginLockEmailPrefix+email)
+	}
+	if ip != "" {
+		keys = append(keys, loginLockIPPrefix+ip)
+	}
+
+	var retryAfter time.Duration
+	for _, key := range keys {
+		ttl, err := cache.GetTTL(key)
+		if err != nil {
+			log.Printf("⚠️  Warning: Không thể kiểm tra khóa đăng nhập: %v", err)
+			continue
+		}
+		if ttl > retryAfter {
+			retryAfter = ttl
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RegisterFailure ghi nhận một lần sai mật khẩu/OTP
+// Trả về *LockoutError nếu lần sai này khiến email hoặc IP bị khóa
+func (s *LoginAttemptService) RegisterFailure(email, ip string) error {
+	var retryAfter time.Duration
+
+	if email != "" {
+		if d := s.registerFailure(loginFailEmailPrefix+email, loginLockEmailPrefix+email, loginLevelEmailPrefix+email, maxLoginFailuresPerEmail); d > retryAfter {
+			retryAfter = d
+		}
+	}
+	if ip != "" {
+		if d := s.registerFailure(loginFailIPPrefix+ip, loginLockIPPrefix+ip, loginLevelIPPrefix+ip, maxAuthFailuresPerIP); d > retryAfter {
+			retryAfter = d
+		}
+	}
+
+	if retryAfter > 0 {
+		return &LockoutError{RetryAfter: retryAfter}
+	}
+	return nil
+}
+
+// RegisterSuccess xóa bộ đếm sai của email sau khi đăng nhập thành công
+func (s *LoginAttemptService) RegisterSuccess(email string) {
+	if err := cache.DeleteKeys(loginFailEmailPrefix+email, loginLevelEmailPrefix+email); err != nil {
+		log.Printf("⚠️  Warning: Không thể reset bộ đếm đăng nhập của %s: %v", email, err)
+	}
+}
+
+// RegisterOtpFailure ghi nhận một lần nhập sai OTP của purpose
+// Trả về true khi đã sai đủ maxOtpFailures lần → caller phải hủy OTP hiện tại
+func (s *LoginAttemptService) RegisterOtpFailure(purpose, email string) bool {
+	key := otpFailPrefix + purpose + ":" + email
+	count, err := cache.IncrementCounter(key, loginFailureWindow)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể ghi nhận OTP sai: %v", err)
+		return false
+	}
+	if count < maxOtpFailures {
+		return false
+	}
+
+	s.ClearOtpFailures(purpose, email)
+	return true
+}
+
+// ClearOtpFailures reset bộ đếm OTP sai (khi xác thực thành công hoặc gửi OTP mới)
+func (s *LoginAttemptService) ClearOtpFailures(purpose, email string) {
+	if err := cache.DeleteKeys(otpFailPrefix + purpose + ":" + email); err != nil {
+		log.Printf("⚠️  Warning: Không thể reset bộ đếm OTP của %s: %v", email, err)
+	}
+}
+
+// GetLockout lấy trạng thái khóa đăng nhập của email (dành cho admin)
+func (s *LoginAttemptService) GetLockout(email string) (*dto.LoginLockoutResponse, error) {
+	failedAttempts, err := cache.GetCounter(loginFailEmailPrefix + email)
+	if err != nil {
+		return nil, fmt.Errorf("không thể lấy trạng thái khóa đăng nhập: %v", err)
+	}
+	lockoutCount, err := cache.GetCounter(loginLevelEmailPrefix + email)
+	if err != nil {
+		return nil, fmt.Errorf("không thể lấy trạng thái khóa đăng nhập: %v", err)
+	}
+	ttl, err := cache.GetTTL(loginLockEmailPrefix + email)
+	if err != nil {
+		return nil, fmt.Errorf("không thể lấy trạng thái khóa đăng nhập: %v", err)
+	}
+
+	response := &dto.LoginLockoutResponse{
+		Email:          email,
+		Locked:         ttl > 0,
+		FailedAttempts: failedAttempts,
+		LockoutCount:   lockoutCount,
+	}
+	if ttl > 0 {
+		lockedUntil := time.Now().Add(ttl).Format("2006-01-02T15:04:05Z07:00")
+		response.LockedUntil = &lockedUntil
+		response.RetryAfter = (&LockoutError{RetryAfter: ttl}).RetryAfterSeconds()
+	}
+	return response, nil
+}
+
+// ClearLockout mở khóa đăng nhập và reset mọi bộ đếm sai của email (dành cho admin)
+func (s *LoginAttemptService) ClearLockout(email string) error {
+	if err := cache.DeleteKeys(
+		loginFailEmailPrefix+email,
+		loginLockEmailPrefix+email,
+		loginLevelEmailPrefix+email,
+		otpFailPrefix+OtpPurposeVerifyEmail+":"+email,
+		otpFailPrefix+OtpPurposeResetPassword+":"+email,
+		otpFailPrefix+OtpPurposeChangeEmail+":"+email,
+	); err != nil {
+		return fmt.Errorf("không thể mở khóa đăng nhập: %v", err)
+	}
+	return nil
+}
+
+// registerFailure tăng bộ đếm, khóa khi vượt ngưỡng và trả về thời gian khóa (0 nếu chưa khóa)
+// Thời gian khóa tăng gấp đôi sau mỗi lần bị khóa: 1, 2, 4, 8... phút (tối đa lockoutMaxDuration)
+func (s *LoginAttemptService) registerFailure(failKey, lockKey, levelKey string, maxFailures int64) time.Duration {
+	count, err := cache.IncrementCounter(failKey, loginFailureWindow)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể ghi nhận đăng nhập sai: %v", err)
+		return 0
+	}
+	if count < maxFailures {
+		return 0
+	}
+
+	level, err := cache.IncrementCounter(levelKey, lockoutLevelTTL)
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể ghi nhận số lần bị khóa: %v", err)
+		level = 1
+	}
+
+	duration := lockoutMaxDuration
+	if level <= 6 { // 2^6 phút đã vượt lockoutMaxDuration
+		duration = lockoutBaseDuration * time.Duration(1<<(level-1))
+		if duration > lockoutMaxDuration {
+			duration = lockoutMaxDuration
+		}
+	}
+
+	if err := cache.SetFlag(lockKey, duration); err != nil {
+		log.Printf("⚠️  Warning: Không thể khóa đăng nhập: %v", err)
+		return 0
+	}
+	// Bắt đầu đếm lại sau khi hết khóa
+	if err := cache.DeleteKeys(failKey); err != nil {
+		log.Printf("⚠️  Warning: Không thể reset bộ đếm đăng nhập: %v", err)
+	}
+	return duration
+}
@@ -0,0 +1,330 @@
+package services
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	jwksCacheTTL         = time.Hour        // Thời gian giữ bộ khóa trước khi tải lại
+	jwksMinRefreshPeriod = time.Minute      // Gặp kid lạ thì tải lại, nhưng không quá 1 lần/phút
+	jwksFetchTimeout     = 10 * time.Second // Timeout khi gọi JWKS endpoint
+)
+
+// ErrInvalidIDToken được trả về khi ID token không hợp lệ (sai chữ ký, hết hạn, sai audience...)
+var ErrInvalidIDToken = errors.New("ID token không hợp lệ hoặc đã hết hạn")
+
+// OAuthProvider cấu hình một nhà cung cấp đăng nhập mạng xã hội (OpenID Connect)
+type OAuthProvider struct {
+	Name      string   // Key dùng trong URL: /auth/oauth/:provider
+	Issuers   []string // Giá trị hợp lệ của claim "iss"
+	Audiences []string // Client ID của app (claim "aud" phải thuộc danh sách này)
+	JWKSURL   string   // Endpoint chứa public key để xác thực chữ ký ID token
+}
+
+// OAuthIdentity là thông tin user lấy từ ID token đã xác thực
+type OAuthIdentity struct {
+	Subject       string
+	Email         string
+	EmailVerified bool
+	Name          string
+}
+
+// oauthClaims là các claim dùng tới trong ID token của Google/Apple
+type oauthClaims struct {
+	Email         string   `json:"email"`
+	EmailVerified flexBool `json:"email_verified"`
+	Name          string   `json:"name"`
+	jwt.RegisteredClaims
+}
+
+// flexBool chấp nhận cả true và "true" (Apple trả email_verified dạng string)
+type flexBool bool
+
+func (b *flexBool) UnmarshalJSON(data []byte) error {
+	value := strings.Trim(string(data), `"`)
+	*b = flexBool(value == "true")
+	return nil
+}
+
+// loadOAuthProviders đọc cấu hình provider từ env
+// Provider chỉ được bật khi có client ID; JWKS URL và issuer có thể override (vd: trỏ tới JWKS giả lập khi test)
+func loadOAuthProviders() map[string]*OAuthProvider {
+	defaults := []OAuthProvider{
+		{
+			Name:    "google",
+			Issuers: []string{"https://accounts.google.com", "accounts.google.com"},
+			JWKSURL: "https://www.googleapis.com/oauth2/v3/certs",
+		},
+		{
+			Name:    "apple",
+			Issuers: []string{"https://appleid.apple.com"},
+			JWKSURL: "https://appleid.apple.com/auth/keys",
+		},
+	}
+
+	providers := make(map[string]*OAuthProvider)
+	for _, provider := range defaults {
+		prefix := strings.ToUpper(provider.Name)
+		provider.Audiences = splitEnvList(getEnv(prefix+"_CLIENT_IDS", ""))
+		if len(provider.Audiences) == 0 {
+			continue
+		}
+		provider.JWKSURL = getEnv(prefix+"_JWKS_URL", provider.JWKSURL)
+		if issuers := splitEnvList(getEnv(prefix+"_ISSUERS", "")); len(issuers) > 0 {
+			provider.Issuers = issuers
+		}
+
+		p := provider
+		providers[p.Name] = &p
+	}
+	return providers
+}
+
+func splitEnvList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// VerifyIDToken xác thực chữ ký (qua JWKS), issuer, audience và thời hạn của ID token
+func (p *OAuthProvider) VerifyIDToken(idToken string) (*OAuthIdentity, error) {
+	claims := &oauthClaims{}
+	token, err := jwt.ParseWithClaims(idToken, claims, func(token *jwt.Token) (interface{}, error) {
+		kid, _ := token.Header["kid"].(string)
+		return jwksKeys.Get(p.JWKSURL, kid)
+	},
+		jwt.WithValidMethods([]string{"RS256", "ES256"}),
+		jwt.WithExpirationRequired(),
+		jwt.WithLeeway(time.Minute),
+	)
+	if err != nil || !token.Valid {
+		return nil, ErrInvalidIDToken
+	}
+
+	if !containsString(p.Issuers, claims.Issuer) {
+		return nil, ErrInvalidIDToken
+	}
+	audienceMatched := false
+	for _, aud := range claims.Audience {
+		if containsString(p.Audiences, aud) {
+			audienceMatched = true
+			break
+		}
+	}
+	if !audienceMatched || claims.Subject == "" {
+		return nil, ErrInvalidIDToken
+	}
+
+	return &OAuthIdentity{
+		Subject:       claims.Subject,
+		Email:         strings.ToLower(strings.TrimSpace(claims.Email)),
+		EmailVerified: bool(claims.EmailVerified),
+		Name:          strings.TrimSpace(claims.Name),
+	}, nil
+}
+
+func containsString(values []string, target string) bool {
+	for _, value := range values {
+		if value == target {
+			return true
+		}
+	}
+	return false
+}
+
+// jwksCache lưu public key của các JWKS endpoint (dùng chung cho cả process)
+// Việc tải JWKS diễn ra ngoài lock, các request cùng cần tải một endpoint thì chờ chung một lần tải
+type jwksCache struct {
+	mu       sync.Mutex
+	client   *http.Client
+	entries  map[string]*jwksEntry
+	inflight map[string]*jwksFetch
+}
+
+type jwksEntry struct {
+	keys        map[string]interface{}
+	fetchedAt   time.Time // Lần tải thành công gần nhất
+	attemptedAt time.Time // Lần gọi endpoint gần nhất (kể cả thất bại)
+}
+
+// jwksFetch là một lần tải JWKS đang chạy, done được đóng khi có kết quả
+type jwksFetch struct {
+	done chan struct{}
+	keys map[string]interface{}
+	err  error
+}
+
+var jwksKeys = newJWKSCache()
+
+func newJWKSCache() *jwksCache {
+	return &jwksCache{
+		client:   &http.Client{Timeout: jwksFetchTimeout},
+		entries:  make(map[string]*jwksEntry),
+		inflight: make(map[string]*jwksFetch),
+	}
+}
+
+// Get trả về public key theo kid, tải lại JWKS khi hết hạn cache hoặc gặp kid chưa biết (provider xoay khóa)
+// Endpoint không được gọi quá 1 lần mỗi jwksMinRefreshPeriod; tải lỗi thì dùng tạm bộ khóa cũ
+func (c *jwksCache) Get(url, kid string) (interface{}, error) {
+	c.mu.Lock()
+	entry := c.entries[url]
+	if entry != nil {
+		key, known := entry.keys[kid]
+		if known && time.Since(entry.fetchedAt) < jwksCacheTTL {
+			c.mu.Unlock()
+			return key, nil
+		}
+		if time.Since(entry.attemptedAt) < jwksMinRefreshPeriod {
+			c.mu.Unlock()
+			if known {
+				return key, nil
+			}
+			return nil, fmt.Errorf("không tìm thấy khóa %q trong JWKS", kid)
+		}
+	}
+
+	fetch, running := c.inflight[url]
+	if !running {
+		fetch = &jwksFetch{done: make(chan struct{})}
+		c.inflight[url] = fetch
+	}
+	c.mu.Unlock()
+
+	if running {
+		<-fetch.done
+	} else {
+		c.refresh(url, fetch)
+	}
+
+	if fetch.err != nil {
+		// Không tải được → dùng tạm bộ khóa cũ nếu có
+		if entry != nil {
+			if key, ok := entry.keys[kid]; ok {
+				return key, nil
+			}
+		}
+		return nil, fetch.err
+	}
+
+	key, ok := fetch.keys[kid]
+	if !ok {
+		return nil, fmt.Errorf("không tìm thấy khóa %q trong JWKS", kid)
+	}
+	return key, nil
+}
+
+// refresh tải JWKS (không giữ lock), lưu kết quả vào cache rồi báo cho các request đang chờ
+func (c *jwksCache) refresh(url string, fetch *jwksFetch) {
+	fetch.keys, fetch.err = c.fetch(url)
+
+	c.mu.Lock()
+	now := time.Now()
+	if fetch.err == nil {
+		c.entries[url] = &jwksEntry{keys: fetch.keys, fetchedAt: now, attemptedAt: now}
+	} else if entry := c.entries[url]; entry != nil {
+		entry.attemptedAt = now
+	}
+	delete(c.inflight, url)
+	c.mu.Unlock()
+
+	close(fetch.done)
+}
+
+// jsonWebKey là một khóa trong JWKS (chỉ hỗ trợ RSA và EC P-256)
+type jsonWebKey struct {
+	Kid string `json:"kid"`
+	Kty string `json:"kty"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+func (c *jwksCache) fetch(url string) (map[string]interface{}, error) {
+	resp, err := c.client.Get(url)
+	if err != nil {
+		return nil, fmt.Errorf("không thể tải JWKS: %w", err)
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("không thể tải JWKS: HTTP %d", resp.StatusCode)
+	}
+
+	var body struct {
+		Keys []jsonWebKey `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		return nil, fmt.Errorf("JWKS không hợp lệ: %w", err)
+	}
+
+	keys := make(map[string]interface{})
+	for _, jwk := range body.Keys {
+		if jwk.Use != "" && jwk.Use != "sig" {
+			continue
+		}
+		key, err := jwk.publicKey()
+		if err != nil {
+			continue // Bỏ qua khóa không hỗ trợ
+		}
+		keys[jwk.Kid] = key
+	}
+	return keys, nil
+}
+
+func (k jsonWebKey) publicKey() (interface{}, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := base64.RawURLEncoding.DecodeString(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := base64.RawURLEncoding.DecodeString(k.E)
+		if err != nil {
+			return nil, err
+		}
+		return &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}, nil
+	case "EC":
+		if k.Crv != "P-256" {
+			return nil, errors.New("curve không được hỗ trợ")
+		}
+		x, err := base64.RawURLEncoding.DecodeString(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := base64.RawURLEncoding.DecodeString(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		return &ecdsa.PublicKey{
+			Curve: elliptic.P256(),
+			X:     new(big.Int).SetBytes(x),
+			Y:     new(big.Int).SetBytes(y),
+		}, nil
+	default:
+		return nil, errors.New("loại khóa không được hỗ trợ")
+	}
+}
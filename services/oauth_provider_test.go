@@ -0,0 +1,369 @@
+package services
+
+import (
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"math/big"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+const (
+	testOAuthIssuer   = "https://issuer.example.com"
+	testOAuthAudience = "test-client-id"
+)
+
+// testSigningKey là một khóa ký ID token cùng thuật toán tương ứng
+type testSigningKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+func newRSASigningKey(t *testing.T, kid string) *testSigningKey {
+	t.Helper()
+	private, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatalf("không thể sinh khóa RSA: %v", err)
+	}
+	return &testSigningKey{kid: kid, method: jwt.SigningMethodRS256, private: private}
+}
+
+func newECSigningKey(t *testing.T, kid string) *testSigningKey {
+	t.Helper()
+	private, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		t.Fatalf("không thể sinh khóa EC: %v", err)
+	}
+	return &testSigningKey{kid: kid, method: jwt.SigningMethodES256, private: private}
+}
+
+// jwk trả về public key dạng JSON Web Key
+func (k *testSigningKey) jwk() jsonWebKey {
+	encode := func(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+	switch public := k.private.Public().(type) {
+	case *rsa.PublicKey:
+		return jsonWebKey{Kid: k.kid, Kty: "RSA", Use: "sig", N: encode(public.N.Bytes()), E: encode(big.NewInt(int64(public.E)).Bytes())}
+	case *ecdsa.PublicKey:
+		return jsonWebKey{Kid: k.kid, Kty: "EC", Use: "sig", Crv: "P-256", X: encode(public.X.FillBytes(make([]byte, 32))), Y: encode(public.Y.FillBytes(make([]byte, 32)))}
+	}
+	return jsonWebKey{}
+}
+
+// sign ký claims bằng khóa với thuật toán mặc định của khóa
+func (k *testSigningKey) sign(t *testing.T, claims jwt.MapClaims) string {
+	t.Helper()
+	return k.signWith(t, k.method, claims)
+}
+
+func (k *testSigningKey) signWith(t *testing.T, method jwt.SigningMethod, claims jwt.MapClaims) string {
+	t.Helper()
+	token := jwt.NewWithClaims(method, claims)
+	token.Header["kid"] = k.kid
+	signed, err := token.SignedString(k.private)
+	if err != nil {
+		t.Fatalf("không thể ký ID token: %v", err)
+	}
+	return signed
+}
+
+// testJWKSServer là JWKS endpoint giả lập, đếm số lần được gọi và có thể bật lỗi
+type testJWKSServer struct {
+	*httptest.Server
+
+	mu      sync.Mutex
+	keys    []*testSigningKey
+	failing bool
+	fetches atomic.Int64
+}
+
+func newTestJWKSServer(t *testing.T, keys ...*testSigningKey) *testJWKSServer {
+	t.Helper()
+	server := &testJWKSServer{keys: keys}
+	server.Server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		server.fetches.Add(1)
+
+		server.mu.Lock()
+		defer server.mu.Unlock()
+		if server.failing {
+			w.WriteHeader(http.StatusServiceUnavailable)
+			return
+		}
+		body := struct {
+			Keys []jsonWebKey `json:"keys"`
+		}{}
+		for _, key := range server.keys {
+			body.Keys = append(body.Keys, key.jwk())
+		}
+		json.NewEncoder(w).Encode(body)
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func (s *testJWKSServer) setKeys(keys ...*testSigningKey) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.keys = keys
+}
+
+func (s *testJWKSServer) setFailing(failing bool) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	s.failing = failing
+}
+
+// newTestOAuthProvider tạo provider trỏ tới JWKS giả lập, dùng cache JWKS riêng cho từng test
+func newTestOAuthProvider(t *testing.T, server *testJWKSServer) *OAuthProvider {
+	t.Helper()
+	previous := jwksKeys
+	jwksKeys = newJWKSCache()
+	t.Cleanup(func() { jwksKeys = previous })
+
+	return &OAuthProvider{
+		Name:      "test",
+		Issuers:   []string{testOAuthIssuer},
+		Audiences: []string{testOAuthAudience},
+		JWKSURL:   server.URL,
+	}
+}
+
+// ageJWKSEntry lùi thời điểm tải JWKS của provider để giả lập thời gian trôi qua
+func ageJWKSEntry(provider *OAuthProvider, age time.Duration) {
+	jwksKeys.mu.Lock()
+	defer jwksKeys.mu.Unlock()
+	entry := jwksKeys.entries[provider.JWKSURL]
+	entry.fetchedAt = entry.fetchedAt.Add(-age)
+	entry.attemptedAt = entry.attemptedAt.Add(-age)
+}
+
+func validIDTokenClaims() jwt.MapClaims {
+	now := time.Now()
+	return jwt.MapClaims{
+		"iss":            testOAuthIssuer,
+		"aud":            testOAuthAudience,
+		"sub":            "oauth-subject-1",
+		"email":          " User@Example.com ",
+		"email_verified": true,
+		"name":           "OAuth User",
+		"iat":            now.Unix(),
+		"exp":            now.Add(time.Hour).Unix(),
+	}
+}
+
+func TestVerifyIDTokenValid(t *testing.T) {
+	for name, key := range map[string]*testSigningKey{
+		"RS256": newRSASigningKey(t, "rsa-1"),
+		"ES256": newECSigningKey(t, "ec-1"),
+	} {
+		t.Run(name, func(t *testing.T) {
+			provider := newTestOAuthProvider(t, newTestJWKSServer(t, key))
+
+			identity, err := provider.VerifyIDToken(key.sign(t, validIDTokenClaims()))
+			if err != nil {
+				t.Fatalf("ID token hợp lệ bị từ chối: %v", err)
+			}
+			if identity.Subject != "oauth-subject-1" || identity.Email != "user@example.com" || !identity.EmailVerified || identity.Name != "OAuth User" {
+				t.Fatalf("thông tin user không đúng: %+v", identity)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenEmailVerifiedString(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	provider := newTestOAuthProvider(t, newTestJWKSServer(t, key))
+
+	// Apple trả email_verified dạng string
+	for value, expected := range map[string]bool{"true": true, "false": false} {
+		claims := validIDTokenClaims()
+		claims["email_verified"] = value
+
+		identity, err := provider.VerifyIDToken(key.sign(t, claims))
+		if err != nil {
+			t.Fatalf("email_verified=%q: %v", value, err)
+		}
+		if identity.EmailVerified != expected {
+			t.Fatalf("email_verified=%q phải cho EmailVerified=%v", value, expected)
+		}
+	}
+}
+
+func TestVerifyIDTokenRejectsInvalidClaims(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	provider := newTestOAuthProvider(t, newTestJWKSServer(t, key))
+
+	cases := map[string]func(claims jwt.MapClaims){
+		"sai audience":      func(claims jwt.MapClaims) { claims["aud"] = "other-client-id" },
+		"sai issuer":        func(claims jwt.MapClaims) { claims["iss"] = "https://evil.example.com" },
+		"đã hết hạn":        func(claims jwt.MapClaims) { claims["exp"] = time.Now().Add(-5 * time.Minute).Unix() },
+		"không có exp":      func(claims jwt.MapClaims) { delete(claims, "exp") },
+		"không có sub":      func(claims jwt.MapClaims) { delete(claims, "sub") },
+		"aud rỗng":          func(claims jwt.MapClaims) { delete(claims, "aud") },
+		"issuer thừa dấu /": func(claims jwt.MapClaims) { claims["iss"] = testOAuthIssuer + "/" },
+	}
+	for name, mutate := range cases {
+		t.Run(name, func(t *testing.T) {
+			claims := validIDTokenClaims()
+			mutate(claims)
+			if _, err := provider.VerifyIDToken(key.sign(t, claims)); !errors.Is(err, ErrInvalidIDToken) {
+				t.Fatalf("phải trả về ErrInvalidIDToken, nhận %v", err)
+			}
+		})
+	}
+}
+
+func TestVerifyIDTokenRejectsUnsupportedAlgorithm(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	provider := newTestOAuthProvider(t, newTestJWKSServer(t, key))
+
+	t.Run("RS512", func(t *testing.T) {
+		token := key.signWith(t, jwt.SigningMethodRS512, validIDTokenClaims())
+		if _, err := provider.VerifyIDToken(token); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("phải trả về ErrInvalidIDToken, nhận %v", err)
+		}
+	})
+
+	t.Run("HS256", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodHS256, validIDTokenClaims())
+		token.Header["kid"] = key.kid
+		signed, err := token.SignedString([]byte("shared-secret"))
+		if err != nil {
+			t.Fatalf("không thể ký token: %v", err)
+		}
+		if _, err := provider.VerifyIDToken(signed); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("phải trả về ErrInvalidIDToken, nhận %v", err)
+		}
+	})
+
+	t.Run("none", func(t *testing.T) {
+		token := jwt.NewWithClaims(jwt.SigningMethodNone, validIDTokenClaims())
+		token.Header["kid"] = key.kid
+		signed, err := token.SignedString(jwt.UnsafeAllowNoneSignatureType)
+		if err != nil {
+			t.Fatalf("không thể tạo token: %v", err)
+		}
+		if _, err := provider.VerifyIDToken(signed); !errors.Is(err, ErrInvalidIDToken) {
+			t.Fatalf("phải trả về ErrInvalidIDToken, nhận %v", err)
+		}
+	})
+}
+
+func TestVerifyIDTokenUnknownKidRefetchIsRateLimited(t *testing.T) {
+	oldKey := newRSASigningKey(t, "rsa-old")
+	newKey := newRSASigningKey(t, "rsa-new")
+	server := newTestJWKSServer(t, oldKey)
+	provider := newTestOAuthProvider(t, server)
+
+	if _, err := provider.VerifyIDToken(oldKey.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("ID token hợp lệ bị từ chối: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Fatalf("JWKS phải được tải 1 lần, nhận %d", fetches)
+	}
+
+	// Provider xoay khóa ngay sau lần tải đầu → chưa qua jwksMinRefreshPeriod nên không tải lại
+	server.setKeys(oldKey, newKey)
+	if _, err := provider.VerifyIDToken(newKey.sign(t, validIDTokenClaims())); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("kid lạ trong jwksMinRefreshPeriod phải bị từ chối, nhận %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Fatalf("không được tải lại JWKS trong jwksMinRefreshPeriod, đã tải %d lần", fetches)
+	}
+
+	// Qua jwksMinRefreshPeriod → kid lạ khiến JWKS được tải lại
+	ageJWKSEntry(provider, jwksMinRefreshPeriod)
+	if _, err := provider.VerifyIDToken(newKey.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("khóa mới phải được chấp nhận sau khi tải lại JWKS: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("JWKS phải được tải lại đúng 1 lần, đã tải %d lần", fetches)
+	}
+
+	// Khóa cũ vẫn còn trong cache, không gọi lại endpoint
+	if _, err := provider.VerifyIDToken(oldKey.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("khóa cũ vẫn phải được chấp nhận: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("khóa đã có trong cache không được tải lại JWKS, đã tải %d lần", fetches)
+	}
+}
+
+func TestVerifyIDTokenFallsBackToCachedKeysWhenFetchFails(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	server := newTestJWKSServer(t, key)
+	provider := newTestOAuthProvider(t, server)
+
+	if _, err := provider.VerifyIDToken(key.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("ID token hợp lệ bị từ chối: %v", err)
+	}
+
+	// Cache hết hạn và endpoint lỗi → vẫn dùng khóa đã tải trước đó
+	server.setFailing(true)
+	ageJWKSEntry(provider, jwksCacheTTL)
+	if _, err := provider.VerifyIDToken(key.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("phải dùng khóa trong cache khi không tải được JWKS: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("JWKS phải được thử tải lại khi cache hết hạn, đã tải %d lần", fetches)
+	}
+
+	// Vừa tải lỗi → không gọi lại endpoint ở mỗi request trong jwksMinRefreshPeriod
+	if _, err := provider.VerifyIDToken(key.sign(t, validIDTokenClaims())); err != nil {
+		t.Fatalf("phải dùng khóa trong cache khi không tải được JWKS: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 2 {
+		t.Fatalf("không được gọi lại JWKS ngay sau lần tải lỗi, đã tải %d lần", fetches)
+	}
+}
+
+func TestVerifyIDTokenFetchFailsWithoutCache(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	server := newTestJWKSServer(t, key)
+	server.setFailing(true)
+	provider := newTestOAuthProvider(t, server)
+
+	if _, err := provider.VerifyIDToken(key.sign(t, validIDTokenClaims())); !errors.Is(err, ErrInvalidIDToken) {
+		t.Fatalf("không tải được JWKS lần đầu phải trả về ErrInvalidIDToken, nhận %v", err)
+	}
+}
+
+func TestJWKSCacheConcurrentRequestsFetchOnce(t *testing.T) {
+	key := newRSASigningKey(t, "rsa-1")
+	server := newTestJWKSServer(t, key)
+	provider := newTestOAuthProvider(t, server)
+	token := key.sign(t, validIDTokenClaims())
+
+	var wg sync.WaitGroup
+	errs := make(chan error, 20)
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if _, err := provider.VerifyIDToken(token); err != nil {
+				errs <- err
+			}
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		t.Fatalf("ID token hợp lệ bị từ chối: %v", err)
+	}
+	if fetches := server.fetches.Load(); fetches != 1 {
+		t.Fatalf("các request đồng thời phải dùng chung một lần tải JWKS, đã tải %d lần", fetches)
+	}
+}
@@ -0,0 +1,195 @@
+package services
+
+import (
+	"errors"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+	"ecommerce-be/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// OAuthService đăng nhập bằng ID token của Google/Apple và liên kết với tài khoản có sẵn
+type OAuthService struct {
+	providers        map[string]*OAuthProvider
+	sessionService   *SessionService
+	twoFactorService *TwoFactorService
+}
+
+func NewOAuthService() *OAuthService {
+	return &OAuthService{
+		providers:        loadOAuthProviders(),
+		sessionService:   NewSessionService(),
+		twoFactorService: NewTwoFactorService(),
+	}
+}
+
+// Login xác thực ID token và trả về token đăng nhập
+// Thứ tự tìm user: tài khoản đã liên kết (provider + sub) → user cùng email (đã được provider xác thực) → tạo user mới
+func (s *OAuthService) Login(providerName string, req dto.OAuthLoginRequest, client dto.ClientInfo) (*dto.LoginResponse, error) {
+	provider, ok := s.providers[strings.ToLower(providerName)]
+	if !ok {
+		return nil, errors.New("nhà cung cấp đăng nhập không được hỗ trợ")
+	}
+
+	identity, err := provider.VerifyIDToken(req.IDToken)
+	if err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var linked models.UserIdentity
+		err := tx.Where("provider = ? AND subject = ?", provider.Name, identity.Subject).First(&linked).Error
+		if err == nil {
+			if err := tx.Where("id = ?", linked.UserID).First(&user).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return errors.New("tài khoản liên kết không còn tồn tại")
+				}
+				return err
+			}
+			return nil
+		}
+		if !errors.Is(err, gorm.ErrRecordNotFound) {
+			return err
+		}
+
+		// Chưa liên kết → cần email đã được provider xác thực để tìm/tạo user
+		if identity.Email == "" || !identity.EmailVerified {
+			return errors.New("tài khoản mạng xã hội chưa có email đã xác thực")
+		}
+
+		if err := s.findOrCreateUser(tx, identity, req.Name, &user); err != nil {
+			return err
+		}
+
+		email := identity.Email
+		if err := tx.Clauses(clause.OnConflict{DoNothing: true}).Create(&models.UserIdentity{
+			UserID:   user.ID,
+			Provider: provider.Name,
+			Subject:  identity.Subject,
+			Email:    &email,
+		}).Error; err != nil {
+			return errors.New("không thể liên kết tài khoản")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	if !user.IsActive {
+		return nil, errors.New("tài khoản đã bị vô hiệu hóa")
+	}
+
+	// Đăng nhập mạng xã hội không thay thế được bước xác thực 2 lớp
+	challenge, err := s.twoFactorService.Challenge(&user)
+	if err != nil {
+		return nil, err
+	}
+	if challenge != nil {
+		return twoFactorLoginResponse(challenge), nil
+	}
+
+	// Tạo phiên đăng nhập mới
+	client.DeviceName = req.DeviceName
+	accessToken, refreshToken, err := s.sessionService.Create(&user, client)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.LoginResponse{
+		Success:      true,
+		Message:      "Đăng nhập thành công!",
+		AccessToken:  accessToken,
+		RefreshToken: refreshToken,
+		User: &dto.UserResponse{
+			ID:              user.ID,
+			Email:           user.Email,
+			Name:            user.Name,
+			Role:            user.Role,
+			Phone:           user.Phone,
+			Avatar:          user.Avatar,
+			Address:         user.Address,
+			Gender:          user.Gender,
+			IsEmailVerified: user.IsEmailVerified,
+			IsActive:        user.IsActive,
+			IsFirstLogin:    user.IsFirstLogin,
+			CreatedAt:       user.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			UpdatedAt:       user.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		},
+	}, nil
+}
+
+// findOrCreateUser tìm user theo email hoặc tạo mới (đã xác thực email) nếu chưa có
+func (s *OAuthService) findOrCreateUser(tx *gorm.DB, identity *OAuthIdentity, name *string, user *models.User) error {
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("email = ?", identity.Email).First(user).Error
+	if err == nil {
+		if user.IsEmailVerified {
+			return nil
+		}
+
+		// Tài khoản đăng ký bằng mật khẩu nhưng chưa xác thực email: provider đã chứng minh quyền sở hữu email,
+		// nhưng mật khẩu có thể do người khác đặt → thay bằng mật khẩu ngẫu nhiên (user dùng quên mật khẩu nếu cần)
+		password, err := randomPasswordHash()
+		if err != nil {
+			return err
+		}
+		if err := tx.Model(user).Updates(map[string]interface{}{
+			"password":          password,
+			"is_email_verified": true,
+			"is_active":         true,
+			"is_first_login":    false,
+		}).Error; err != nil {
+			return errors.New("không thể cập nhật tài khoản")
+		}
+		return tx.Where("user_id = ? AND purpose = ?", user.ID, OtpPurposeVerifyEmail).Delete(&models.UserOtp{}).Error
+	}
+	if !errors.Is(err, gorm.ErrRecordNotFound) {
+		return err
+	}
+
+	// Tạo user mới: không có mật khẩu dùng được (user có thể đặt qua quên mật khẩu)
+	password, err := randomPasswordHash()
+	if err != nil {
+		return err
+	}
+	displayName := identity.Name
+	if name != nil && strings.TrimSpace(*name) != "" {
+		displayName = strings.TrimSpace(*name) // Apple chỉ gửi tên cho app ở lần đăng nhập đầu, không có trong ID token
+	}
+	if displayName == "" {
+		displayName = strings.Split(identity.Email, "@")[0]
+	}
+
+	*user = models.User{
+		Email:           identity.Email,
+		Password:        password,
+		Name:            displayName,
+		Role:            "customer",
+		IsEmailVerified: true,
+		IsActive:        true,
+		IsFirstLogin:    false,
+	}
+	if err := tx.Create(user).Error; err != nil {
+		return errors.New("không thể tạo tài khoản")
+	}
+	return nil
+}
+
+// randomPasswordHash tạo hash của một mật khẩu ngẫu nhiên không ai biết
+func randomPasswordHash() (string, error) {
+	password, err := generateRandomHex(32)
+	if err != nil {
+		return "", errors.New("không thể tạo mật khẩu")
+	}
+	hashed, err := utils.HashPassword(password)
+	if err != nil {
+		return "", errors.New("không thể hash password")
+	}
+	return hashed, nil
+}
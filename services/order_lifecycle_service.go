@@ -0,0 +1,260 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// orderTransitions định nghĩa các bước chuyển trạng thái hợp lệ của đơn hàng
+// delivered chỉ có thể refund, cancelled/refunded là trạng thái cuối
+var orderTransitions = map[models.OrderStatus][]models.OrderStatus{
+	models.OrderStatusPending:    {models.OrderStatusConfirmed, models.OrderStatusCancelled},
+	models.OrderStatusConfirmed:  {models.OrderStatusProcessing, models.OrderStatusCancelled},
+	models.OrderStatusProcessing: {models.OrderStatusShipping, models.OrderStatusCancelled},
+	models.OrderStatusShipping:   {models.OrderStatusDelivered},
+	models.OrderStatusDelivered:  {models.OrderStatusRefunded},
+	models.OrderStatusCancelled:  {},
+	models.OrderStatusRefunded:   {},
+}
+
+// ErrOrderNotFound - Đơn hàng không tồn tại hoặc không thuộc về user
+var ErrOrderNotFound = errors.New("không tìm thấy đơn hàng")
+
+type OrderLifecycleService struct{}
+
+func NewOrderLifecycleService() *OrderLifecycleService {
+	return &OrderLifecycleService{}
+}
+
+// CanTransition kiểm tra có được phép chuyển từ trạng thái from sang to không
+func (s *OrderLifecycleService) CanTransition(from, to models.OrderStatus) bool {
+	for _, allowed := range orderTransitions[from] {
+		if allowed == to {
+			return true
+		}
+	}
+	return false
+}
+
+// ChangeStatus chuyển trạng thái đơn hàng (admin) và ghi lại lịch sử
+func (s *OrderLifecycleService) ChangeStatus(orderID uint, req dto.UpdateOrderStatusRequest, actorID uint) (*dto.OrderResponse, error) {
+	var order models.Order
+	var restockedIDs []uint
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("không tìm thấy đơn hàng với ID %d", orderID)
+			}
+			return err
+		}
+
+		ids, err := s.applyTransition(tx, &order, models.OrderStatus(req.Status), &actorID, req.Reason)
+		if err != nil {
+			return err
+		}
+		restockedIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.invalidateProducts(restockedIDs)
+
+	if err := database.DB.Preload("Items.Product").First(&order, order.ID).Error; err != nil {
+		return nil, err
+	}
+
+	return mapOrderToResponse(&order), nil
+}
+
+// BulkChangeStatus chuyển trạng thái nhiều đơn hàng (admin)
+// Mỗi đơn chạy trong transaction riêng: đơn không hợp lệ bị bỏ qua và không ảnh hưởng các đơn khác
+func (s *OrderLifecycleService) BulkChangeStatus(req dto.BulkUpdateOrderStatusRequest, actorID uint) *dto.BulkUpdateOrderStatusResponse {
+	response := &dto.BulkUpdateOrderStatusResponse{
+		Results: make([]dto.BulkOrderStatusResult, 0, len(req.OrderIDs)),
+	}
+	to := models.OrderStatus(req.Status)
+	seen := make(map[uint]bool, len(req.OrderIDs))
+	var restockedIDs []uint
+
+	for _, orderID := range req.OrderIDs {
+		if seen[orderID] {
+			continue
+		}
+		seen[orderID] = true
+
+		var order models.Order
+		var ids []uint
+		err := database.DB.Transaction(func(tx *gorm.DB) error {
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, orderID).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("không tìm thấy đơn hàng với ID %d", orderID)
+				}
+				return err
+			}
+
+			var err error
+			ids, err = s.applyTransition(tx, &order, to, &actorID, req.Reason)
+			return err
+		})
+
+		result := dto.BulkOrderStatusResult{OrderID: orderID}
+		if err != nil {
+			message := err.Error()
+			result.Error = &message
+			response.Failed++
+		} else {
+			result.Success = true
+			result.Status = string(order.Status)
+			restockedIDs = append(restockedIDs, ids...)
+			response.Updated++
+		}
+		response.Results = append(response.Results, result)
+	}
+
+	s.invalidateProducts(restockedIDs)
+	return response
+}
+
+// GetHistory lấy lịch sử trạng thái của đơn hàng (chỉ chủ đơn hàng)
+func (s *OrderLifecycleService) GetHistory(userID, orderID uint) ([]dto.OrderStatusHistoryResponse, error) {
+	var order models.Order
+	if err := database.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, errors.New("không thể lấy lịch sử đơn hàng")
+	}
+
+	var histories []models.OrderStatusHistory
+	if err := database.DB.Where("order_id = ?", order.ID).
+		Preload("ChangedBy").
+		Order("created_at ASC, id ASC").
+		Find(&histories).Error; err != nil {
+		return nil, errors.New("không thể lấy lịch sử đơn hàng")
+	}
+
+	responses := make([]dto.OrderStatusHistoryResponse, len(histories))
+	for i, history := range histories {
+		var changedBy *string
+		if history.ChangedBy != nil {
+			changedBy = &history.ChangedBy.Name
+		}
+		responses[i] = dto.OrderStatusHistoryResponse{
+			ID:          history.ID,
+			FromStatus:  string(history.FromStatus),
+			ToStatus:    string(history.ToStatus),
+			ChangedByID: history.ChangedByID,
+			ChangedBy:   changedBy,
+			Reason:      history.Reason,
+			CreatedAt:   history.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+
+	return responses, nil
+}
+
+// applyTransition chuyển trạng thái order trong transaction tx (order phải đã được lock)
+// Trả về danh sách product ID đã được hoàn kho để caller xóa cache sau khi commit
+func (s *OrderLifecycleService) applyTransition(tx *gorm.DB, order *models.Order, to models.OrderStatus, actorID *uint, reason *string) ([]uint, error) {
+	from := order.Status
+	if !s.CanTransition(from, to) {
+		return nil, fmt.Errorf("không thể chuyển đơn hàng từ trạng thái %s sang %s", from, to)
+	}
+
+	if err := tx.Model(order).Update("status", to).Error; err != nil {
+		return nil, errors.New("không thể cập nhật trạng thái đơn hàng")
+	}
+	order.Status = to
+
+	if err := s.recordHistory(tx, order.ID, from, to, actorID, reason); err != nil {
+		return nil, err
+	}
+
+	// Đồng bộ các giao dịch thanh toán của đơn hàng
+	switch to {
+	case models.OrderStatusCancelled:
+		// Giao dịch còn đang chờ không còn hiệu lực
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status IN ?", order.ID, []models.PaymentStatus{models.PaymentStatusPending, models.PaymentStatusProcessing}).
+			Update("status", models.PaymentStatusFailed).Error; err != nil {
+			return nil, errors.New("không thể cập nhật giao dịch thanh toán")
+		}
+	case models.OrderStatusRefunded:
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status = ?", order.ID, models.PaymentStatusCompleted).
+			Update("status", models.PaymentStatusRefunded).Error; err != nil {
+			return nil, errors.New("không thể cập nhật giao dịch thanh toán")
+		}
+	}
+
+	// Hủy hoặc hoàn tiền → trả hàng về kho
+	if to == models.OrderStatusCancelled || to == models.OrderStatusRefunded {
+		return s.restock(tx, order.ID)
+	}
+
+	return nil, nil
+}
+
+// recordHistory ghi một dòng lịch sử trạng thái
+func (s *OrderLifecycleService) recordHistory(tx *gorm.DB, orderID uint, from, to models.OrderStatus, actorID *uint, reason *string) error {
+	if reason != nil {
+		trimmed := strings.TrimSpace(*reason)
+		if trimmed == "" {
+			reason = nil
+		} else {
+			reason = &trimmed
+		}
+	}
+
+	history := models.OrderStatusHistory{
+		OrderID:     orderID,
+		FromStatus:  from,
+		ToStatus:    to,
+		ChangedByID: actorID,
+		Reason:      reason,
+	}
+	if err := tx.Create(&history).Error; err != nil {
+		return errors.New("không thể lưu lịch sử đơn hàng")
+	}
+	return nil
+}
+
+// restock cộng lại tồn kho (và trừ số lượng đã bán) cho các sản phẩm trong đơn hàng
+func (s *OrderLifecycleService) restock(tx *gorm.DB, orderID uint) ([]uint, error) {
+	var items []models.OrderItem
+	if err := tx.Where("order_id = ?", orderID).Find(&items).Error; err != nil {
+		return nil, err
+	}
+
+	productIDs := make([]uint, 0, len(items))
+	for _, item := range items {
+		if err := tx.Model(&models.Product{}).Where("id = ?", item.ProductID).Updates(map[string]interface{}{
+			"stock": gorm.Expr("stock + ?", item.Quantity),
+			"sold":  gorm.Expr("GREATEST(sold - ?, 0)", item.Quantity),
+		}).Error; err != nil {
+			return nil, errors.New("không thể hoàn kho sản phẩm")
+		}
+		productIDs = append(productIDs, item.ProductID)
+	}
+
+	return productIDs, nil
+}
+
+// invalidateProducts xóa cache các sản phẩm có thay đổi tồn kho
+func (s *OrderLifecycleService) invalidateProducts(productIDs []uint) {
+	productService := NewProductService()
+	for _, id := range productIDs {
+		productService.invalidateProductCacheByID(id)
+	}
+}
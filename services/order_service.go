@@ -0,0 +1,388 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+	"time"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Các lỗi do dữ liệu của user khi đặt hàng (handler trả 4xx), lỗi khác là lỗi hệ thống
+var (
+	// ErrShippingAddressNotFound - Địa chỉ giao hàng không tồn tại hoặc không thuộc về user
+	ErrShippingAddressNotFound = errors.New("không tìm thấy địa chỉ giao hàng")
+	// ErrCartEmpty - Giỏ hàng không có sản phẩm nào
+	ErrCartEmpty = errors.New("giỏ hàng đang trống")
+	// ErrProductUnavailable - Sản phẩm trong giỏ đã bị xóa hoặc ngừng bán
+	ErrProductUnavailable = errors.New("sản phẩm hiện không khả dụng")
+	// ErrInsufficientStock - Tồn kho không đủ số lượng trong giỏ
+	ErrInsufficientStock = errors.New("sản phẩm không đủ hàng trong kho")
+)
+
+// ErrInvalidDateFilter - fromDate/toDate khi tìm kiếm đơn hàng sai định dạng
+var ErrInvalidDateFilter = errors.New("ngày không hợp lệ (YYYY-MM-DD hoặc RFC3339)")
+
+type OrderService struct{}
+
+func NewOrderService() *OrderService {
+	return &OrderService{}
+}
+
+// Checkout tạo đơn hàng từ giỏ hàng của user
+// Toàn bộ các bước (tạo order, trừ kho, xóa giỏ hàng) chạy trong một transaction
+func (s *OrderService) Checkout(userID uint, req dto.CheckoutRequest) (*dto.OrderResponse, error) {
+	var order models.Order
+	var touchedProductIDs []uint
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Kiểm tra địa chỉ giao hàng thuộc về user
+		var address models.Address
+		if err := tx.Where("id = ? AND user_id = ?", req.ShippingAddressID, userID).First(&address).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrShippingAddressNotFound
+			}
+			return err
+		}
+
+		// Lấy giỏ hàng
+		var cartItems []models.CartItem
+		if err := tx.Where("user_id = ?", userID).Order("id ASC").Find(&cartItems).Error; err != nil {
+			return err
+		}
+		if len(cartItems) == 0 {
+			return ErrCartEmpty
+		}
+
+		// Khóa từng sản phẩm (SELECT ... FOR UPDATE) để tránh bán vượt tồn kho khi nhiều người cùng đặt
+		items := make([]models.OrderItem, 0, len(cartItems))
+		var subtotal float64
+		for _, cartItem := range cartItems {
+			var product models.Product
+			if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+				Where("id = ?", cartItem.ProductID).
+				First(&product).Error; err != nil {
+				if errors.Is(err, gorm.ErrRecordNotFound) {
+					return fmt.Errorf("%w: sản phẩm ID %d đã bị xóa", ErrProductUnavailable, cartItem.ProductID)
+				}
+				return err
+			}
+
+			if !product.IsActive {
+				return fmt.Errorf("%w: \"%s\"", ErrProductUnavailable, product.Name)
+			}
+			if product.Stock < cartItem.Quantity {
+				return fmt.Errorf("%w: \"%s\" chỉ còn %d sản phẩm", ErrInsufficientStock, product.Name, product.Stock)
+			}
+
+			// Snapshot giá tại thời điểm đặt hàng
+			total := product.Price * float64(cartItem.Quantity)
+			items = append(items, models.OrderItem{
+				Quantity:  cartItem.Quantity,
+				Price:     product.Price,
+				Total:     total,
+				ProductID: product.ID,
+			})
+			subtotal += total
+
+			// Trừ kho và tăng số lượng đã bán
+			if err := tx.Model(&models.Product{}).Where("id = ?", product.ID).Updates(map[string]interface{}{
+				"stock": gorm.Expr("stock - ?", cartItem.Quantity),
+				"sold":  gorm.Expr("sold + ?", cartItem.Quantity),
+			}).Error; err != nil {
+				return err
+			}
+			touchedProductIDs = append(touchedProductIDs, product.ID)
+		}
+
+		orderNumber, err := s.generateOrderNumber(tx)
+		if err != nil {
+			return err
+		}
+
+		snapshot, err := json.Marshal(dto.ShippingSnapshot{
+			FullName: address.FullName,
+			Phone:    address.Phone,
+			Address:  address.Address,
+			Ward:     address.Ward,
+			District: address.District,
+			City:     address.City,
+		})
+		if err != nil {
+			return err
+		}
+		snapshotStr := string(snapshot)
+
+		var notes *string
+		if req.Notes != nil && strings.TrimSpace(*req.Notes) != "" {
+			trimmed := strings.TrimSpace(*req.Notes)
+			notes = &trimmed
+		}
+
+		order = models.Order{
+			OrderNumber:       orderNumber,
+			TotalAmount:       subtotal,
+			ShippingFee:       0,
+			Discount:          0,
+			Status:            models.OrderStatusPending,
+			Notes:             notes,
+			UserID:            userID,
+			ShippingAddressID: address.ID,
+			ShippingSnapshot:  &snapshotStr,
+			Items:             items,
+		}
+
+		if err := tx.Create(&order).Error; err != nil {
+			return errors.New("không thể tạo đơn hàng")
+		}
+
+		// Ghi trạng thái khởi tạo vào lịch sử
+		if err := NewOrderLifecycleService().recordHistory(tx, order.ID, "", models.OrderStatusPending, &userID, nil); err != nil {
+			return err
+		}
+
+		// Xóa giỏ hàng
+		if err := tx.Where("user_id = ?", userID).Delete(&models.CartItem{}).Error; err != nil {
+			return errors.New("không thể xóa giỏ hàng")
+		}
+
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// Stock/sold đã thay đổi → xóa cache của các sản phẩm liên quan
+	productService := NewProductService()
+	for _, id := range touchedProductIDs {
+		productService.invalidateProductCacheByID(id)
+	}
+
+	// Load lại order với product info để trả về
+	if err := database.DB.Preload("Items.Product").First(&order, order.ID).Error; err != nil {
+		return nil, err
+	}
+
+	return mapOrderToResponse(&order), nil
+}
+
+// Search tìm kiếm đơn hàng của user (phân trang, lọc theo trạng thái và khoảng thời gian)
+func (s *OrderService) Search(userID uint, req dto.SearchOrderRequest) (*dto.OrderPaginationResponse, error) {
+	// Default values
+	sortBy := "createdAt"
+	sortOrder := "DESC"
+	page := 1
+	limit := 10
+
+	if req.SortBy != nil {
+		sortBy = *req.SortBy
+	}
+	if req.SortOrder != nil {
+		sortOrder = *req.SortOrder
+	}
+	if req.Page != nil {
+		page = *req.Page
+	}
+	if req.Limit != nil {
+		limit = *req.Limit
+	}
+
+	// Chỉ lấy đơn hàng của user hiện tại
+	query := database.DB.Model(&models.Order{}).Where("user_id = ?", userID)
+
+	if req.Status != nil {
+		query = query.Where("status = ?", *req.Status)
+	}
+
+	if req.FromDate != nil && *req.FromDate != "" {
+		from, _, err := parseDateFilter(*req.FromDate)
+		if err != nil {
+			return nil, fmt.Errorf("fromDate: %w", ErrInvalidDateFilter)
+		}
+		query = query.Where("created_at >= ?", from)
+	}
+
+	if req.ToDate != nil && *req.ToDate != "" {
+		to, dateOnly, err := parseDateFilter(*req.ToDate)
+		if err != nil {
+			return nil, fmt.Errorf("toDate: %w", ErrInvalidDateFilter)
+		}
+		if dateOnly {
+			// Bao gồm toàn bộ ngày toDate
+			query = query.Where("created_at < ?", to.AddDate(0, 0, 1))
+		} else {
+			query = query.Where("created_at <= ?", to)
+		}
+	}
+
+	// Count total
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("không thể đếm số lượng đơn hàng")
+	}
+
+	// Pagination
+	offset := (page - 1) * limit
+	var orders []models.Order
+	if err := query.Preload("Items.Product").
+		Order(s.orderBy(sortBy, sortOrder)).
+		Offset(offset).
+		Limit(limit).
+		Find(&orders).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách đơn hàng")
+	}
+
+	orderResponses := make([]dto.OrderResponse, len(orders))
+	for i := range orders {
+		orderResponses[i] = *mapOrderToResponse(&orders[i])
+	}
+
+	return &dto.OrderPaginationResponse{
+		Data:       orderResponses,
+		Total:      total,
+		Page:       page,
+		Limit:      limit,
+		TotalPages: int(math.Ceil(float64(total) / float64(limit))),
+	}, nil
+}
+
+// FindOne lấy chi tiết đơn hàng (sản phẩm, địa chỉ giao hàng, thanh toán) - chỉ chủ đơn hàng
+func (s *OrderService) FindOne(userID, orderID uint) (*dto.OrderResponse, error) {
+	var order models.Order
+	if err := database.DB.Where("id = ? AND user_id = ?", orderID, userID).
+		Preload("Items.Product").
+		Preload("Payments", func(db *gorm.DB) *gorm.DB {
+			return db.Order("created_at DESC")
+		}).
+		First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrOrderNotFound
+		}
+		return nil, errors.New("không thể lấy chi tiết đơn hàng")
+	}
+
+	return mapOrderToResponse(&order), nil
+}
+
+// orderBy tạo ORDER BY theo trường sắp xếp, thêm id cùng chiều để các đơn trùng giá trị không bị lặp/sót giữa các trang
+func (s *OrderService) orderBy(sortBy, sortOrder string) string {
+	column := s.mapSortFieldToColumn(sortBy)
+	if column == "id" {
+		return fmt.Sprintf("id %s", sortOrder)
+	}
+	return fmt.Sprintf("%s %s, id %s", column, sortOrder, sortOrder)
+}
+
+func (s *OrderService) mapSortFieldToColumn(field string) string {
+	switch field {
+	case "totalAmount":
+		return "total_amount"
+	case "createdAt":
+		return "created_at"
+	case "updatedAt":
+		return "updated_at"
+	default:
+		return field
+	}
+}
+
+// parseDateFilter parse ngày dạng YYYY-MM-DD hoặc RFC3339
+// dateOnly = true nếu chỉ có ngày (không có giờ)
+func parseDateFilter(value string) (time.Time, bool, error) {
+	if t, err := time.ParseInLocation("2006-01-02", value, time.Local); err == nil {
+		return t, true, nil
+	}
+	t, err := time.Parse(time.RFC3339, value)
+	return t, false, err
+}
+
+// generateOrderNumber tạo mã đơn hàng dạng ORD-YYYYMMDD-XXXXXXXX (unique)
+func (s *OrderService) generateOrderNumber(tx *gorm.DB) (string, error) {
+	for i := 0; i < 5; i++ {
+		buf := make([]byte, 4)
+		if _, err := rand.Read(buf); err != nil {
+			return "", errors.New("không thể tạo mã đơn hàng")
+		}
+		orderNumber := fmt.Sprintf("ORD-%s-%s", time.Now().Format("20060102"), strings.ToUpper(hex.EncodeToString(buf)))
+
+		var count int64
+		if err := tx.Model(&models.Order{}).Unscoped().Where("order_number = ?", orderNumber).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return orderNumber, nil
+		}
+	}
+	return "", errors.New("không thể tạo mã đơn hàng")
+}
+
+// Helper function để map Order sang OrderResponse
+func mapOrderToResponse(order *models.Order) *dto.OrderResponse {
+	items := make([]dto.OrderItemResponse, len(order.Items))
+	for i, item := range order.Items {
+		items[i] = dto.OrderItemResponse{
+			ID:        item.ID,
+			Quantity:  item.Quantity,
+			Price:     item.Price,
+			Total:     item.Total,
+			ProductID: item.ProductID,
+		}
+		if item.Product.ID > 0 {
+			items[i].Product = MapProductToResponse(&item.Product)
+		}
+	}
+
+	var shippingAddress *dto.ShippingSnapshot
+	if order.ShippingSnapshot != nil {
+		var snapshot dto.ShippingSnapshot
+		if err := json.Unmarshal([]byte(*order.ShippingSnapshot), &snapshot); err == nil {
+			shippingAddress = &snapshot
+		}
+	}
+
+	var payments []dto.PaymentResponse
+	if len(order.Payments) > 0 {
+		payments = make([]dto.PaymentResponse, len(order.Payments))
+		for i := range order.Payments {
+			payments[i] = *mapPaymentToResponse(&order.Payments[i])
+		}
+	}
+
+	var customer *dto.OrderCustomer
+	if order.User.ID > 0 {
+		customer = &dto.OrderCustomer{
+			ID:    order.User.ID,
+			Name:  order.User.Name,
+			Email: order.User.Email,
+			Phone: order.User.Phone,
+		}
+	}
+
+	return &dto.OrderResponse{
+		ID:                order.ID,
+		OrderNumber:       order.OrderNumber,
+		TotalAmount:       order.TotalAmount,
+		ShippingFee:       order.ShippingFee,
+		Discount:          order.Discount,
+		Status:            string(order.Status),
+		Notes:             order.Notes,
+		UserID:            order.UserID,
+		ShippingAddressID: order.ShippingAddressID,
+		ShippingAddress:   shippingAddress,
+		Customer:          customer,
+		Items:             items,
+		Payments:          payments,
+		CreatedAt:         order.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:         order.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -1,21 +1,55 @@
 package services
 
 import (
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/subtle"
+	"encoding/hex"
+	"errors"
 	"fmt"
-	"math/rand"
+	"math/big"
+	"strconv"
 	"time"
+
+	"ecommerce-be/database"
+	"ecommerce-be/models"
+	"ecommerce-be/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
-type OtpService struct{}
+// Mục đích sử dụng của OTP - mã cấp cho mục đích này không dùng được cho mục đích khác
+const (
+	OtpPurposeVerifyEmail   = "verify_email"
+	OtpPurposeResetPassword = "reset_password"
+	OtpPurposeChangeEmail   = "change_email"
+)
+
+// otpResendCooldownSeconds là thời gian chờ tối thiểu giữa 2 lần gửi OTP cùng mục đích
+const otpResendCooldownSeconds = 60
+
+// ErrInvalidOtp - OTP sai, hết hạn hoặc không tồn tại
+var ErrInvalidOtp = errors.New("mã OTP không hợp lệ hoặc đã hết hạn")
+
+type OtpService struct {
+	secret []byte // Khóa HMAC dùng để hash OTP trước khi lưu
+}
 
 func NewOtpService() *OtpService {
-	return &OtpService{}
+	return &OtpService{
+		secret: utils.OtpSecret(),
+	}
 }
 
-// GenerateOtp tạo mã OTP 6 chữ số
-func (s *OtpService) GenerateOtp() string {
-	r := rand.New(rand.NewSource(time.Now().UnixNano()))
-	return fmt.Sprintf("%06d", r.Intn(1000000))
+// GenerateOtp tạo mã OTP 6 chữ số từ crypto/rand
+func (s *OtpService) GenerateOtp() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
 }
 
 // GetOtpExpiry trả về thời gian hết hạn OTP (5 phút)
@@ -23,22 +57,115 @@ func (s *OtpService) GetOtpExpiry() time.Time {
 	return time.Now().Add(5 * time.Minute)
 }
 
-// IsOtpValid kiểm tra OTP có hợp lệ không
-func (s *OtpService) IsOtpValid(otp, storedOtp string, expiresAt *time.Time) bool {
-	if otp == "" || storedOtp == "" || expiresAt == nil {
-		return false
+// Issue tạo OTP mới cho user theo mục đích (thay thế OTP cũ cùng mục đích)
+// Trả về mã gốc để gửi email, database chỉ lưu hash
+func (s *OtpService) Issue(tx *gorm.DB, userID uint, purpose string) (string, error) {
+	return s.IssueForTarget(tx, userID, purpose, nil)
+}
+
+// IssueForTarget giống Issue nhưng lưu kèm giá trị chờ xác nhận (vd: email mới), trả lại khi Consume
+func (s *OtpService) IssueForTarget(tx *gorm.DB, userID uint, purpose string, target *string) (string, error) {
+	code, err := s.GenerateOtp()
+	if err != nil {
+		return "", errors.New("không thể tạo mã OTP")
 	}
 
-	// Kiểm tra OTP có khớp không
-	if otp != storedOtp {
+	otp := models.UserOtp{
+		UserID:     userID,
+		Purpose:    purpose,
+		CodeHash:   s.hash(userID, purpose, code),
+		Target:     target,
+		ExpiresAt:  s.GetOtpExpiry(),
+		LastSentAt: time.Now(),
+	}
+	if err := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "purpose"}},
+		DoUpdates: clause.AssignmentColumns([]string{"code_hash", "target", "expires_at", "last_sent_at", "updated_at"}),
+	}).Create(&otp).Error; err != nil {
+		return "", errors.New("không thể cập nhật OTP")
+	}
+
+	return code, nil
+}
+
+// Find lấy OTP hiện tại của user theo mục đích (nil nếu chưa có)
+func (s *OtpService) Find(userID uint, purpose string) (*models.UserOtp, error) {
+	var otp models.UserOtp
+	if err := database.DB.Where("user_id = ? AND purpose = ?", userID, purpose).First(&otp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// CheckCooldown trả lỗi nếu OTP cùng mục đích vừa được gửi chưa quá 60 giây
+func (s *OtpService) CheckCooldown(userID uint, purpose string) error {
+	currentOtp, err := s.Find(userID, purpose)
+	if err != nil {
+		return errors.New("không thể kiểm tra OTP")
+	}
+	if currentOtp == nil {
+		return nil
+	}
+
+	secondsSinceLastSent := int(time.Since(currentOtp.LastSentAt).Seconds())
+	if secondsSinceLastSent < otpResendCooldownSeconds {
+		remainingSeconds := otpResendCooldownSeconds - secondsSinceLastSent
+		return fmt.Errorf("vui lòng đợi %d giây trước khi yêu cầu gửi lại OTP", remainingSeconds)
+	}
+	return nil
+}
+
+// Consume kiểm tra OTP và xóa ngay khi đúng (mỗi mã chỉ dùng được 1 lần)
+// Gọi trong transaction của thao tác cần OTP để mã chỉ bị tiêu thụ khi thao tác thành công
+func (s *OtpService) Consume(tx *gorm.DB, userID uint, purpose, code string) (*models.UserOtp, error) {
+	var otp models.UserOtp
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND purpose = ?", userID, purpose).
+		First(&otp).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, ErrInvalidOtp
+		}
+		return nil, err
+	}
+
+	if !s.IsOtpValid(userID, purpose, code, &otp) {
+		return nil, ErrInvalidOtp
+	}
+
+	if err := tx.Delete(&otp).Error; err != nil {
+		return nil, err
+	}
+	return &otp, nil
+}
+
+// Invalidate hủy OTP hiện tại của user theo mục đích
+func (s *OtpService) Invalidate(userID uint, purpose string) error {
+	return database.DB.Where("user_id = ? AND purpose = ?", userID, purpose).Delete(&models.UserOtp{}).Error
+}
+
+// IsOtpValid kiểm tra OTP có hợp lệ không (so sánh hash trong thời gian hằng)
+func (s *OtpService) IsOtpValid(userID uint, purpose, code string, otp *models.UserOtp) bool {
+	if code == "" || otp == nil || otp.Purpose != purpose {
 		return false
 	}
 
-	// Kiểm tra OTP còn hạn không
-	if time.Now().After(*expiresAt) {
+	// Kiểm tra OTP có khớp không
+	expected := []byte(otp.CodeHash)
+	actual := []byte(s.hash(userID, purpose, code))
+	if subtle.ConstantTimeCompare(expected, actual) != 1 {
 		return false
 	}
 
-	return true
+	// Kiểm tra OTP còn hạn không
+	return time.Now().Before(otp.ExpiresAt)
 }
 
+// hash tính HMAC của mã OTP, gắn với user và mục đích
+func (s *OtpService) hash(userID uint, purpose, code string) string {
+	mac := hmac.New(sha256.New, s.secret)
+	mac.Write([]byte(purpose + ":" + strconv.FormatUint(uint64(userID), 10) + ":" + code))
+	return hex.EncodeToString(mac.Sum(nil))
+}
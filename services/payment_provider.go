@@ -0,0 +1,44 @@
+package services
+
+import (
+	"errors"
+	"net/http"
+
+	"ecommerce-be/models"
+)
+
+// PaymentProvider là interface chung cho các cổng thanh toán
+// Thêm cổng mới (VNPay, MoMo...) chỉ cần implement interface này và đăng ký qua PaymentService.RegisterProvider
+type PaymentProvider interface {
+	// Name trả về key của provider (dùng trong request và URL webhook)
+	Name() string
+	// Method trả về phương thức thanh toán mà provider xử lý
+	Method() models.PaymentMethod
+	// Initiate khởi tạo giao dịch cho payment vừa được tạo
+	Initiate(payment *models.Payment, order *models.Order) (*PaymentInitResult, error)
+}
+
+// PaymentInitResult là kết quả khởi tạo giao dịch từ provider
+type PaymentInitResult struct {
+	Status       models.PaymentStatus   // Trạng thái ban đầu của payment
+	Details      map[string]interface{} // Được lưu vào Payment.PaymentDetails dưới dạng JSON
+	Instructions *string                // Hướng dẫn hiển thị cho khách hàng
+	RedirectURL  *string                // URL chuyển hướng sang cổng thanh toán (nếu có)
+}
+
+// WebhookProvider là provider có callback (IPN) từ cổng thanh toán
+type WebhookProvider interface {
+	PaymentProvider
+	// ParseWebhook xác thực chữ ký của callback và trả về kết quả giao dịch
+	ParseWebhook(headers http.Header, body []byte) (*WebhookEvent, error)
+}
+
+// WebhookEvent là kết quả giao dịch do cổng thanh toán gửi về
+type WebhookEvent struct {
+	TransactionID string
+	Status        models.PaymentStatus // completed hoặc failed
+	Amount        *float64             // Số tiền cổng thanh toán ghi nhận (nếu có) để đối soát
+}
+
+// ErrInvalidWebhookSignature được trả về khi chữ ký callback không hợp lệ
+var ErrInvalidWebhookSignature = errors.New("chữ ký webhook không hợp lệ")
@@ -0,0 +1,54 @@
+package services
+
+import (
+	"fmt"
+
+	"ecommerce-be/models"
+)
+
+// BankTransferProvider - Chuyển khoản ngân hàng, được admin xác nhận thủ công
+// Nội dung chuyển khoản là TransactionID để đối soát
+type BankTransferProvider struct {
+	bankName      string
+	accountNumber string
+	accountName   string
+}
+
+func NewBankTransferProvider() *BankTransferProvider {
+	return &BankTransferProvider{
+		bankName:      getEnv("BANK_NAME", ""),
+		accountNumber: getEnv("BANK_ACCOUNT_NUMBER", ""),
+		accountName:   getEnv("BANK_ACCOUNT_NAME", ""),
+	}
+}
+
+func (p *BankTransferProvider) Name() string {
+	return string(models.PaymentMethodBankTransfer)
+}
+
+func (p *BankTransferProvider) Method() models.PaymentMethod {
+	return models.PaymentMethodBankTransfer
+}
+
+func (p *BankTransferProvider) Initiate(payment *models.Payment, order *models.Order) (*PaymentInitResult, error) {
+	if p.accountNumber == "" {
+		return nil, fmt.Errorf("chưa cấu hình tài khoản nhận chuyển khoản")
+	}
+
+	instructions := fmt.Sprintf(
+		"Vui lòng chuyển khoản %.0f VND đến tài khoản %s - %s (%s) với nội dung: %s",
+		payment.Amount, p.accountNumber, p.accountName, p.bankName, payment.TransactionID,
+	)
+	return &PaymentInitResult{
+		Status: models.PaymentStatusPending,
+		Details: map[string]interface{}{
+			"provider":        p.Name(),
+			"orderNumber":     order.OrderNumber,
+			"bankName":        p.bankName,
+			"accountNumber":   p.accountNumber,
+			"accountName":     p.accountName,
+			"transferContent": payment.TransactionID,
+		},
+		Instructions: &instructions,
+	}, nil
+}
@@ -0,0 +1,33 @@
+package services
+
+import (
+	"ecommerce-be/models"
+)
+
+// CODProvider - Thanh toán khi nhận hàng
+// Payment ở trạng thái pending cho đến khi admin xác nhận đã thu tiền
+type CODProvider struct{}
+
+func NewCODProvider() *CODProvider {
+	return &CODProvider{}
+}
+
+func (p *CODProvider) Name() string {
+	return string(models.PaymentMethodCOD)
+}
+
+func (p *CODProvider) Method() models.PaymentMethod {
+	return models.PaymentMethodCOD
+}
+
+func (p *CODProvider) Initiate(payment *models.Payment, order *models.Order) (*PaymentInitResult, error) {
+	instructions := "Vui lòng chuẩn bị tiền mặt để thanh toán khi nhận hàng."
+	return &PaymentInitResult{
+		Status: models.PaymentStatusPending,
+		Details: map[string]interface{}{
+			"provider":    p.Name(),
+			"orderNumber": order.OrderNumber,
+		},
+		Instructions: &instructions,
+	}, nil
+}
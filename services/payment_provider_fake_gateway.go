@@ -0,0 +1,117 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"ecommerce-be/models"
+)
+
+// FakeGatewaySignatureHeader là header chứa chữ ký HMAC-SHA256 (hex) của body callback
+const FakeGatewaySignatureHeader = "X-Signature"
+
+// FakeGatewayProvider - Cổng thanh toán giả lập chạy trong process, dùng để test toàn bộ luồng
+// thanh toán → webhook mà không cần kết nối mạng. Chỉ bật khi FAKE_GATEWAY_ENABLED=true
+type FakeGatewayProvider struct {
+	secret []byte
+}
+
+// fakeGatewayPayload là body callback mà cổng giả lập gửi về
+type fakeGatewayPayload struct {
+	TransactionID string  `json:"transactionId"`
+	Status        string  `json:"status"` // success hoặc failed
+	Amount        float64 `json:"amount"`
+	EventID       string  `json:"eventId"`
+}
+
+func NewFakeGatewayProvider() *FakeGatewayProvider {
+	return &FakeGatewayProvider{
+		secret: []byte(getEnv("FAKE_GATEWAY_SECRET", "fake-gateway-dev-secret")),
+	}
+}
+
+func (p *FakeGatewayProvider) Name() string {
+	return "fakepay"
+}
+
+func (p *FakeGatewayProvider) Method() models.PaymentMethod {
+	return models.PaymentMethodEWallet
+}
+
+func (p *FakeGatewayProvider) Initiate(payment *models.Payment, order *models.Order) (*PaymentInitResult, error) {
+	redirectURL := fmt.Sprintf("/api/v1/payments/fake-gateway/%s", payment.TransactionID)
+	return &PaymentInitResult{
+		Status: models.PaymentStatusPending,
+		Details: map[string]interface{}{
+			"provider":    p.Name(),
+			"orderNumber": order.OrderNumber,
+		},
+		RedirectURL: &redirectURL,
+	}, nil
+}
+
+// ParseWebhook xác thực chữ ký HMAC và parse body callback
+func (p *FakeGatewayProvider) ParseWebhook(headers http.Header, body []byte) (*WebhookEvent, error) {
+	signature, err := hex.DecodeString(headers.Get(FakeGatewaySignatureHeader))
+	if err != nil || !hmac.Equal(signature, p.sign(body)) {
+		return nil, ErrInvalidWebhookSignature
+	}
+
+	var payload fakeGatewayPayload
+	if err := json.Unmarshal(body, &payload); err != nil {
+		return nil, errors.New("dữ liệu webhook không hợp lệ")
+	}
+	if payload.TransactionID == "" {
+		return nil, errors.New("webhook thiếu mã giao dịch")
+	}
+
+	event := &WebhookEvent{
+		TransactionID: payload.TransactionID,
+		Amount:        &payload.Amount,
+	}
+	switch strings.ToLower(payload.Status) {
+	case "success":
+		event.Status = models.PaymentStatusCompleted
+	case "failed":
+		event.Status = models.PaymentStatusFailed
+	default:
+		return nil, fmt.Errorf("trạng thái webhook không hợp lệ: %s", payload.Status)
+	}
+	return event, nil
+}
+
+// BuildCallback tạo body callback đã ký giống như cổng thanh toán thật sẽ gửi
+func (p *FakeGatewayProvider) BuildCallback(transactionID string, amount float64, success bool) ([]byte, string, error) {
+	status := "failed"
+	if success {
+		status = "success"
+	}
+
+	eventID, err := generateRandomHex(8)
+	if err != nil {
+		return nil, "", err
+	}
+
+	body, err := json.Marshal(fakeGatewayPayload{
+		TransactionID: transactionID,
+		Status:        status,
+		Amount:        amount,
+		EventID:       eventID,
+	})
+	if err != nil {
+		return nil, "", err
+	}
+	return body, hex.EncodeToString(p.sign(body)), nil
+}
+
+func (p *FakeGatewayProvider) sign(body []byte) []byte {
+	mac := hmac.New(sha256.New, p.secret)
+	mac.Write(body)
+	return mac.Sum(nil)
+}
@@ -0,0 +1,383 @@
+package services
+
+import (
+	"crypto/rand"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math"
+	"net/http"
+	"strings"
+	"time"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// ErrUnknownPaymentProvider được trả về khi provider không tồn tại hoặc không hỗ trợ webhook
+var ErrUnknownPaymentProvider = errors.New("cổng thanh toán không được hỗ trợ")
+
+type PaymentService struct {
+	providers        map[string]PaymentProvider
+	lifecycleService *OrderLifecycleService
+}
+
+func NewPaymentService() *PaymentService {
+	s := &PaymentService{
+		providers:        make(map[string]PaymentProvider),
+		lifecycleService: NewOrderLifecycleService(),
+	}
+	s.RegisterProvider(NewCODProvider())
+	s.RegisterProvider(NewBankTransferProvider())
+	if getEnv("FAKE_GATEWAY_ENABLED", "false") == "true" {
+		s.RegisterProvider(NewFakeGatewayProvider())
+	}
+	return s
+}
+
+// RegisterProvider đăng ký một cổng thanh toán
+func (s *PaymentService) RegisterProvider(provider PaymentProvider) {
+	s.providers[provider.Name()] = provider
+}
+
+// GetProvider lấy cổng thanh toán theo key
+func (s *PaymentService) GetProvider(name string) (PaymentProvider, bool) {
+	provider, ok := s.providers[name]
+	return provider, ok
+}
+
+// CreatePayment tạo giao dịch thanh toán cho đơn hàng của user
+func (s *PaymentService) CreatePayment(userID, orderID uint, req dto.CreatePaymentRequest) (*dto.PaymentResponse, error) {
+	providerName := req.Method
+	if req.Provider != nil && *req.Provider != "" {
+		providerName = strings.ToLower(strings.TrimSpace(*req.Provider))
+	}
+
+	provider, ok := s.GetProvider(providerName)
+	if !ok || string(provider.Method()) != req.Method {
+		return nil, errors.New("phương thức thanh toán chưa được hỗ trợ")
+	}
+
+	var payment models.Payment
+	var initResult *PaymentInitResult
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// Khóa order để tránh tạo trùng payment khi request bị gửi 2 lần
+		var order models.Order
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", orderID, userID).
+			First(&order).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("không tìm thấy đơn hàng")
+			}
+			return err
+		}
+
+		if order.Status == models.OrderStatusCancelled || order.Status == models.OrderStatusRefunded {
+			return errors.New("không thể thanh toán cho đơn hàng đã hủy hoặc đã hoàn tiền")
+		}
+
+		// Không cho tạo thêm nếu đã thanh toán hoặc đang có giao dịch chờ xử lý
+		var activeCount int64
+		if err := tx.Model(&models.Payment{}).
+			Where("order_id = ? AND status IN ?", order.ID, []models.PaymentStatus{
+				models.PaymentStatusPending, models.PaymentStatusProcessing, models.PaymentStatusCompleted,
+			}).
+			Count(&activeCount).Error; err != nil {
+			return err
+		}
+		if activeCount > 0 {
+			return errors.New("đơn hàng đã được thanh toán hoặc đang có giao dịch chờ xử lý")
+		}
+
+		transactionID, err := s.generateTransactionID(tx)
+		if err != nil {
+			return err
+		}
+
+		var notes *string
+		if req.Notes != nil && strings.TrimSpace(*req.Notes) != "" {
+			trimmed := strings.TrimSpace(*req.Notes)
+			notes = &trimmed
+		}
+
+		payment = models.Payment{
+			TransactionID: transactionID,
+			Amount:        order.TotalAmount,
+			Method:        provider.Method(),
+			Notes:         notes,
+			UserID:        userID,
+			OrderID:       order.ID,
+		}
+
+		initResult, err = provider.Initiate(&payment, &order)
+		if err != nil {
+			return err
+		}
+		payment.Status = initResult.Status
+		if initResult.Details != nil {
+			details, err := json.Marshal(initResult.Details)
+			if err != nil {
+				return err
+			}
+			detailsStr := string(details)
+			payment.PaymentDetails = &detailsStr
+		}
+
+		if err := tx.Create(&payment).Error; err != nil {
+			return errors.New("không thể tạo giao dịch thanh toán")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	response := mapPaymentToResponse(&payment)
+	response.Instructions = initResult.Instructions
+	response.RedirectURL = initResult.RedirectURL
+	return response, nil
+}
+
+// ListByOrder lấy danh sách thanh toán của một đơn hàng (chỉ chủ đơn hàng)
+func (s *PaymentService) ListByOrder(userID, orderID uint) ([]dto.PaymentResponse, error) {
+	var order models.Order
+	if err := database.DB.Where("id = ? AND user_id = ?", orderID, userID).First(&order).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("không tìm thấy đơn hàng")
+		}
+		return nil, err
+	}
+
+	var payments []models.Payment
+	if err := database.DB.Where("order_id = ?", order.ID).Order("created_at DESC").Find(&payments).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách thanh toán")
+	}
+
+	responses := make([]dto.PaymentResponse, len(payments))
+	for i := range payments {
+		responses[i] = *mapPaymentToResponse(&payments[i])
+	}
+	return responses, nil
+}
+
+// UpdateStatus admin xác nhận (completed) hoặc từ chối (failed) thanh toán
+// Thanh toán thành công thì đơn còn pending được chuyển sang confirmed; thất bại thì đơn vẫn pending để khách thanh toán lại
+func (s *PaymentService) UpdateStatus(paymentID uint, req dto.UpdatePaymentStatusRequest, actorID uint) (*dto.PaymentResponse, error) {
+	var payment models.Payment
+	var restockedIDs []uint
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&payment, paymentID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("không tìm thấy giao dịch với ID %d", paymentID)
+			}
+			return err
+		}
+
+		ids, err := s.applyPaymentStatus(tx, &payment, models.PaymentStatus(req.Status), &actorID, req.Notes)
+		if err != nil {
+			return err
+		}
+		restockedIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.lifecycleService.invalidateProducts(restockedIDs)
+
+	return mapPaymentToResponse(&payment), nil
+}
+
+// applyPaymentStatus chuyển payment (đã được lock) sang completed/failed và đồng bộ trạng thái đơn hàng
+// Payment failed không hủy đơn: khách có thể tạo payment mới (cách khác), hủy đơn chỉ qua transition cancel
+func (s *PaymentService) applyPaymentStatus(tx *gorm.DB, payment *models.Payment, status models.PaymentStatus, actorID *uint, notes *string) ([]uint, error) {
+	if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusProcessing {
+		return nil, fmt.Errorf("giao dịch đang ở trạng thái %s, không thể cập nhật", payment.Status)
+	}
+
+	updates := map[string]interface{}{"status": status}
+	if notes != nil && strings.TrimSpace(*notes) != "" {
+		updates["notes"] = strings.TrimSpace(*notes)
+	}
+	if err := tx.Model(payment).Updates(updates).Error; err != nil {
+		return nil, errors.New("không thể cập nhật giao dịch")
+	}
+	payment.Status = status
+
+	if status != models.PaymentStatusCompleted {
+		return nil, nil
+	}
+
+	var order models.Order
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&order, payment.OrderID).Error; err != nil {
+		return nil, err
+	}
+	if order.Status != models.OrderStatusPending {
+		return nil, nil
+	}
+
+	reason := fmt.Sprintf("Thanh toán %s thành công", payment.TransactionID)
+	return s.lifecycleService.applyTransition(tx, &order, models.OrderStatusConfirmed, actorID, &reason)
+}
+
+// HandleWebhook xử lý callback từ cổng thanh toán
+// Chữ ký được xác thực bởi provider; mỗi TransactionID chỉ được cập nhật trạng thái đúng một lần,
+// các callback lặp lại được bỏ qua và trả về duplicate = true
+func (s *PaymentService) HandleWebhook(providerName string, headers http.Header, body []byte) (*dto.PaymentResponse, bool, error) {
+	provider, ok := s.GetProvider(providerName)
+	if !ok {
+		return nil, false, ErrUnknownPaymentProvider
+	}
+	webhookProvider, ok := provider.(WebhookProvider)
+	if !ok {
+		return nil, false, ErrUnknownPaymentProvider
+	}
+
+	event, err := webhookProvider.ParseWebhook(headers, body)
+	if err != nil {
+		return nil, false, err
+	}
+
+	var payment models.Payment
+	var restockedIDs []uint
+	duplicate := false
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		// Lock theo TransactionID để các callback đến cùng lúc được xử lý tuần tự
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("transaction_id = ?", event.TransactionID).
+			First(&payment).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return fmt.Errorf("không tìm thấy giao dịch %s", event.TransactionID)
+			}
+			return err
+		}
+
+		if payment.Method != provider.Method() {
+			return errors.New("giao dịch không thuộc cổng thanh toán này")
+		}
+
+		// Đã xử lý trước đó → bỏ qua
+		if payment.Status != models.PaymentStatusPending && payment.Status != models.PaymentStatusProcessing {
+			duplicate = true
+			return nil
+		}
+
+		if event.Amount != nil && math.Abs(*event.Amount-payment.Amount) > 0.005 {
+			return fmt.Errorf("số tiền giao dịch không khớp (nhận %.2f, cần %.2f)", *event.Amount, payment.Amount)
+		}
+
+		// Lưu nguyên văn callback để đối soát
+		raw := string(body)
+		if err := tx.Model(&payment).Update("payment_details", raw).Error; err != nil {
+			return errors.New("không thể lưu dữ liệu callback")
+		}
+		payment.PaymentDetails = &raw
+
+		ids, err := s.applyPaymentStatus(tx, &payment, event.Status, nil, nil)
+		if err != nil {
+			return err
+		}
+		restockedIDs = ids
+		return nil
+	})
+	if err != nil {
+		return nil, false, err
+	}
+
+	s.lifecycleService.invalidateProducts(restockedIDs)
+
+	return mapPaymentToResponse(&payment), duplicate, nil
+}
+
+// FakeGatewayPay giả lập khách hàng thanh toán trên cổng giả lập:
+// tạo callback đã ký rồi gửi vào HandleWebhook giống như cổng thật gọi về
+// Trả về kết quả xử lý cùng body/chữ ký để có thể gửi lại webhook thủ công khi test
+func (s *PaymentService) FakeGatewayPay(transactionID string, success bool) (*dto.FakeGatewayResponse, error) {
+	provider, ok := s.GetProvider("fakepay")
+	if !ok {
+		return nil, ErrUnknownPaymentProvider
+	}
+	fakeGateway := provider.(*FakeGatewayProvider)
+
+	var payment models.Payment
+	if err := database.DB.Where("transaction_id = ?", transactionID).First(&payment).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("không tìm thấy giao dịch %s", transactionID)
+		}
+		return nil, err
+	}
+
+	body, signature, err := fakeGateway.BuildCallback(payment.TransactionID, payment.Amount, success)
+	if err != nil {
+		return nil, errors.New("không thể tạo callback")
+	}
+
+	headers := http.Header{}
+	headers.Set(FakeGatewaySignatureHeader, signature)
+	result, duplicate, err := s.HandleWebhook(fakeGateway.Name(), headers, body)
+	if err != nil {
+		return nil, err
+	}
+
+	return &dto.FakeGatewayResponse{
+		Payment:   *result,
+		Duplicate: duplicate,
+		Callback:  string(body),
+		Signature: signature,
+	}, nil
+}
+
+// generateTransactionID tạo mã giao dịch dạng PAY-YYYYMMDDHHMMSS-XXXXXXXX (unique)
+func (s *PaymentService) generateTransactionID(tx *gorm.DB) (string, error) {
+	for i := 0; i < 5; i++ {
+		suffix, err := generateRandomHex(4)
+		if err != nil {
+			return "", errors.New("không thể tạo mã giao dịch")
+		}
+		transactionID := fmt.Sprintf("PAY-%s-%s", time.Now().Format("20060102150405"), strings.ToUpper(suffix))
+
+		var count int64
+		if err := tx.Model(&models.Payment{}).Unscoped().Where("transaction_id = ?", transactionID).Count(&count).Error; err != nil {
+			return "", err
+		}
+		if count == 0 {
+			return transactionID, nil
+		}
+	}
+	return "", errors.New("không thể tạo mã giao dịch")
+}
+
+// generateRandomHex tạo chuỗi hex ngẫu nhiên từ n byte (crypto/rand)
+func generateRandomHex(n int) (string, error) {
+	buf := make([]byte, n)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// Helper function để map Payment sang PaymentResponse
+func mapPaymentToResponse(payment *models.Payment) *dto.PaymentResponse {
+	return &dto.PaymentResponse{
+		ID:             payment.ID,
+		TransactionID:  payment.TransactionID,
+		Amount:         payment.Amount,
+		Method:         string(payment.Method),
+		Status:         string(payment.Status),
+		PaymentDetails: payment.PaymentDetails,
+		Notes:          payment.Notes,
+		UserID:         payment.UserID,
+		OrderID:        payment.OrderID,
+		CreatedAt:      payment.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:      payment.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,259 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"os"
+	"testing"
+	"time"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/logger"
+)
+
+// openPaymentTestDB kết nối Postgres test (TEST_DATABASE_DSN) và chạy mỗi test trong một transaction được rollback khi kết thúc
+// Bỏ qua test nếu chưa cấu hình TEST_DATABASE_DSN
+func openPaymentTestDB(t *testing.T) {
+	t.Helper()
+
+	dsn := os.Getenv("TEST_DATABASE_DSN")
+	if dsn == "" {
+		t.Skip("TEST_DATABASE_DSN chưa được cấu hình, bỏ qua test cần Postgres")
+	}
+
+	db, err := gorm.Open(postgres.Open(dsn), &gorm.Config{Logger: logger.Default.LogMode(logger.Silent)})
+	if err != nil {
+		t.Fatalf("không thể kết nối database test: %v", err)
+	}
+	if err := db.AutoMigrate(
+		&models.User{},
+		&models.Address{},
+		&models.Category{},
+		&models.Product{},
+		&models.Order{},
+		&models.OrderItem{},
+		&models.OrderStatusHistory{},
+		&models.Payment{},
+	); err != nil {
+		t.Fatalf("không thể migrate database test: %v", err)
+	}
+
+	tx := db.Begin()
+	previous := database.DB
+	database.DB = tx
+	t.Cleanup(func() {
+		database.DB = previous
+		tx.Rollback()
+		if sqlDB, err := db.DB(); err == nil {
+			sqlDB.Close()
+		}
+	})
+}
+
+// createPendingOrder tạo user, địa chỉ và một đơn hàng pending để thanh toán
+func createPendingOrder(t *testing.T, total float64) *models.Order {
+	t.Helper()
+
+	suffix := fmt.Sprintf("%d", time.Now().UnixNano())
+	user := models.User{Email: "payment-" + suffix + "@example.com", Password: "x", Name: "Payment Test", IsActive: true}
+	if err := database.DB.Create(&user).Error; err != nil {
+		t.Fatalf("không thể tạo user: %v", err)
+	}
+	address := models.Address{FullName: "Payment Test", Phone: "0900000000", Address: "1 Test", UserID: user.ID}
+	if err := database.DB.Create(&address).Error; err != nil {
+		t.Fatalf("không thể tạo địa chỉ: %v", err)
+	}
+	order := models.Order{
+		OrderNumber:       "ORD-TEST-" + suffix,
+		TotalAmount:       total,
+		Status:            models.OrderStatusPending,
+		UserID:            user.ID,
+		ShippingAddressID: address.ID,
+	}
+	if err := database.DB.Create(&order).Error; err != nil {
+		t.Fatalf("không thể tạo đơn hàng: %v", err)
+	}
+	return &order
+}
+
+func newFakeGatewayPaymentService() (*PaymentService, *FakeGatewayProvider) {
+	s := NewPaymentService()
+	gateway := NewFakeGatewayProvider()
+	s.RegisterProvider(gateway)
+	return s, gateway
+}
+
+// initiateFakePayment tạo payment qua cổng giả lập cho đơn hàng
+func initiateFakePayment(t *testing.T, s *PaymentService, order *models.Order) *dto.PaymentResponse {
+	t.Helper()
+
+	provider := "fakepay"
+	payment, err := s.CreatePayment(order.UserID, order.ID, dto.CreatePaymentRequest{
+		Method:   string(models.PaymentMethodEWallet),
+		Provider: &provider,
+	})
+	if err != nil {
+		t.Fatalf("CreatePayment: %v", err)
+	}
+	if payment.Status != string(models.PaymentStatusPending) {
+		t.Fatalf("payment mới phải ở trạng thái pending, nhận %s", payment.Status)
+	}
+	if payment.RedirectURL == nil {
+		t.Fatal("cổng giả lập phải trả về redirectUrl")
+	}
+	return payment
+}
+
+func signedHeaders(signature string) http.Header {
+	headers := http.Header{}
+	headers.Set(FakeGatewaySignatureHeader, signature)
+	return headers
+}
+
+func assertOrderState(t *testing.T, orderID uint, status models.OrderStatus, historyCount int64) {
+	t.Helper()
+
+	var order models.Order
+	if err := database.DB.First(&order, orderID).Error; err != nil {
+		t.Fatalf("không thể đọc đơn hàng: %v", err)
+	}
+	if order.Status != status {
+		t.Fatalf("đơn hàng phải ở trạng thái %s, nhận %s", status, order.Status)
+	}
+
+	var count int64
+	database.DB.Model(&models.OrderStatusHistory{}).Where("order_id = ?", orderID).Count(&count)
+	if count != historyCount {
+		t.Fatalf("đơn hàng phải có %d dòng lịch sử trạng thái, nhận %d", historyCount, count)
+	}
+}
+
+func TestFakeGatewayWebhookRejectsBadSignature(t *testing.T) {
+	s, gateway := newFakeGatewayPaymentService()
+
+	body, signature, err := gateway.BuildCallback("PAY-TEST", 100000, true)
+	if err != nil {
+		t.Fatalf("BuildCallback: %v", err)
+	}
+
+	cases := map[string]struct {
+		body      []byte
+		signature string
+	}{
+		"thiếu chữ ký":          {body, ""},
+		"chữ ký không phải hex": {body, "not-hex"},
+		"body bị sửa":           {[]byte(`{"transactionId":"PAY-TEST","status":"success","amount":1}`), signature},
+		"ký bằng secret khác":   {body, signWithOtherSecret(gateway, body)},
+	}
+	for name, tc := range cases {
+		t.Run(name, func(t *testing.T) {
+			_, _, err := s.HandleWebhook(gateway.Name(), signedHeaders(tc.signature), tc.body)
+			if !errors.Is(err, ErrInvalidWebhookSignature) {
+				t.Fatalf("phải trả về ErrInvalidWebhookSignature, nhận %v", err)
+			}
+		})
+	}
+}
+
+// signWithOtherSecret ký body bằng một secret khác với cổng giả lập
+func signWithOtherSecret(gateway *FakeGatewayProvider, body []byte) string {
+	other := &FakeGatewayProvider{secret: append([]byte("other-"), gateway.secret...)}
+	return fmt.Sprintf("%x", other.sign(body))
+}
+
+func TestFakeGatewayRoundTrip(t *testing.T) {
+	openPaymentTestDB(t)
+	s, gateway := newFakeGatewayPaymentService()
+
+	order := createPendingOrder(t, 150000)
+	payment := initiateFakePayment(t, s, order)
+
+	body, signature, err := gateway.BuildCallback(payment.TransactionID, payment.Amount, true)
+	if err != nil {
+		t.Fatalf("BuildCallback: %v", err)
+	}
+
+	result, duplicate, err := s.HandleWebhook(gateway.Name(), signedHeaders(signature), body)
+	if err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if duplicate {
+		t.Fatal("callback đầu tiên không được đánh dấu duplicate")
+	}
+	if result.Status != string(models.PaymentStatusCompleted) {
+		t.Fatalf("payment phải completed, nhận %s", result.Status)
+	}
+	assertOrderState(t, order.ID, models.OrderStatusConfirmed, 1)
+
+	// Gửi lại đúng callback cũ → duplicate, không chuyển trạng thái lần nữa
+	result, duplicate, err = s.HandleWebhook(gateway.Name(), signedHeaders(signature), body)
+	if err != nil {
+		t.Fatalf("HandleWebhook (replay): %v", err)
+	}
+	if !duplicate {
+		t.Fatal("callback gửi lại phải được đánh dấu duplicate")
+	}
+	if result.Status != string(models.PaymentStatusCompleted) {
+		t.Fatalf("payment vẫn phải completed, nhận %s", result.Status)
+	}
+	assertOrderState(t, order.ID, models.OrderStatusConfirmed, 1)
+}
+
+func TestFakeGatewayWebhookRejectsAmountMismatch(t *testing.T) {
+	openPaymentTestDB(t)
+	s, gateway := newFakeGatewayPaymentService()
+
+	order := createPendingOrder(t, 200000)
+	payment := initiateFakePayment(t, s, order)
+
+	body, signature, err := gateway.BuildCallback(payment.TransactionID, payment.Amount-1000, true)
+	if err != nil {
+		t.Fatalf("BuildCallback: %v", err)
+	}
+
+	if _, _, err := s.HandleWebhook(gateway.Name(), signedHeaders(signature), body); err == nil {
+		t.Fatal("callback sai số tiền phải bị từ chối")
+	}
+
+	var stored models.Payment
+	if err := database.DB.Where("transaction_id = ?", payment.TransactionID).First(&stored).Error; err != nil {
+		t.Fatalf("không thể đọc payment: %v", err)
+	}
+	if stored.Status != models.PaymentStatusPending {
+		t.Fatalf("payment phải giữ trạng thái pending, nhận %s", stored.Status)
+	}
+	assertOrderState(t, order.ID, models.OrderStatusPending, 0)
+}
+
+func TestFakeGatewayFailedPaymentKeepsOrderPending(t *testing.T) {
+	openPaymentTestDB(t)
+	s, gateway := newFakeGatewayPaymentService()
+
+	order := createPendingOrder(t, 120000)
+	payment := initiateFakePayment(t, s, order)
+
+	body, signature, err := gateway.BuildCallback(payment.TransactionID, payment.Amount, false)
+	if err != nil {
+		t.Fatalf("BuildCallback: %v", err)
+	}
+	result, _, err := s.HandleWebhook(gateway.Name(), signedHeaders(signature), body)
+	if err != nil {
+		t.Fatalf("HandleWebhook: %v", err)
+	}
+	if result.Status != string(models.PaymentStatusFailed) {
+		t.Fatalf("payment phải failed, nhận %s", result.Status)
+	}
+	assertOrderState(t, order.ID, models.OrderStatusPending, 0)
+
+	// Khách thanh toán lại bằng giao dịch mới
+	retry := initiateFakePayment(t, s, order)
+	if retry.TransactionID == payment.TransactionID {
+		t.Fatal("lần thanh toán lại phải có mã giao dịch mới")
+	}
+}
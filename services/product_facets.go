@@ -0,0 +1,123 @@
+package services
+
+import (
+	"errors"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+
+	"gorm.io/gorm"
+)
+
+const (
+	defaultPriceBuckets = 5
+	ratingBands         = 5 // 0-1, 1-2, 2-3, 3-4, 4-5 sao
+)
+
+// buildFacets tính facets cho sidebar lọc từ query đã áp dụng bộ lọc tìm kiếm (chưa sort/phân trang)
+func (s *ProductService) buildFacets(filtered *gorm.DB, language string, priceBuckets int) (*dto.ProductFacets, error) {
+	if priceBuckets <= 0 {
+		priceBuckets = defaultPriceBuckets
+	}
+
+	// Gom theo subquery để mọi facet dùng đúng bộ lọc (kể cả soft delete) của kết quả tìm kiếm
+	subquery := filtered.Session(&gorm.Session{}).
+		Select("products.id", "products.category_id", "products.price", "products.stock", "products.rating")
+	from := func() *gorm.DB {
+		return database.DB.Table("(?) AS filtered", subquery)
+	}
+
+	facets := &dto.ProductFacets{
+		Categories: []dto.CategoryFacet{},
+		Prices:     []dto.PriceFacet{},
+		Ratings:    make([]dto.RatingFacet, ratingBands),
+	}
+
+	// Tồn kho và khoảng giá
+	var summary struct {
+		InStock    int64
+		OutOfStock int64
+		MinPrice   *float64
+		MaxPrice   *float64
+	}
+	if err := from().
+		Select("COUNT(*) FILTER (WHERE stock > 0) AS in_stock, COUNT(*) FILTER (WHERE stock <= 0) AS out_of_stock, MIN(price) AS min_price, MAX(price) AS max_price").
+		Scan(&summary).Error; err != nil {
+		return nil, errors.New("không thể thống kê tồn kho sản phẩm")
+	}
+	facets.Stock = dto.StockFacet{InStock: summary.InStock, OutOfStock: summary.OutOfStock}
+
+	// Danh mục
+	nameColumn := "categories.name"
+	if language == "en" {
+		nameColumn = "COALESCE(NULLIF(categories.name_en, ''), categories.name)"
+	}
+	if err := from().
+		Select("filtered.category_id, " + nameColumn + " AS name, COUNT(*) AS count").
+		Joins("LEFT JOIN categories ON categories.id = filtered.category_id").
+		Group("filtered.category_id, categories.name, categories.name_en").
+		Order("count DESC, filtered.category_id").
+		Scan(&facets.Categories).Error; err != nil {
+		return nil, errors.New("không thể thống kê sản phẩm theo danh mục")
+	}
+
+	// Khoảng giá chia đều từ giá thấp nhất đến cao nhất
+	if summary.MinPrice != nil && summary.MaxPrice != nil {
+		minPrice, maxPrice := *summary.MinPrice, *summary.MaxPrice
+		if minPrice == maxPrice {
+			priceBuckets = 1
+		}
+		width := (maxPrice - minPrice) / float64(priceBuckets)
+
+		var rows []struct {
+			Bucket int
+			Count  int64
+		}
+		query := from().Select("1 AS bucket, COUNT(*) AS count")
+		if priceBuckets > 1 {
+			// width_bucket đưa giá = max vào bucket n+1 nên gộp về bucket cuối
+			query = from().
+				Select("LEAST(width_bucket(price, ?, ?, ?), ?) AS bucket, COUNT(*) AS count", minPrice, maxPrice, priceBuckets, priceBuckets).
+				Group("bucket")
+		}
+		if err := query.Scan(&rows).Error; err != nil {
+			return nil, errors.New("không thể thống kê sản phẩm theo khoảng giá")
+		}
+
+		facets.Prices = make([]dto.PriceFacet, priceBuckets)
+		for i := range facets.Prices {
+			facets.Prices[i] = dto.PriceFacet{
+				Min: minPrice + width*float64(i),
+				Max: minPrice + width*float64(i+1),
+			}
+		}
+		facets.Prices[priceBuckets-1].Max = maxPrice
+		for _, row := range rows {
+			if row.Bucket >= 1 && row.Bucket <= priceBuckets {
+				facets.Prices[row.Bucket-1].Count = row.Count
+			}
+		}
+	}
+
+	// Đánh giá
+	var ratingRows []struct {
+		Band  int
+		Count int64
+	}
+	if err := from().
+		Select("LEAST(GREATEST(FLOOR(rating), 0), ?)::int AS band, COUNT(*) AS count", ratingBands-1).
+		Group("band").
+		Scan(&ratingRows).Error; err != nil {
+		return nil, errors.New("không thể thống kê sản phẩm theo đánh giá")
+	}
+	for i := range facets.Ratings {
+		facets.Ratings[i] = dto.RatingFacet{Min: i, Max: i + 1}
+	}
+	for _, row := range ratingRows {
+		if row.Band >= 0 && row.Band < ratingBands {
+			facets.Ratings[row.Band].Count = row.Count
+		}
+	}
+
+	return facets, nil
+}
@@ -3,7 +3,6 @@ package services
 import (
 	"errors"
 	"fmt"
-	"math"
 	"strings"
 	"time"
 
@@ -15,10 +14,14 @@ import (
 	"gorm.io/gorm"
 )
 
-type ProductService struct{}
+type ProductService struct {
+	searchAnalytics *SearchAnalyticsService
+}
 
 func NewProductService() *ProductService {
-	return &ProductService{}
+	return &ProductService{
+		searchAnalytics: NewSearchAnalyticsService(),
+	}
 }
 
 // Create tạo product mới
@@ -81,7 +84,8 @@ func (s *ProductService) Create(req dto.CreateProductRequest) (*models.Product,
 }
 
 // Search tìm kiếm và lọc products
-func (s *ProductService) Search(req dto.SearchProductRequest, language string) (*dto.ProductPaginationResponse, error) {
+// searcher (IP hoặc user của người tìm) dùng để thống kê từ khóa, mỗi người chỉ được đếm một lượt mỗi giờ
+func (s *ProductService) Search(req dto.SearchProductRequest, language, searcher string) (*dto.ProductPaginationResponse, error) {
 	// Default values
 	sortBy := "createdAt"
 	sortOrder := "DESC"
@@ -104,34 +108,17 @@ func (s *ProductService) Search(req dto.SearchProductRequest, language string) (
 	// Build query
 	query := database.DB.Model(&models.Product{}).Preload("Category")
 
-	// Search theo name (partial match, case-insensitive, không phân biệt dấu)
-	// Tìm kiếm cả tiếng Việt và tiếng Anh
-	if req.Name != nil && *req.Name != "" {
-		normalizedSearch := s.normalizeForSearch(*req.Name)
-
-		// Lấy tất cả products để filter trong memory (vì PostgreSQL không có built-in function để xóa dấu tiếng Việt)
-		var allProducts []models.Product
-		if err := database.DB.Select("id", "name", "name_en").Find(&allProducts).Error; err != nil {
-			return nil, errors.New("không thể tìm kiếm sản phẩm")
-		}
-
-		// Filter products có tên chứa search term
-		var matchedIDs []uint
-		for _, prod := range allProducts {
-			nameVi := s.normalizeForSearch(prod.Name)
-			nameEn := ""
-			if prod.NameEn != nil {
-				nameEn = s.normalizeForSearch(*prod.NameEn)
-			}
-			if strings.Contains(nameVi, normalizedSearch) || (nameEn != "" && strings.Contains(nameEn, normalizedSearch)) {
-				matchedIDs = append(matchedIDs, prod.ID)
-			}
-		}
-
-		if len(matchedIDs) > 0 {
-			query = query.Where("id IN ?", matchedIDs)
-		} else {
-			query = query.Where("1 = 0") // Always false condition
+	// Search theo tên/mô tả (partial match, case-insensitive, không phân biệt dấu)
+	// Tìm kiếm cả tiếng Việt và tiếng Anh bằng full-text search của PostgreSQL
+	var search *textSearch
+	if req.Name != nil {
+		search = newTextSearch(*req.Name)
+	}
+	if search != nil {
+		query = search.apply(query)
+		// Có từ khóa mà không chọn cách sắp xếp → ưu tiên kết quả liên quan nhất
+		if req.SortBy == nil {
+			sortBy = SortByRelevance
 		}
 	}
 
@@ -218,23 +205,60 @@ func (s *ProductService) Search(req dto.SearchProductRequest, language string) (
 		query = query.Where("stock > 0")
 	}
 
-	// Count total
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("không thể đếm số lượng sản phẩm")
+	// Sort - map field names to database columns (relevance chỉ có ý nghĩa khi có từ khóa)
+	key := s.sortKey(sortBy, sortOrder)
+	if sortBy == SortByRelevance && search != nil {
+		key = search.relevanceKey(sortOrder)
+	}
+	pagination, err := newPageRequest(key, page, limit, req.Cursor, req.IncludeTotal)
+	if err != nil {
+		return nil, err
+	}
+
+	// Facets cho sidebar lọc - tính trên cùng bộ lọc trước khi sort/phân trang
+	var facets *dto.ProductFacets
+	if req.IncludeFacets != nil && *req.IncludeFacets {
+		priceBuckets := 0
+		if req.PriceBuckets != nil {
+			priceBuckets = *req.PriceBuckets
+		}
+		if facets, err = s.buildFacets(query, language, priceBuckets); err != nil {
+			return nil, err
+		}
 	}
 
-	// Sort - map field names to database columns
-	sortByColumn := s.mapSortFieldToColumn(sortBy)
-	orderBy := fmt.Sprintf("%s %s", sortByColumn, sortOrder)
-	query = query.Order(orderBy)
+	// Count total (tùy chọn - bỏ qua mặc định khi phân trang theo cursor)
+	var total int64
+	if pagination.IncludeTotal {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, errors.New("không thể đếm số lượng sản phẩm")
+		}
+	}
 
-	// Pagination
-	offset := (page - 1) * limit
+	// Pagination - theo page (offset) hoặc theo cursor (keyset)
 	var products []models.Product
-	if err := query.Offset(offset).Limit(limit).Find(&products).Error; err != nil {
+	if err := pagination.paginate(query, key).Find(&products).Error; err != nil {
 		return nil, errors.New("không thể lấy danh sách sản phẩm")
 	}
+	hasMore := len(products) > limit
+	if hasMore {
+		products = products[:limit]
+	}
+	var nextCursor *string
+	if len(products) > 0 {
+		if nextCursor, err = pagination.next(key, "products", hasMore, products[len(products)-1].ID); err != nil {
+			return nil, errors.New("không thể tạo cursor trang tiếp theo")
+		}
+	}
+
+	// Thống kê từ khóa - chỉ tính ở trang đầu để lướt các trang sau không bị đếm thành nhiều lượt tìm
+	if search != nil && pagination.isFirstPage() {
+		resultCount := int64(len(products))
+		if pagination.IncludeTotal {
+			resultCount = total
+		}
+		s.searchAnalytics.Record(SearchSourceSearch, *req.Name, resultCount, searcher)
+	}
 
 	// Transform với language nếu có
 	if language == "en" || language == "vi" {
@@ -283,14 +307,16 @@ func (s *ProductService) Search(req dto.SearchProductRequest, language string) (
 		}
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	totalCount, totalPages := pagination.totals(total)
 
 	return &dto.ProductPaginationResponse{
 		Data:       productResponses,
-		Total:      total,
-		Page:       page,
+		Total:      totalCount,
+		Page:       pagination.Page,
 		Limit:      limit,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
+		Facets:     facets,
 	}, nil
 }
 
@@ -530,25 +556,20 @@ func (s *ProductService) transformProduct(prod models.Product, language string)
 	return prod
 }
 
-// normalizeForSearch normalize string để tìm kiếm
-func (s *ProductService) normalizeForSearch(str string) string {
-	return strings.ToLower(strings.TrimSpace(str))
-}
-
-// mapSortFieldToColumn map sort field name to database column name
-func (s *ProductService) mapSortFieldToColumn(field string) string {
-	fieldMap := map[string]string{
-		"id":        "id",
-		"name":      "name",
-		"price":     "price",
-		"stock":     "stock",
-		"createdAt": "created_at",
-		"updatedAt": "updated_at",
+// sortKey map sort field name to database column name (kèm kiểu dữ liệu để tạo cursor)
+func (s *ProductService) sortKey(field, order string) sortKey {
+	fieldMap := map[string]sortKey{
+		"id":        newSortKey("id", "id", cursorInt, order),
+		"name":      newSortKey("name", "name", cursorString, order),
+		"price":     newSortKey("price", "price", cursorFloat, order),
+		"stock":     newSortKey("stock", "stock", cursorInt, order),
+		"createdAt": newSortKey("createdAt", "created_at", cursorTime, order),
+		"updatedAt": newSortKey("updatedAt", "updated_at", cursorTime, order),
 	}
-	if column, ok := fieldMap[field]; ok {
-		return column
+	if key, ok := fieldMap[field]; ok {
+		return key
 	}
-	return "created_at" // default
+	return fieldMap["createdAt"] // default
 }
 
 // invalidateProductCache xóa tất cả cache liên quan đến products
@@ -592,94 +613,11 @@ func MapProductToResponse(product *models.Product) *dto.ProductResponse {
 	}
 }
 
-// GetSearchSuggestions lấy danh sách gợi ý tìm kiếm dựa trên query
-func (s *ProductService) GetSearchSuggestions(query string, language string, limit int) ([]dto.SearchSuggestion, error) {
+// GetPopularSearches lấy danh sách từ khóa được tìm kiếm nhiều nhất trong hours giờ gần đây
+func (s *ProductService) GetPopularSearches(hours int, limit int) ([]dto.PopularSearch, error) {
 	if limit <= 0 || limit > 20 {
 		limit = 10
 	}
 
-	query = strings.TrimSpace(query)
-	if query == "" {
-		return []dto.SearchSuggestion{}, nil
-	}
-
-	var suggestions []dto.SearchSuggestion
-
-	// Tìm sản phẩm phù hợp
-	var products []models.Product
-	nameField := "name"
-	if language == "en" {
-		nameField = "name_en"
-	}
-
-	database.DB.Where("is_active = ? AND ? ILIKE ?", true, nameField+" LIKE ?", "%"+query+"%").
-		Limit(limit / 2).
-		Find(&products)
-
-	// Thêm product suggestions
-	for _, product := range products {
-		productName := product.Name
-		if language == "en" && product.NameEn != nil {
-			productName = *product.NameEn
-		}
-
-		suggestions = append(suggestions, dto.SearchSuggestion{
-			Text:  productName,
-			Type:  "product",
-			Count: 1, // TODO: có thể update thêm count field
-		})
-	}
-
-	// Tìm danh mục phù hợp
-	var categories []models.Category
-	database.DB.Where("is_active = ? AND name ILIKE ?", true, "%"+query+"%").
-		Limit(limit / 2).
-		Find(&categories)
-
-	// Thêm category suggestions
-	for _, category := range categories {
-		suggestions = append(suggestions, dto.SearchSuggestion{
-			Text:  category.Name,
-			Type:  "category",
-			Count: 1, // TODO: có thể update thêm count field
-		})
-	}
-
-	// Giới hạn tổng số suggestions
-	if len(suggestions) > limit {
-		suggestions = suggestions[:limit]
-	}
-
-	return suggestions, nil
-}
-
-// GetPopularSearches lấy danh sách từ khóa tìm kiếm phổ biến
-func (s *ProductService) GetPopularSearches(language string, limit int) ([]dto.PopularSearch, error) {
-	if limit <= 0 || limit > 20 {
-		limit = 10
-	}
-
-	var searches []dto.PopularSearch
-
-	// Lấy tất cả sản phẩm active
-	var products []models.Product
-	database.DB.Where("is_active = ?", true).
-		Order("sold DESC").
-		Limit(limit).
-		Find(&products)
-
-	// Tạo popular search list từ products
-	for _, product := range products {
-		productName := product.Name
-		if language == "en" && product.NameEn != nil {
-			productName = *product.NameEn
-		}
-
-		searches = append(searches, dto.PopularSearch{
-			Text:  productName,
-			Count: product.Sold,
-		})
-	}
-
-	return searches, nil
+	return s.searchAnalytics.Trending(hours, limit)
 }
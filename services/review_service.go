@@ -0,0 +1,319 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"math"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ReviewService struct {
+	productService *ProductService
+}
+
+func NewReviewService() *ReviewService {
+	return &ReviewService{
+		productService: NewProductService(),
+	}
+}
+
+// ListByProduct lấy danh sách đánh giá của sản phẩm (phân trang, có thể lọc theo số sao)
+func (s *ReviewService) ListByProduct(productID uint, rating *int, page, limit int) (*dto.ReviewPaginationResponse, error) {
+	if page < 1 {
+		page = 1
+	}
+	if limit < 1 || limit > 100 {
+		limit = 10
+	}
+
+	var product models.Product
+	if err := database.DB.Select("id", "rating", "review_count").First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("không tìm thấy sản phẩm với ID %d", productID)
+		}
+		return nil, errors.New("không thể lấy sản phẩm")
+	}
+
+	// Phân bố số sao (trên toàn bộ đánh giá của sản phẩm)
+	var buckets []struct {
+		Rating int
+		Count  int64
+	}
+	if err := database.DB.Model(&models.Review{}).
+		Select("rating, COUNT(*) AS count").
+		Where("product_id = ?", productID).
+		Group("rating").
+		Scan(&buckets).Error; err != nil {
+		return nil, errors.New("không thể thống kê đánh giá")
+	}
+	distribution := map[int]int64{1: 0, 2: 0, 3: 0, 4: 0, 5: 0}
+	var reviewCount int64
+	for _, bucket := range buckets {
+		distribution[bucket.Rating] = bucket.Count
+		reviewCount += bucket.Count
+	}
+
+	query := database.DB.Model(&models.Review{}).Where("product_id = ?", productID)
+	if rating != nil {
+		query = query.Where("rating = ?", *rating)
+	}
+
+	var total int64
+	if err := query.Count(&total).Error; err != nil {
+		return nil, errors.New("không thể đếm số lượng đánh giá")
+	}
+
+	var reviews []models.Review
+	if err := query.Preload("User").
+		Order("is_verified DESC, created_at DESC").
+		Offset((page - 1) * limit).
+		Limit(limit).
+		Find(&reviews).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách đánh giá")
+	}
+
+	responses := make([]dto.ReviewResponse, len(reviews))
+	for i := range reviews {
+		responses[i] = *mapReviewToResponse(&reviews[i])
+	}
+
+	return &dto.ReviewPaginationResponse{
+		Data:               responses,
+		Total:              total,
+		Page:               page,
+		Limit:              limit,
+		TotalPages:         int(math.Ceil(float64(total) / float64(limit))),
+		AverageRating:      product.Rating,
+		ReviewCount:        reviewCount,
+		RatingDistribution: distribution,
+	}, nil
+}
+
+// Create tạo đánh giá cho sản phẩm
+// Mỗi user chỉ được đánh giá một sản phẩm một lần; tự động đánh dấu "đã mua hàng" nếu có đơn đã giao
+func (s *ReviewService) Create(userID, productID uint, req dto.CreateReviewRequest) (*dto.ReviewResponse, error) {
+	var review models.Review
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		product, err := s.lockProduct(tx, productID)
+		if err != nil {
+			return err
+		}
+		if !product.IsActive {
+			return errors.New("sản phẩm không tồn tại hoặc không khả dụng")
+		}
+
+		var existing int64
+		if err := tx.Model(&models.Review{}).
+			Where("user_id = ? AND product_id = ?", userID, productID).
+			Count(&existing).Error; err != nil {
+			return err
+		}
+		if existing > 0 {
+			return errors.New("bạn đã đánh giá sản phẩm này. Vui lòng sửa đánh giá cũ")
+		}
+
+		orderID, err := s.findDeliveredOrderID(tx, userID, productID)
+		if err != nil {
+			return err
+		}
+
+		review = models.Review{
+			Rating:     req.Rating,
+			Comment:    s.normalizeComment(req.Comment),
+			Images:     req.Images,
+			IsVerified: orderID != nil,
+			UserID:     userID,
+			ProductID:  productID,
+			OrderID:    orderID,
+		}
+		if err := tx.Create(&review).Error; err != nil {
+			return errors.New("không thể tạo đánh giá")
+		}
+
+		return s.recalculateProductRating(tx, productID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.productService.invalidateProductCacheByID(productID)
+
+	database.DB.Preload("User").First(&review, review.ID)
+	return mapReviewToResponse(&review), nil
+}
+
+// Update sửa đánh giá (chỉ chủ đánh giá)
+func (s *ReviewService) Update(userID, reviewID uint, req dto.UpdateReviewRequest) (*dto.ReviewResponse, error) {
+	var review models.Review
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Where("id = ? AND user_id = ?", reviewID, userID).First(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("không tìm thấy đánh giá")
+			}
+			return err
+		}
+
+		if _, err := s.lockProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+
+		if req.Rating != nil {
+			review.Rating = *req.Rating
+		}
+		if req.Comment != nil {
+			review.Comment = s.normalizeComment(req.Comment)
+		}
+		if req.Images != nil {
+			review.Images = req.Images
+		}
+
+		// Cập nhật lại trạng thái "đã mua hàng" (đơn có thể đã được giao sau khi đánh giá)
+		if !review.IsVerified {
+			orderID, err := s.findDeliveredOrderID(tx, userID, review.ProductID)
+			if err != nil {
+				return err
+			}
+			review.IsVerified = orderID != nil
+			review.OrderID = orderID
+		}
+
+		if err := tx.Save(&review).Error; err != nil {
+			return errors.New("không thể cập nhật đánh giá")
+		}
+
+		return s.recalculateProductRating(tx, review.ProductID)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	s.productService.invalidateProductCacheByID(review.ProductID)
+
+	database.DB.Preload("User").First(&review, review.ID)
+	return mapReviewToResponse(&review), nil
+}
+
+// Delete xóa đánh giá (chủ đánh giá hoặc admin)
+func (s *ReviewService) Delete(userID, reviewID uint, isAdmin bool) error {
+	var review models.Review
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		query := tx.Where("id = ?", reviewID)
+		if !isAdmin {
+			query = query.Where("user_id = ?", userID)
+		}
+		if err := query.First(&review).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("không tìm thấy đánh giá")
+			}
+			return err
+		}
+
+		if _, err := s.lockProduct(tx, review.ProductID); err != nil {
+			return err
+		}
+
+		if err := tx.Delete(&review).Error; err != nil {
+			return errors.New("không thể xóa đánh giá")
+		}
+
+		return s.recalculateProductRating(tx, review.ProductID)
+	})
+	if err != nil {
+		return err
+	}
+
+	s.productService.invalidateProductCacheByID(review.ProductID)
+	return nil
+}
+
+// lockProduct khóa dòng product để các lần tính lại rating không ghi đè lên nhau
+func (s *ReviewService) lockProduct(tx *gorm.DB, productID uint) (*models.Product, error) {
+	var product models.Product
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&product, productID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("không tìm thấy sản phẩm với ID %d", productID)
+		}
+		return nil, err
+	}
+	return &product, nil
+}
+
+// findDeliveredOrderID tìm đơn hàng đã giao gần nhất của user có chứa sản phẩm
+func (s *ReviewService) findDeliveredOrderID(tx *gorm.DB, userID, productID uint) (*uint, error) {
+	var orderIDs []uint
+	if err := tx.Model(&models.OrderItem{}).
+		Joins("JOIN orders ON orders.id = order_items.order_id AND orders.deleted_at IS NULL").
+		Where("orders.user_id = ? AND orders.status = ? AND order_items.product_id = ?", userID, models.OrderStatusDelivered, productID).
+		Order("orders.created_at DESC").
+		Limit(1).
+		Pluck("orders.id", &orderIDs).Error; err != nil {
+		return nil, err
+	}
+	if len(orderIDs) == 0 {
+		return nil, nil
+	}
+	return &orderIDs[0], nil
+}
+
+// recalculateProductRating tính lại Product.Rating và ReviewCount từ bảng reviews
+func (s *ReviewService) recalculateProductRating(tx *gorm.DB, productID uint) error {
+	var stats struct {
+		Average float64
+		Count   int
+	}
+	if err := tx.Model(&models.Review{}).
+		Select("COALESCE(AVG(rating), 0) AS average, COUNT(*) AS count").
+		Where("product_id = ?", productID).
+		Scan(&stats).Error; err != nil {
+		return errors.New("không thể tính điểm đánh giá")
+	}
+
+	// Làm tròn 1 chữ số thập phân (vd: 4.3)
+	rating := math.Round(stats.Average*10) / 10
+	if err := tx.Model(&models.Product{}).Where("id = ?", productID).Updates(map[string]interface{}{
+		"rating":       rating,
+		"review_count": stats.Count,
+	}).Error; err != nil {
+		return errors.New("không thể cập nhật điểm đánh giá")
+	}
+	return nil
+}
+
+func (s *ReviewService) normalizeComment(comment *string) *string {
+	if comment == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*comment)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
+
+// Helper function để map Review sang ReviewResponse
+func mapReviewToResponse(review *models.Review) *dto.ReviewResponse {
+	return &dto.ReviewResponse{
+		ID:         review.ID,
+		Rating:     review.Rating,
+		Comment:    review.Comment,
+		Images:     review.Images,
+		IsVerified: review.IsVerified,
+		UserID:     review.UserID,
+		UserName:   review.User.Name,
+		UserAvatar: review.User.Avatar,
+		ProductID:  review.ProductID,
+		OrderID:    review.OrderID,
+		CreatedAt:  review.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:  review.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
@@ -0,0 +1,397 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+)
+
+// rolePermissionCacheTTL là thời gian giữ quyền của role trong bộ nhớ
+// Sửa role trên instance này có hiệu lực ngay, các instance khác chậm tối đa bằng TTL
+const rolePermissionCacheTTL = 30 * time.Second
+
+var roleNamePattern = regexp.MustCompile(`^[a-z][a-z0-9_]{1,49}$`)
+
+// ErrRoleNotFound - Role không tồn tại
+var ErrRoleNotFound = errors.New("không tìm thấy role")
+
+// rolePermissionCache lưu quyền theo role (dùng chung cho cả process, middleware gọi mỗi request)
+type rolePermissionCache struct {
+	mu      sync.RWMutex
+	entries map[string]rolePermissionEntry
+}
+
+type rolePermissionEntry struct {
+	permissions map[string]bool
+	loadedAt    time.Time
+}
+
+var rolePermissions = &rolePermissionCache{entries: make(map[string]rolePermissionEntry)}
+
+func (c *rolePermissionCache) get(role string) (map[string]bool, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[role]
+	if !ok || time.Since(entry.loadedAt) > rolePermissionCacheTTL {
+		return nil, false
+	}
+	return entry.permissions, true
+}
+
+func (c *rolePermissionCache) set(role string, permissions map[string]bool) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries[role] = rolePermissionEntry{permissions: permissions, loadedAt: time.Now()}
+}
+
+func (c *rolePermissionCache) clear() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.entries = make(map[string]rolePermissionEntry)
+}
+
+// RoleService quản lý role, quyền và kiểm tra quyền của user
+type RoleService struct{}
+
+func NewRoleService() *RoleService {
+	return &RoleService{}
+}
+
+// Permissions lấy tập quyền của role (role không tồn tại → không có quyền nào)
+func (s *RoleService) Permissions(roleName string) (map[string]bool, error) {
+	if permissions, ok := rolePermissions.get(roleName); ok {
+		return permissions, nil
+	}
+
+	var codes []string
+	if err := database.DB.Table("role_permissions").
+		Select("permissions.code").
+		Joins("JOIN roles ON roles.id = role_permissions.role_id").
+		Joins("JOIN permissions ON permissions.id = role_permissions.permission_id").
+		Where("roles.name = ?", roleName).
+		Pluck("permissions.code", &codes).Error; err != nil {
+		return nil, errors.New("không thể lấy quyền của role")
+	}
+
+	permissions := make(map[string]bool, len(codes))
+	for _, code := range codes {
+		permissions[code] = true
+	}
+	rolePermissions.set(roleName, permissions)
+	return permissions, nil
+}
+
+// HasPermission kiểm tra role có quyền không
+func (s *RoleService) HasPermission(roleName, permission string) (bool, error) {
+	permissions, err := s.Permissions(roleName)
+	if err != nil {
+		return false, err
+	}
+	return permissions[permission], nil
+}
+
+// IsStaff kiểm tra role có quyền quản trị nào không (admin và nhân viên)
+func (s *RoleService) IsStaff(roleName string) (bool, error) {
+	permissions, err := s.Permissions(roleName)
+	if err != nil {
+		return false, err
+	}
+	return len(permissions) > 0, nil
+}
+
+// CanManageUser kiểm tra người thao tác có được sửa tài khoản target không
+// Tài khoản nhân viên (role có quyền) chỉ được sửa bởi người có quyền role:manage, tránh leo thang quyền
+func (s *RoleService) CanManageUser(actorRole string, targetUserID uint) (bool, error) {
+	canManageRoles, err := s.HasPermission(actorRole, models.PermissionRoleManage)
+	if err != nil {
+		return false, err
+	}
+	if canManageRoles {
+		return true, nil
+	}
+
+	var target models.User
+	if err := database.DB.Select("id", "role").Where("id = ?", targetUserID).First(&target).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return true, nil // Để handler trả 404 như bình thường
+		}
+		return false, err
+	}
+
+	isStaff, err := s.IsStaff(target.Role)
+	if err != nil {
+		return false, err
+	}
+	return !isStaff, nil
+}
+
+// ValidateRole kiểm tra role có tồn tại không
+func (s *RoleService) ValidateRole(roleName string) error {
+	var count int64
+	if err := database.DB.Model(&models.Role{}).Where("name = ?", roleName).Count(&count).Error; err != nil {
+		return errors.New("không thể kiểm tra role")
+	}
+	if count == 0 {
+		return fmt.Errorf("role %q không tồn tại", roleName)
+	}
+	return nil
+}
+
+// ListPermissions lấy danh sách tất cả quyền
+func (s *RoleService) ListPermissions() ([]dto.PermissionResponse, error) {
+	var permissions []models.Permission
+	if err := database.DB.Order("code ASC").Find(&permissions).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách quyền")
+	}
+
+	responses := make([]dto.PermissionResponse, len(permissions))
+	for i, p := range permissions {
+		responses[i] = dto.PermissionResponse{Code: p.Code, Description: p.Description}
+	}
+	return responses, nil
+}
+
+// ListRoles lấy danh sách role kèm quyền và số user
+func (s *RoleService) ListRoles() ([]dto.RoleResponse, error) {
+	var roles []models.Role
+	if err := database.DB.Preload("Permissions").Order("id ASC").Find(&roles).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách role")
+	}
+
+	type roleCount struct {
+		Role  string
+		Count int64
+	}
+	var counts []roleCount
+	if err := database.DB.Model(&models.User{}).
+		Select("role, COUNT(*) AS count").
+		Group("role").
+		Scan(&counts).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách role")
+	}
+	countByRole := make(map[string]int64, len(counts))
+	for _, c := range counts {
+		countByRole[c.Role] = c.Count
+	}
+
+	responses := make([]dto.RoleResponse, len(roles))
+	for i := range roles {
+		responses[i] = toRoleResponse(&roles[i], countByRole[roles[i].Name])
+	}
+	return responses, nil
+}
+
+// CreateRole tạo role mới (vd: nhân viên kho) với danh sách quyền
+func (s *RoleService) CreateRole(req dto.CreateRoleRequest) (*dto.RoleResponse, error) {
+	name := strings.ToLower(strings.TrimSpace(req.Name))
+	if !roleNamePattern.MatchString(name) {
+		return nil, errors.New("tên role chỉ gồm chữ thường, số và dấu gạch dưới, bắt đầu bằng chữ cái")
+	}
+
+	role := models.Role{
+		Name:        name,
+		DisplayName: strings.TrimSpace(req.DisplayName),
+		Description: req.Description,
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var count int64
+		if err := tx.Model(&models.Role{}).Where("name = ?", name).Count(&count).Error; err != nil {
+			return err
+		}
+		if count > 0 {
+			return errors.New("role đã tồn tại")
+		}
+
+		permissions, err := findPermissions(tx, req.Permissions)
+		if err != nil {
+			return err
+		}
+		role.Permissions = permissions
+
+		if err := tx.Create(&role).Error; err != nil {
+			return errors.New("không thể tạo role")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rolePermissions.clear()
+	response := toRoleResponse(&role, 0)
+	return &response, nil
+}
+
+// UpdateRole cập nhật tên hiển thị, mô tả và quyền của role
+func (s *RoleService) UpdateRole(id uint, req dto.UpdateRoleRequest) (*dto.RoleResponse, error) {
+	var role models.Role
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Preload("Permissions").Where("id = ?", id).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+
+		if req.DisplayName != nil {
+			role.DisplayName = strings.TrimSpace(*req.DisplayName)
+		}
+		if req.Description != nil {
+			role.Description = req.Description
+		}
+		if err := tx.Model(&role).Updates(map[string]interface{}{
+			"display_name": role.DisplayName,
+			"description":  role.Description,
+		}).Error; err != nil {
+			return errors.New("không thể cập nhật role")
+		}
+
+		if req.Permissions != nil {
+			// Admin luôn có toàn bộ quyền để không thể tự khóa mình khỏi hệ thống
+			if role.Name == models.RoleAdmin {
+				return errors.New("không thể thay đổi quyền của role admin")
+			}
+			permissions, err := findPermissions(tx, req.Permissions)
+			if err != nil {
+				return err
+			}
+			if err := tx.Model(&role).Association("Permissions").Replace(permissions); err != nil {
+				return errors.New("không thể cập nhật quyền của role")
+			}
+			role.Permissions = permissions
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	rolePermissions.clear()
+
+	var userCount int64
+	database.DB.Model(&models.User{}).Where("role = ?", role.Name).Count(&userCount)
+	response := toRoleResponse(&role, userCount)
+	return &response, nil
+}
+
+// DeleteRole xóa role (không xóa được role hệ thống hoặc role đang có user)
+func (s *RoleService) DeleteRole(id uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var role models.Role
+		if err := tx.Where("id = ?", id).First(&role).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrRoleNotFound
+			}
+			return err
+		}
+		if role.IsSystem {
+			return errors.New("không thể xóa role hệ thống")
+		}
+
+		var userCount int64
+		if err := tx.Model(&models.User{}).Where("role = ?", role.Name).Count(&userCount).Error; err != nil {
+			return err
+		}
+		if userCount > 0 {
+			return fmt.Errorf("role đang được gán cho %d người dùng, vui lòng đổi role của họ trước", userCount)
+		}
+
+		if err := tx.Model(&role).Association("Permissions").Clear(); err != nil {
+			return errors.New("không thể xóa role")
+		}
+		if err := tx.Delete(&role).Error; err != nil {
+			return errors.New("không thể xóa role")
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	rolePermissions.clear()
+	return nil
+}
+
+// AssignRole đổi role của user
+func (s *RoleService) AssignRole(actorID, userID uint, roleName string) (*models.User, error) {
+	roleName = strings.ToLower(strings.TrimSpace(roleName))
+	if actorID == userID {
+		return nil, errors.New("bạn không thể thay đổi role của chính mình. Vui lòng nhờ admin khác thực hiện")
+	}
+	if err := s.ValidateRole(roleName); err != nil {
+		return nil, err
+	}
+
+	var user models.User
+	if err := database.DB.Where("id = ?", userID).First(&user).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("không tìm thấy người dùng")
+		}
+		return nil, err
+	}
+
+	if user.Role != roleName {
+		if err := database.DB.Model(&user).Update("role", roleName).Error; err != nil {
+			return nil, errors.New("không thể cập nhật role")
+		}
+		user.Role = roleName
+
+		if cache.RedisClient != nil {
+			cache.Delete(cache.UserKey(user.ID))
+			cache.Delete(cache.UserProfileKey(user.ID))
+		}
+	}
+	return &user, nil
+}
+
+// findPermissions tìm quyền theo mã, báo lỗi nếu có mã không tồn tại
+func findPermissions(tx *gorm.DB, codes []string) ([]models.Permission, error) {
+	if len(codes) == 0 {
+		return []models.Permission{}, nil
+	}
+
+	var permissions []models.Permission
+	if err := tx.Where("code IN ?", codes).Find(&permissions).Error; err != nil {
+		return nil, err
+	}
+
+	found := make(map[string]bool, len(permissions))
+	for _, p := range permissions {
+		found[p.Code] = true
+	}
+	for _, code := range codes {
+		if !found[code] {
+			return nil, fmt.Errorf("quyền %q không tồn tại", code)
+		}
+	}
+	return permissions, nil
+}
+
+func toRoleResponse(role *models.Role, userCount int64) dto.RoleResponse {
+	permissions := make([]string, len(role.Permissions))
+	for i, p := range role.Permissions {
+		permissions[i] = p.Code
+	}
+	return dto.RoleResponse{
+		ID:          role.ID,
+		Name:        role.Name,
+		DisplayName: role.DisplayName,
+		Description: role.Description,
+		IsSystem:    role.IsSystem,
+		Permissions: permissions,
+		UserCount:   userCount,
+		CreatedAt:   role.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+		UpdatedAt:   role.UpdatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
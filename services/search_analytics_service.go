@@ -0,0 +1,302 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+	"time"
+	"unicode/utf8"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Nguồn của lượt tìm kiếm: gõ gợi ý (từng ký tự) được thống kê riêng để không làm nhiễu từ khóa phổ biến
+const (
+	SearchSourceSearch  = "search"
+	SearchSourceSuggest = "suggest"
+)
+
+const (
+	searchStatsKindCount = "count"
+	searchStatsKindZero  = "zero"
+
+	searchTermMinLength = 2
+	searchTermMaxLength = 100
+	searchStatsMaxHours = 7 * 24
+
+	// searchReportMaxLimit số từ khóa tối đa của báo cáo cho admin
+	searchReportMaxLimit = 100
+	// popularTermsLimit số từ khóa phổ biến dùng làm nguồn gợi ý
+	popularTermsLimit = 200
+
+	// trendingMinSearchers số người tìm khác nhau tối thiểu để một từ khóa được đưa vào danh sách phổ biến
+	trendingMinSearchers = 3
+	// trendingCandidateFactor lấy dư ứng viên (theo số lượt) trước khi lọc theo số người tìm
+	trendingCandidateFactor = 4
+)
+
+// popularTermsCacheTTL thời gian giữ danh sách từ khóa phổ biến dùng cho gợi ý (gọi theo từng lần gõ phím)
+const popularTermsCacheTTL = 5 * time.Minute
+
+var (
+	// Lần dọn thống kê cũ trong database gần nhất (chỉ dùng khi không có Redis)
+	searchStatsPruneMu   sync.Mutex
+	searchStatsLastPrune time.Time
+
+	// Từ khóa phổ biến 7 ngày gần đây, dùng làm nguồn gợi ý tìm kiếm
+	popularTermsMu       sync.Mutex
+	popularTermsCache    []searchTermCount
+	popularTermsLoadedAt time.Time
+)
+
+type SearchAnalyticsService struct{}
+
+func NewSearchAnalyticsService() *SearchAnalyticsService {
+	return &SearchAnalyticsService{}
+}
+
+// Record ghi nhận một lượt tìm kiếm vào Redis (sorted set theo giờ) hoặc bảng search_query_stats nếu không có Redis
+// searcher là IP hoặc user của người tìm: mỗi người chỉ được đếm một lượt cho mỗi từ khóa trong một giờ
+// Lỗi chỉ được log lại, không làm hỏng request tìm kiếm
+func (s *SearchAnalyticsService) Record(source, query string, resultCount int64, searcher string) {
+	term := normalizeSearchTerm(query)
+	if term == "" {
+		return
+	}
+	zeroResult := resultCount == 0
+	now := time.Now().UTC()
+	hour := now.Truncate(time.Hour)
+
+	searcherHash := ""
+	if searcher != "" {
+		searcherHash = hashSearcher(searcher)
+		seenKey := fmt.Sprintf("%s:%s:%s", source, hour.Format("2006010215"), hashSearcher(term+"\x00"+searcherHash))
+		first, err := cache.MarkSearchSeen(seenKey, hour.Add(time.Hour).Sub(now))
+		if err != nil {
+			log.Printf("⚠️  Warning: Không thể kiểm tra lượt tìm kiếm trùng: %v", err)
+		} else if !first {
+			return // Người này đã được đếm cho từ khóa trong giờ hiện tại
+		}
+	}
+
+	if cache.RedisClient != nil {
+		keys := []string{cache.SearchStatsKey(source, searchStatsKindCount, hour)}
+		if zeroResult {
+			keys = append(keys, cache.SearchStatsKey(source, searchStatsKindZero, hour))
+		}
+		if err := cache.IncrementSearchTerm(term, cache.SearchSearchersKey(source, term, hour), searcherHash, keys...); err != nil {
+			log.Printf("⚠️  Warning: Không thể ghi nhận từ khóa tìm kiếm: %v", err)
+		}
+		return
+	}
+
+	stat := models.SearchQueryStat{Term: term, Source: source, Bucket: hour, SearchCount: 1}
+	if zeroResult {
+		stat.ZeroResultCount = 1
+	}
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Clauses(clause.OnConflict{
+			Columns: []clause.Column{{Name: "term"}, {Name: "source"}, {Name: "bucket"}},
+			DoUpdates: clause.Assignments(map[string]interface{}{
+				"search_count":      gorm.Expr("search_query_stats.search_count + ?", stat.SearchCount),
+				"zero_result_count": gorm.Expr("search_query_stats.zero_result_count + ?", stat.ZeroResultCount),
+				"updated_at":        time.Now(),
+			}),
+		}).Create(&stat).Error; err != nil {
+			return err
+		}
+		if searcherHash == "" {
+			return nil
+		}
+		return tx.Clauses(clause.OnConflict{DoNothing: true}).
+			Create(&models.SearchQuerySearcher{Term: term, Source: source, Bucket: hour, SearcherHash: searcherHash}).Error
+	})
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể ghi nhận từ khóa tìm kiếm: %v", err)
+		return
+	}
+
+	s.pruneDatabaseStats(hour)
+}
+
+// Trending lấy các từ khóa được tìm nhiều nhất trong hours giờ gần đây
+func (s *SearchAnalyticsService) Trending(hours, limit int) ([]dto.PopularSearch, error) {
+	stats, err := s.top(SearchSourceSearch, searchStatsKindCount, hours, limit, trendingMinSearchers)
+	if err != nil {
+		return nil, errors.New("không thể lấy từ khóa tìm kiếm phổ biến")
+	}
+
+	searches := make([]dto.PopularSearch, len(stats))
+	for i, stat := range stats {
+		searches[i] = dto.PopularSearch{Text: stat.Term, Count: int(stat.Count)}
+	}
+	return searches, nil
+}
+
+// ZeroResults báo cáo các từ khóa tìm kiếm không có kết quả trong hours giờ gần đây (để bổ sung catalog)
+// limit tối đa searchReportMaxLimit
+func (s *SearchAnalyticsService) ZeroResults(hours, limit int) ([]dto.ZeroResultSearch, error) {
+	if limit > searchReportMaxLimit {
+		limit = searchReportMaxLimit
+	}
+	stats, err := s.top(SearchSourceSearch, searchStatsKindZero, hours, limit, 0)
+	if err != nil {
+		return nil, errors.New("không thể lấy báo cáo từ khóa không có kết quả")
+	}
+
+	searches := make([]dto.ZeroResultSearch, len(stats))
+	for i, stat := range stats {
+		searches[i] = dto.ZeroResultSearch{Term: stat.Term, Count: stat.Count}
+	}
+	return searches, nil
+}
+
+// PopularTerms lấy tối đa popularTermsLimit từ khóa được nhiều người tìm nhất trong 7 ngày (cache trong process) để làm gợi ý
+func (s *SearchAnalyticsService) PopularTerms() ([]searchTermCount, error) {
+	popularTermsMu.Lock()
+	defer popularTermsMu.Unlock()
+
+	if popularTermsCache != nil && time.Since(popularTermsLoadedAt) < popularTermsCacheTTL {
+		return popularTermsCache, nil
+	}
+	terms, err := s.top(SearchSourceSearch, searchStatsKindCount, searchStatsMaxHours, popularTermsLimit, trendingMinSearchers)
+	if err != nil {
+		return nil, err
+	}
+	popularTermsCache, popularTermsLoadedAt = terms, time.Now()
+	return terms, nil
+}
+
+type searchTermCount struct {
+	Term  string
+	Count int64
+}
+
+// top cộng dồn thống kê theo giờ trong window và trả về limit từ khóa cao nhất
+// minSearchers > 0 thì chỉ giữ từ khóa có ít nhất minSearchers người tìm khác nhau (chống một người spam lên danh sách phổ biến)
+func (s *SearchAnalyticsService) top(source, kind string, hours, limit, minSearchers int) ([]searchTermCount, error) {
+	if hours <= 0 || hours > searchStatsMaxHours {
+		hours = 24
+	}
+	if limit <= 0 || limit > popularTermsLimit {
+		limit = 10
+	}
+	now := time.Now().UTC().Truncate(time.Hour)
+	since := now.Add(-time.Duration(hours-1) * time.Hour)
+
+	if cache.RedisClient != nil {
+		keys := make([]string, hours)
+		for i := range keys {
+			keys[i] = cache.SearchStatsKey(source, kind, now.Add(-time.Duration(i)*time.Hour))
+		}
+		candidates := limit
+		if minSearchers > 0 {
+			candidates = limit * trendingCandidateFactor
+		}
+		scores, err := cache.TopSearchTerms(keys, candidates)
+		if err != nil {
+			return nil, err
+		}
+		result := make([]searchTermCount, 0, len(scores))
+		for _, score := range scores {
+			if term, ok := score.Member.(string); ok {
+				result = append(result, searchTermCount{Term: term, Count: int64(score.Score)})
+			}
+		}
+		if minSearchers > 0 {
+			return s.filterBySearchers(source, result, since, limit, minSearchers)
+		}
+		return result, nil
+	}
+
+	column := "search_count"
+	if kind == searchStatsKindZero {
+		column = "zero_result_count"
+	}
+	query := database.DB.Model(&models.SearchQueryStat{}).
+		Select("term, SUM("+column+") AS count").
+		Where("source = ? AND bucket >= ?", source, since).
+		Group("term").
+		Having("SUM(" + column + ") > 0")
+	if minSearchers > 0 {
+		query = query.Having("(SELECT COUNT(DISTINCT searcher_hash) FROM search_query_searchers"+
+			" WHERE search_query_searchers.term = search_query_stats.term"+
+			" AND search_query_searchers.source = ? AND search_query_searchers.bucket >= ?) >= ?", source, since, minSearchers)
+	}
+	result := []searchTermCount{}
+	err := query.Order("count DESC, term").Limit(limit).Scan(&result).Error
+	return result, err
+}
+
+// filterBySearchers giữ lại tối đa limit từ khóa có ít nhất minSearchers người tìm khác nhau từ since (đếm bằng HyperLogLog theo ngày)
+func (s *SearchAnalyticsService) filterBySearchers(source string, terms []searchTermCount, since time.Time, limit, minSearchers int) ([]searchTermCount, error) {
+	keysByTerm := make(map[string][]string, len(terms))
+	for _, term := range terms {
+		for day := since.Truncate(24 * time.Hour); !day.After(time.Now().UTC()); day = day.Add(24 * time.Hour) {
+			keysByTerm[term.Term] = append(keysByTerm[term.Term], cache.SearchSearchersKey(source, term.Term, day))
+		}
+	}
+	searchers, err := cache.CountSearchTermSearchers(keysByTerm)
+	if err != nil {
+		return nil, err
+	}
+
+	result := make([]searchTermCount, 0, limit)
+	for _, term := range terms {
+		if searchers[term.Term] >= int64(minSearchers) {
+			result = append(result, term)
+			if len(result) == limit {
+				break
+			}
+		}
+	}
+	return result, nil
+}
+
+// pruneDatabaseStats xóa thống kê quá hạn trong database, tối đa mỗi giờ một lần
+func (s *SearchAnalyticsService) pruneDatabaseStats(hour time.Time) {
+	searchStatsPruneMu.Lock()
+	if !searchStatsLastPrune.Before(hour) {
+		searchStatsPruneMu.Unlock()
+		return
+	}
+	searchStatsLastPrune = hour
+	searchStatsPruneMu.Unlock()
+
+	cutoff := hour.Add(-cache.SearchStatsRetention)
+	if err := database.DB.Where("bucket < ?", cutoff).Delete(&models.SearchQueryStat{}).Error; err != nil {
+		log.Printf("⚠️  Warning: Không thể dọn thống kê tìm kiếm cũ: %v", err)
+	}
+	if err := database.DB.Where("bucket < ?", cutoff).Delete(&models.SearchQuerySearcher{}).Error; err != nil {
+		log.Printf("⚠️  Warning: Không thể dọn thống kê tìm kiếm cũ: %v", err)
+	}
+}
+
+// hashSearcher hash IP/user của người tìm để không lưu dữ liệu định danh trong thống kê
+func hashSearcher(searcher string) string {
+	sum := sha256.Sum256([]byte(searcher))
+	return hex.EncodeToString(sum[:16])
+}
+
+// normalizeSearchTerm chuẩn hóa từ khóa để gộp thống kê: lowercase, gộp khoảng trắng, cắt độ dài
+// Trả về "" nếu từ khóa quá ngắn để có ý nghĩa
+func normalizeSearchTerm(query string) string {
+	term := strings.Join(strings.Fields(strings.ToLower(query)), " ")
+	if utf8.RuneCountInString(term) < searchTermMinLength {
+		return ""
+	}
+	if utf8.RuneCountInString(term) > searchTermMaxLength {
+		term = strings.TrimSpace(string([]rune(term)[:searchTermMaxLength]))
+	}
+	return term
+}
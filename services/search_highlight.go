@@ -0,0 +1,185 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"golang.org/x/text/unicode/norm"
+)
+
+// foldedText chuỗi đã bỏ dấu + lowercase, giữ vị trí (rune) tương ứng trong chuỗi gốc để tô sáng
+type foldedText struct {
+	runes  []rune
+	origin []int // origin[i] = vị trí rune gốc sinh ra runes[i]
+	length int   // Số rune của chuỗi gốc
+}
+
+// foldForSearch bỏ dấu tiếng Việt (kể cả đ → d) và lowercase từng ký tự, giống f_unaccent(lower(...)) trong Postgres
+func foldForSearch(text string) foldedText {
+	folded := foldedText{}
+	for i, r := range []rune(text) {
+		folded.length = i + 1
+		if r == 'đ' || r == 'Đ' {
+			folded.runes = append(folded.runes, 'd')
+			folded.origin = append(folded.origin, i)
+			continue
+		}
+		for _, d := range norm.NFD.String(string(r)) {
+			if unicode.Is(unicode.Mn, d) {
+				continue // Dấu thanh, dấu mũ... (kể cả dấu rời trong chuỗi đã ở dạng NFD)
+			}
+			folded.runes = append(folded.runes, unicode.ToLower(d))
+			folded.origin = append(folded.origin, i)
+		}
+	}
+	return folded
+}
+
+// span đổi vị trí [start, end) trong chuỗi đã bỏ dấu về vị trí rune trong chuỗi gốc
+func (f foldedText) span(start, end int) (int, int) {
+	originEnd := f.length
+	if end < len(f.origin) {
+		originEnd = f.origin[end]
+	}
+	return f.origin[start], originEnd
+}
+
+// words tách các từ (chữ/số liên tiếp) và trả về vị trí [start, end) của từng từ
+func (f foldedText) words() [][2]int {
+	var words [][2]int
+	start := -1
+	for i, r := range f.runes {
+		isWord := unicode.IsLetter(r) || unicode.IsDigit(r)
+		if isWord && start < 0 {
+			start = i
+		} else if !isWord && start >= 0 {
+			words = append(words, [2]int{start, i})
+			start = -1
+		}
+	}
+	if start >= 0 {
+		words = append(words, [2]int{start, len(f.runes)})
+	}
+	return words
+}
+
+// Mức độ khớp của từ khóa với một chuỗi (càng lớn càng liên quan)
+const (
+	matchNone       = 0
+	matchFuzzy      = 1 // Khớp gần đúng (gõ sai chính tả)
+	matchContains   = 2 // Chứa từ khóa ở giữa từ
+	matchWordPrefix = 3 // Một từ bắt đầu bằng từ khóa
+	matchPrefix     = 4 // Chuỗi bắt đầu bằng từ khóa
+)
+
+// matchHighlight tìm đoạn trong text khớp với query (không phân biệt dấu, chấp nhận gõ sai)
+// Trả về vị trí [start, end) tính theo rune của text gốc và mức độ khớp
+func matchHighlight(text, query string) (start, end, quality int) {
+	folded := foldForSearch(text)
+	foldedQuery := foldForSearch(query)
+	queryWords := foldedQuery.words()
+	if len(queryWords) == 0 || len(folded.runes) == 0 {
+		return 0, 0, matchNone
+	}
+
+	// Gộp các từ của query bằng một khoảng trắng để so khớp nguyên cụm
+	parts := make([]string, len(queryWords))
+	for i, w := range queryWords {
+		parts[i] = string(foldedQuery.runes[w[0]:w[1]])
+	}
+	needle := []rune(strings.Join(parts, " "))
+
+	if index := runeIndex(folded.runes, needle); index >= 0 {
+		start, end = folded.span(index, index+len(needle))
+		switch {
+		case index == 0:
+			return start, end, matchPrefix
+		case !unicode.IsLetter(folded.runes[index-1]) && !unicode.IsDigit(folded.runes[index-1]):
+			return start, end, matchWordPrefix
+		default:
+			return start, end, matchContains
+		}
+	}
+
+	// Không khớp nguyên cụm → so từng từ của query với từng từ trong text, chấp nhận sai vài ký tự
+	textWords := folded.words()
+	first, last := -1, -1
+	for _, part := range parts {
+		best := -1
+		for i, w := range textWords {
+			if fuzzyWordMatch(folded.runes[w[0]:w[1]], []rune(part)) {
+				best = i
+				break
+			}
+		}
+		if best < 0 {
+			return 0, 0, matchNone
+		}
+		if first < 0 || best < first {
+			first = best
+		}
+		if best > last {
+			last = best
+		}
+	}
+	start, end = folded.span(textWords[first][0], textWords[last][1])
+	return start, end, matchFuzzy
+}
+
+// fuzzyWordMatch so từ trong text với từ người dùng gõ (có thể chưa gõ xong nên so với phần đầu của từ)
+func fuzzyWordMatch(word, typed []rune) bool {
+	maxTypos := 1
+	switch {
+	case len(typed) <= 2:
+		return len(word) >= len(typed) && string(word[:len(typed)]) == string(typed)
+	case len(typed) >= 7:
+		maxTypos = 2
+	}
+
+	// Thử phần đầu của từ dài bằng từ đã gõ (±1 ký tự cho trường hợp gõ thiếu/thừa)
+	for n := len(typed) - 1; n <= len(typed)+1; n++ {
+		if n <= 0 || n > len(word) {
+			continue
+		}
+		if editDistance(word[:n], typed) <= maxTypos {
+			return true
+		}
+	}
+	return false
+}
+
+// editDistance khoảng cách chỉnh sửa giữa hai chuỗi: thêm/xóa/thay một ký tự hoặc đảo hai ký tự liền nhau
+// (optimal string alignment - lỗi gõ đảo chữ như "thaoi" chỉ tính là một lỗi)
+func editDistance(a, b []rune) int {
+	rows := make([][]int, len(a)+1)
+	for i := range rows {
+		rows[i] = make([]int, len(b)+1)
+		rows[i][0] = i
+	}
+	for j := range rows[0] {
+		rows[0][j] = j
+	}
+	for i := 1; i <= len(a); i++ {
+		for j := 1; j <= len(b); j++ {
+			cost := 1
+			if a[i-1] == b[j-1] {
+				cost = 0
+			}
+			rows[i][j] = min(rows[i-1][j]+1, rows[i][j-1]+1, rows[i-1][j-1]+cost)
+			if i > 1 && j > 1 && a[i-1] == b[j-2] && a[i-2] == b[j-1] {
+				rows[i][j] = min(rows[i][j], rows[i-2][j-2]+1)
+			}
+		}
+	}
+	return rows[len(a)][len(b)]
+}
+
+// runeIndex vị trí đầu tiên của needle trong haystack (-1 nếu không có)
+func runeIndex(haystack, needle []rune) int {
+	for i := 0; i+len(needle) <= len(haystack); i++ {
+		if string(haystack[i:i+len(needle)]) == string(needle) {
+			return i
+		}
+	}
+	return -1
+}
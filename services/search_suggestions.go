@@ -0,0 +1,223 @@
+package services
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+)
+
+// Loại gợi ý tìm kiếm
+const (
+	SuggestionTypeQuery    = "query"    // Từ khóa được nhiều người tìm
+	SuggestionTypeCategory = "category" // Danh mục
+	SuggestionTypeProduct  = "product"  // Sản phẩm
+)
+
+// suggestionSimilarityThreshold ngưỡng word_similarity cho toán tử <% (mặc định của pg_trgm là 0.6, quá chặt với lỗi gõ)
+const suggestionSimilarityThreshold = 0.4
+
+// suggestionNameScoreSQL chấm điểm một cột tên: khớp đầu chuỗi > khớp đầu một từ, cộng độ giống trigram
+const suggestionNameScoreSQL = `(CASE WHEN f_unaccent(lower(coalesce(%[1]s, ''))) LIKE f_unaccent(@prefix) THEN 2` +
+	` WHEN f_unaccent(lower(coalesce(%[1]s, ''))) LIKE f_unaccent(@wordPrefix) THEN 1 ELSE 0 END` +
+	` + word_similarity(f_unaccent(@term), f_unaccent(lower(coalesce(%[1]s, '')))))`
+
+// suggestionRow bản ghi ứng viên (sản phẩm/danh mục) lấy từ database
+type suggestionRow struct {
+	ID     uint
+	Name   string
+	NameEn *string
+	Count  int64
+}
+
+// rankedSuggestion gợi ý kèm mức độ khớp để sắp xếp trong cùng loại
+type rankedSuggestion struct {
+	suggestion dto.SearchSuggestion
+	quality    int
+}
+
+// GetSearchSuggestions gợi ý từ khóa phổ biến, danh mục và sản phẩm theo từ khóa đang gõ
+// Không phân biệt dấu, chấp nhận gõ sai (trigram) và trả về vị trí cần tô sáng trong mỗi gợi ý
+func (s *ProductService) GetSearchSuggestions(query string, language string, limit int, searcher string) ([]dto.SearchSuggestion, error) {
+	if limit <= 0 || limit > 20 {
+		limit = 10
+	}
+
+	term := normalizeSearchTerm(query)
+	if term == "" || len(foldForSearch(term).words()) == 0 {
+		return []dto.SearchSuggestion{}, nil
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	args := map[string]interface{}{
+		"term":       term,
+		"prefix":     escaper.Replace(term) + "%",
+		"wordPrefix": "% " + escaper.Replace(term) + "%",
+	}
+
+	var products, categories []suggestionRow
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// SET LOCAL chỉ có hiệu lực trong transaction này
+		if err := tx.Exec(fmt.Sprintf("SET LOCAL pg_trgm.word_similarity_threshold = %v", suggestionSimilarityThreshold)).Error; err != nil {
+			return err
+		}
+
+		if err := matchSuggestionNames(tx.Model(&models.Product{}), args).
+			Select("id, name, name_en, "+suggestionScoreSQL()+" AS score", args).
+			Order("score DESC, sold DESC, id").
+			Limit(limit).
+			Scan(&products).Error; err != nil {
+			return err
+		}
+
+		return matchSuggestionNames(tx.Model(&models.Category{}), args).
+			Select("id, name, name_en, "+suggestionScoreSQL()+" AS score, "+
+				"(SELECT COUNT(*) FROM products WHERE products.category_id = categories.id"+
+				" AND products.is_active = true AND products.deleted_at IS NULL) AS count", args).
+			Order("score DESC, id").
+			Limit(limit).
+			Scan(&categories).Error
+	})
+	if err != nil {
+		return nil, errors.New("không thể lấy gợi ý tìm kiếm")
+	}
+
+	// Từ khóa phổ biến chỉ là phần phụ, lỗi thì vẫn trả về gợi ý sản phẩm/danh mục
+	var queries []rankedSuggestion
+	popularTerms, err := s.searchAnalytics.PopularTerms()
+	if err != nil {
+		log.Printf("⚠️  Warning: Không thể lấy từ khóa phổ biến cho gợi ý: %v", err)
+	}
+	for _, popular := range popularTerms {
+		if popular.Term == term {
+			continue // Không gợi ý lại đúng từ khóa đang gõ
+		}
+		if suggestion, quality := highlightSuggestion(popular.Term, nil, language, term); quality > matchNone {
+			suggestion.Type = SuggestionTypeQuery
+			suggestion.Count = int(popular.Count)
+			queries = append(queries, rankedSuggestion{suggestion: suggestion, quality: quality})
+		}
+	}
+
+	suggestions := mergeSuggestions(limit,
+		queries,
+		rankSuggestionRows(categories, SuggestionTypeCategory, language, term),
+		rankSuggestionRows(products, SuggestionTypeProduct, language, term),
+	)
+
+	s.searchAnalytics.Record(SearchSourceSuggest, query, int64(len(suggestions)), searcher)
+
+	return suggestions, nil
+}
+
+// matchSuggestionNames lọc bản ghi đang hoạt động có tên (vi/en) bắt đầu bằng từ khóa, có một từ bắt đầu bằng từ khóa
+// hoặc giống từ khóa theo trigram (dùng được index idx_<bảng>_name_trgm / idx_<bảng>_name_en_trgm)
+func matchSuggestionNames(query *gorm.DB, args map[string]interface{}) *gorm.DB {
+	conditions := make([]string, 0, 6)
+	for _, column := range []string{"name", "name_en"} {
+		conditions = append(conditions,
+			"f_unaccent(lower("+column+")) LIKE f_unaccent(@prefix)",
+			"f_unaccent(lower("+column+")) LIKE f_unaccent(@wordPrefix)",
+			"f_unaccent(@term) <% f_unaccent(lower("+column+"))",
+		)
+	}
+	return query.Where("is_active = ?", true).Where("("+strings.Join(conditions, " OR ")+")", args)
+}
+
+// suggestionScoreSQL điểm của bản ghi là điểm cao nhất giữa tên tiếng Việt và tiếng Anh
+func suggestionScoreSQL() string {
+	return "GREATEST(" + fmt.Sprintf(suggestionNameScoreSQL, "name") + ", " + fmt.Sprintf(suggestionNameScoreSQL, "name_en") + ")"
+}
+
+// rankSuggestionRows tạo gợi ý từ bản ghi database, bỏ những bản ghi không tô sáng được (trigram khớp quá lỏng)
+func rankSuggestionRows(rows []suggestionRow, suggestionType, language, term string) []rankedSuggestion {
+	ranked := make([]rankedSuggestion, 0, len(rows))
+	for _, row := range rows {
+		suggestion, quality := highlightSuggestion(row.Name, row.NameEn, language, term)
+		if quality == matchNone {
+			continue
+		}
+		id := row.ID
+		suggestion.ID = &id
+		suggestion.Type = suggestionType
+		suggestion.Count = int(row.Count)
+		ranked = append(ranked, rankedSuggestion{suggestion: suggestion, quality: quality})
+	}
+	return ranked
+}
+
+// highlightSuggestion chọn tên hiển thị theo ngôn ngữ và tính đoạn khớp với từ khóa
+// Nếu từ khóa chỉ khớp với tên ở ngôn ngữ còn lại thì hiển thị tên đó để phần tô sáng luôn đúng với text
+func highlightSuggestion(name string, nameEn *string, language, term string) (dto.SearchSuggestion, int) {
+	names := []string{name}
+	if nameEn != nil && *nameEn != "" {
+		if language == "en" {
+			names = []string{*nameEn, name}
+		} else {
+			names = append(names, *nameEn)
+		}
+	}
+
+	for _, text := range names {
+		if start, end, quality := matchHighlight(text, term); quality > matchNone {
+			return dto.SearchSuggestion{
+				Text:      text,
+				Highlight: &dto.SuggestionHighlight{Start: start, End: end},
+			}, quality
+		}
+	}
+	return dto.SearchSuggestion{Text: names[0]}, matchNone
+}
+
+// mergeSuggestions ghép gợi ý theo thứ tự: từ khóa phổ biến, danh mục (mỗi loại tối đa 1/3 limit), sản phẩm lấp phần còn lại
+// Loại nào thiếu thì phần dư được nhường cho các loại khác
+func mergeSuggestions(limit int, groups ...[]rankedSuggestion) []dto.SearchSuggestion {
+	for i, group := range groups {
+		// Khớp tốt hơn lên trước, cùng mức thì giữ thứ tự gốc (số lượt tìm / điểm database)
+		sort.SliceStable(group, func(a, b int) bool { return group[a].quality > group[b].quality })
+
+		// Bỏ trùng text trong cùng loại (vd. hai sản phẩm cùng tên)
+		seen := make(map[string]bool, len(group))
+		unique := group[:0]
+		for _, ranked := range group {
+			key := string(foldForSearch(ranked.suggestion.Text).runes)
+			if !seen[key] {
+				seen[key] = true
+				unique = append(unique, ranked)
+			}
+		}
+		groups[i] = unique
+	}
+
+	// Phân bổ số lượng: các loại trước tối đa limit/3, loại cuối lấy phần còn lại, sau đó chia lại phần dư
+	quotas := make([]int, len(groups))
+	remaining := limit
+	for i, group := range groups {
+		quota := limit / 3
+		if i == len(groups)-1 {
+			quota = remaining
+		}
+		quotas[i] = min(quota, len(group), remaining)
+		remaining -= quotas[i]
+	}
+	for i, group := range groups {
+		extra := min(len(group)-quotas[i], remaining)
+		quotas[i] += extra
+		remaining -= extra
+	}
+
+	suggestions := make([]dto.SearchSuggestion, 0, limit-remaining)
+	for i, group := range groups {
+		for _, ranked := range group[:quotas[i]] {
+			suggestions = append(suggestions, ranked.suggestion)
+		}
+	}
+	return suggestions
+}
@@ -0,0 +1,286 @@
+package services
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"errors"
+	"log"
+	"strings"
+	"time"
+
+	"ecommerce-be/cache"
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+	"ecommerce-be/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Lý do thu hồi phiên đăng nhập
+const (
+	SessionRevokedLogout        = "logout"
+	SessionRevokedLogoutAll     = "logout_all"
+	SessionRevokedReuseDetected = "reuse_detected"
+	SessionRevokedDeactivated   = "deactivated"
+	SessionRevokedPasswordReset = "password_reset"
+	SessionRevokedEmailChanged  = "email_changed"
+)
+
+// ErrRefreshTokenReused - Refresh token đã được rotate bị dùng lại (có thể đã bị đánh cắp)
+var ErrRefreshTokenReused = errors.New("refresh token đã được sử dụng. Phiên đăng nhập đã bị thu hồi, vui lòng đăng nhập lại")
+
+// ErrSessionNotFound - Phiên đăng nhập không tồn tại hoặc đã bị thu hồi
+var ErrSessionNotFound = errors.New("không tìm thấy phiên đăng nhập")
+
+type SessionService struct{}
+
+func NewSessionService() *SessionService {
+	return &SessionService{}
+}
+
+// Create tạo phiên đăng nhập mới và cấp access/refresh token cho phiên đó
+func (s *SessionService) Create(user *models.User, client dto.ClientInfo) (string, string, error) {
+	var accessToken, refreshToken string
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		// token_hash là unique → đặt giá trị tạm cho tới khi có token thật (token cần session ID)
+		placeholder, err := generateRandomHex(32)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		session := models.UserSession{
+			UserID:     user.ID,
+			TokenHash:  placeholder,
+			DeviceName: normalizeDeviceName(client.DeviceName),
+			IPAddress:  client.IPAddress,
+			UserAgent:  client.UserAgent,
+			LastUsedAt: now,
+			ExpiresAt:  now.Add(utils.RefreshTokenTTL),
+		}
+		if err := tx.Create(&session).Error; err != nil {
+			return err
+		}
+
+		accessToken, refreshToken, err = utils.GenerateTokens(user.ID, user.Email, session.ID)
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&session).Update("token_hash", hashRefreshToken(refreshToken)).Error
+	})
+	if err != nil {
+		return "", "", errors.New("không thể tạo phiên đăng nhập")
+	}
+
+	return accessToken, refreshToken, nil
+}
+
+// Rotate đổi refresh token lấy cặp token mới, rotate ngay trên dòng session
+// Nếu token hợp lệ về chữ ký nhưng không phải token hiện tại của phiên (đã bị rotate trước đó)
+// thì coi như bị đánh cắp → thu hồi toàn bộ phiên
+func (s *SessionService) Rotate(refreshToken string, client dto.ClientInfo) (*models.User, string, string, error) {
+	claims, err := utils.ValidateToken(refreshToken)
+	if err != nil || claims.TokenType != utils.TokenTypeRefresh || claims.SessionID == 0 {
+		return nil, "", "", errors.New("refresh token không hợp lệ hoặc đã hết hạn")
+	}
+
+	var user models.User
+	var accessToken, newRefreshToken string
+	reused := false
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.UserSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ?", claims.SessionID, claims.UserID).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("refresh token không hợp lệ")
+			}
+			return err
+		}
+
+		if session.RevokedAt != nil || time.Now().After(session.ExpiresAt) {
+			return errors.New("phiên đăng nhập đã hết hạn hoặc đã bị thu hồi")
+		}
+
+		if session.TokenHash != hashRefreshToken(refreshToken) {
+			// Token cũ bị dùng lại → thu hồi phiên (commit transaction rồi mới trả lỗi)
+			reused = true
+			return s.revoke(tx, &session, SessionRevokedReuseDetected)
+		}
+
+		if err := tx.Where("id = ?", claims.UserID).First(&user).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("refresh token không hợp lệ")
+			}
+			return err
+		}
+		if !user.IsActive {
+			return errors.New("tài khoản đã bị vô hiệu hóa")
+		}
+
+		accessToken, newRefreshToken, err = utils.GenerateTokens(user.ID, user.Email, session.ID)
+		if err != nil {
+			return errors.New("không thể tạo token")
+		}
+
+		if err := tx.Model(&session).Updates(map[string]interface{}{
+			"token_hash":     hashRefreshToken(newRefreshToken),
+			"rotation_count": gorm.Expr("rotation_count + 1"),
+			"last_used_at":   time.Now(),
+			"ip_address":     client.IPAddress,
+			"user_agent":     client.UserAgent,
+		}).Error; err != nil {
+			return errors.New("không thể lưu refresh token")
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, "", "", err
+	}
+	if reused {
+		denySessionTokens(claims.SessionID)
+		return nil, "", "", ErrRefreshTokenReused
+	}
+
+	return &user, accessToken, newRefreshToken, nil
+}
+
+// List lấy các phiên đăng nhập còn hiệu lực của user (mới dùng gần nhất lên đầu)
+func (s *SessionService) List(userID, currentSessionID uint) ([]dto.SessionResponse, error) {
+	var sessions []models.UserSession
+	if err := database.DB.Where("user_id = ? AND revoked_at IS NULL AND expires_at > ?", userID, time.Now()).
+		Order("last_used_at DESC").
+		Find(&sessions).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách phiên đăng nhập")
+	}
+
+	responses := make([]dto.SessionResponse, len(sessions))
+	for i, session := range sessions {
+		responses[i] = dto.SessionResponse{
+			ID:         session.ID,
+			DeviceName: session.DeviceName,
+			IPAddress:  session.IPAddress,
+			UserAgent:  session.UserAgent,
+			IsCurrent:  session.ID == currentSessionID,
+			CreatedAt:  session.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+			LastUsedAt: session.LastUsedAt.Format("2006-01-02T15:04:05Z07:00"),
+			ExpiresAt:  session.ExpiresAt.Format("2006-01-02T15:04:05Z07:00"),
+		}
+	}
+	return responses, nil
+}
+
+// Revoke thu hồi một phiên đăng nhập của user
+// Access token của phiên bị chặn ngay qua denylist (không chờ hết hạn)
+func (s *SessionService) Revoke(userID, sessionID uint) error {
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var session models.UserSession
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("id = ? AND user_id = ? AND revoked_at IS NULL", sessionID, userID).
+			First(&session).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return ErrSessionNotFound
+			}
+			return err
+		}
+		return s.revoke(tx, &session, SessionRevokedLogout)
+	})
+	if err != nil {
+		return err
+	}
+
+	denySessionTokens(sessionID)
+	return nil
+}
+
+// RevokeAll thu hồi tất cả phiên đăng nhập của user (đăng xuất khỏi mọi thiết bị)
+// Trả về số phiên đã thu hồi
+func (s *SessionService) RevokeAll(userID uint, reason string) (int64, error) {
+	now := time.Now()
+	result := database.DB.Model(&models.UserSession{}).
+		Where("user_id = ? AND revoked_at IS NULL", userID).
+		Updates(map[string]interface{}{
+			"revoked_at":     now,
+			"revoked_reason": reason,
+		})
+	if result.Error != nil {
+		return 0, errors.New("không thể thu hồi phiên đăng nhập")
+	}
+
+	// Chặn mọi access token đã cấp cho user tới thời điểm này
+	if err := cache.DenyUserTokensIssuedBefore(userID, now, utils.AccessTokenTTL); err != nil {
+		log.Printf("⚠️  Warning: Không thể thu hồi access token của user %d: %v", userID, err)
+	}
+	return result.RowsAffected, nil
+}
+
+// RevokeAllExcept thu hồi mọi phiên đăng nhập của user trừ phiên hiện tại (vd: sau khi đổi email)
+// Access token của các phiên bị thu hồi bị chặn theo phiên để token của phiên hiện tại vẫn dùng được
+func (s *SessionService) RevokeAllExcept(userID, currentSessionID uint, reason string) (int64, error) {
+	var sessionIDs []uint
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := tx.Model(&models.UserSession{}).
+			Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ? AND id <> ? AND revoked_at IS NULL", userID, currentSessionID).
+			Pluck("id", &sessionIDs).Error; err != nil {
+			return err
+		}
+		if len(sessionIDs) == 0 {
+			return nil
+		}
+		return tx.Model(&models.UserSession{}).
+			Where("id IN ?", sessionIDs).
+			Updates(map[string]interface{}{
+				"revoked_at":     time.Now(),
+				"revoked_reason": reason,
+			}).Error
+	})
+	if err != nil {
+		return 0, errors.New("không thể thu hồi phiên đăng nhập")
+	}
+
+	for _, sessionID := range sessionIDs {
+		denySessionTokens(sessionID)
+	}
+	return int64(len(sessionIDs)), nil
+}
+
+func (s *SessionService) revoke(tx *gorm.DB, session *models.UserSession, reason string) error {
+	now := time.Now()
+	if err := tx.Model(session).Updates(map[string]interface{}{
+		"revoked_at":     now,
+		"revoked_reason": reason,
+	}).Error; err != nil {
+		return errors.New("không thể thu hồi phiên đăng nhập")
+	}
+	return nil
+}
+
+// denySessionTokens chặn các access token còn hạn của phiên đã bị thu hồi
+func denySessionTokens(sessionID uint) {
+	if err := cache.DenySession(sessionID, utils.AccessTokenTTL); err != nil {
+		log.Printf("⚠️  Warning: Không thể thu hồi access token của phiên %d: %v", sessionID, err)
+	}
+}
+
+// hashRefreshToken - chỉ lưu hash của refresh token trong database
+func hashRefreshToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+func normalizeDeviceName(name *string) *string {
+	if name == nil {
+		return nil
+	}
+	trimmed := strings.TrimSpace(*name)
+	if trimmed == "" {
+		return nil
+	}
+	return &trimmed
+}
@@ -0,0 +1,64 @@
+package services
+
+import (
+	"strings"
+	"unicode"
+
+	"ecommerce-be/database"
+
+	"gorm.io/gorm"
+)
+
+// SortByRelevance sắp xếp theo độ liên quan với từ khóa tìm kiếm (mặc định khi có từ khóa)
+const SortByRelevance = "relevance"
+
+// textSearch tìm kiếm full-text không phân biệt dấu trên Postgres, dùng chung cho products và categories
+// (bảng cần có các cột name, name_en, description, description_en và index tạo bởi database.SetupFullTextSearch)
+type textSearch struct {
+	term    string // Từ khóa đã lowercase, dùng để tính độ giống với tên
+	tsQuery string // Dạng "điện:* & thoại:*" - mỗi từ khớp theo tiền tố
+	pattern string // Dạng "%điện thoại%" - khớp một phần tên như trước đây
+}
+
+// newTextSearch tạo điều kiện tìm kiếm từ từ khóa người dùng nhập, trả về nil nếu từ khóa rỗng
+func newTextSearch(raw string) *textSearch {
+	term := strings.ToLower(strings.TrimSpace(raw))
+
+	// Chỉ giữ chữ và số để từ khóa không phá cú pháp tsquery (&, |, !, :...)
+	words := strings.FieldsFunc(term, func(r rune) bool {
+		return !unicode.IsLetter(r) && !unicode.IsDigit(r)
+	})
+	if len(words) == 0 {
+		return nil
+	}
+	for i, word := range words {
+		words[i] = word + ":*"
+	}
+
+	escaper := strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+	return &textSearch{
+		term:    term,
+		tsQuery: strings.Join(words, " & "),
+		pattern: "%" + escaper.Replace(term) + "%",
+	}
+}
+
+// apply lọc các bản ghi có tên/mô tả khớp từ khóa (bỏ dấu cả hai phía)
+func (t *textSearch) apply(query *gorm.DB) *gorm.DB {
+	return query.Where(
+		"("+database.SearchDocumentSQL+" @@ to_tsquery('simple', f_unaccent(?))"+
+			" OR f_unaccent(lower(name)) LIKE f_unaccent(?)"+
+			" OR f_unaccent(lower(name_en)) LIKE f_unaccent(?))",
+		t.tsQuery, t.pattern, t.pattern,
+	)
+}
+
+// relevanceKey sắp xếp theo điểm full-text cộng độ giống (trigram) giữa tên và từ khóa
+func (t *textSearch) relevanceKey(sortOrder string) sortKey {
+	key := newSortKey(SortByRelevance,
+		"(ts_rank("+database.SearchDocumentSQL+", to_tsquery('simple', f_unaccent(?)))"+
+			" + GREATEST(similarity(f_unaccent(lower(name)), f_unaccent(?)), similarity(f_unaccent(lower(coalesce(name_en, ''))), f_unaccent(?))))",
+		cursorFloat, sortOrder)
+	key.Vars = []interface{}{t.tsQuery, t.term, t.term}
+	return key
+}
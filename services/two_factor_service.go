@@ -0,0 +1,417 @@
+package services
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"strings"
+	"time"
+
+	"ecommerce-be/config"
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+	"ecommerce-be/utils"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// recoveryCodeCount là số mã khôi phục được cấp mỗi lần
+const recoveryCodeCount = 10
+
+// ErrInvalidTwoFactorCode - Mã TOTP hoặc mã khôi phục không đúng
+var ErrInvalidTwoFactorCode = errors.New("mã xác thực không hợp lệ")
+
+// TwoFactorService quản lý xác thực 2 lớp bằng TOTP (Google Authenticator, Authy...)
+type TwoFactorService struct {
+	issuer              string
+	key                 []byte // Khóa AES-256 dùng để mã hóa TOTP secret trong database
+	roleService         *RoleService
+	loginAttemptService *LoginAttemptService
+}
+
+func NewTwoFactorService() *TwoFactorService {
+	// Băm khóa đã giải mã (>= 32 byte) về đúng 32 byte cho AES-256
+	key := sha256.Sum256(utils.TwoFactorKey())
+	return &TwoFactorService{
+		issuer:              getEnv("TWO_FACTOR_ISSUER", "Ecommerce"),
+		key:                 key[:],
+		roleService:         NewRoleService(),
+		loginAttemptService: NewLoginAttemptService(),
+	}
+}
+
+// IsRequired kiểm tra user có bị bắt buộc bật 2FA không (ADMIN_REQUIRE_2FA=true và là admin/nhân viên có quyền quản trị)
+func (s *TwoFactorService) IsRequired(user *models.User) bool {
+	if config.AppConfig == nil || !config.AppConfig.AdminRequireTwoFactor {
+		return false
+	}
+	isStaff, err := s.roleService.IsStaff(user.Role)
+	if err != nil {
+		return true // Không kiểm tra được quyền → coi như bắt buộc cho an toàn
+	}
+	return isStaff
+}
+
+// IsEnabled kiểm tra user đã bật 2FA chưa
+func (s *TwoFactorService) IsEnabled(userID uint) (bool, error) {
+	var count int64
+	if err := database.DB.Model(&models.UserTwoFactor{}).
+		Where("user_id = ? AND enabled_at IS NOT NULL", userID).
+		Count(&count).Error; err != nil {
+		return false, errors.New("không thể kiểm tra xác thực 2 lớp")
+	}
+	return count > 0, nil
+}
+
+// Challenge trả về bước xác thực 2 lớp cần làm sau khi nhập đúng mật khẩu (nil nếu không cần)
+func (s *TwoFactorService) Challenge(user *models.User) (*dto.TwoFactorChallenge, error) {
+	enabled, err := s.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if !enabled && !s.IsRequired(user) {
+		return nil, nil
+	}
+
+	token, err := utils.GenerateTwoFactorToken(user.ID, user.Email)
+	if err != nil {
+		return nil, errors.New("không thể tạo token xác thực 2 lớp")
+	}
+	return &dto.TwoFactorChallenge{
+		RequiresTwoFactor:      enabled,
+		RequiresTwoFactorSetup: !enabled,
+		TwoFactorToken:         token,
+	}, nil
+}
+
+// Status lấy trạng thái 2FA của user
+func (s *TwoFactorService) Status(user *models.User) (*dto.TwoFactorStatusResponse, error) {
+	response := &dto.TwoFactorStatusResponse{Required: s.IsRequired(user)}
+
+	var twoFactor models.UserTwoFactor
+	if err := database.DB.Where("user_id = ?", user.ID).First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return response, nil
+		}
+		return nil, errors.New("không thể lấy trạng thái xác thực 2 lớp")
+	}
+
+	if twoFactor.EnabledAt == nil {
+		response.Pending = true
+		return response, nil
+	}
+
+	enabledAt := twoFactor.EnabledAt.Format("2006-01-02T15:04:05Z07:00")
+	response.Enabled = true
+	response.EnabledAt = &enabledAt
+	if err := database.DB.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND used_at IS NULL", user.ID).
+		Count(&response.RecoveryCodesRemaining).Error; err != nil {
+		return nil, errors.New("không thể lấy trạng thái xác thực 2 lớp")
+	}
+	return response, nil
+}
+
+// Setup tạo secret mới (chưa bật) và trả về URI để hiển thị QR code
+// Gọi lại khi chưa xác nhận sẽ thay secret cũ
+func (s *TwoFactorService) Setup(user *models.User) (*dto.TwoFactorSetupResponse, error) {
+	enabled, err := s.IsEnabled(user.ID)
+	if err != nil {
+		return nil, err
+	}
+	if enabled {
+		return nil, errors.New("xác thực 2 lớp đã được bật")
+	}
+
+	secret, err := utils.GenerateTOTPSecret()
+	if err != nil {
+		return nil, errors.New("không thể tạo secret")
+	}
+	encrypted, err := s.encrypt(secret)
+	if err != nil {
+		return nil, errors.New("không thể mã hóa secret")
+	}
+
+	twoFactor := models.UserTwoFactor{
+		UserID:          user.ID,
+		EncryptedSecret: encrypted,
+	}
+	if err := database.DB.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}},
+		DoUpdates: clause.Assignments(map[string]interface{}{"encrypted_secret": encrypted, "last_used_step": 0, "updated_at": time.Now()}),
+	}).Create(&twoFactor).Error; err != nil {
+		return nil, errors.New("không thể lưu cấu hình xác thực 2 lớp")
+	}
+
+	return &dto.TwoFactorSetupResponse{
+		Secret:          secret,
+		ProvisioningURI: utils.TOTPProvisioningURI(s.issuer, user.Email, secret),
+		Issuer:          s.issuer,
+		Account:         user.Email,
+	}, nil
+}
+
+// Enable xác nhận mã TOTP đầu tiên để bật 2FA, trả về mã khôi phục (chỉ hiển thị một lần)
+func (s *TwoFactorService) Enable(user *models.User, code string) ([]string, error) {
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		var twoFactor models.UserTwoFactor
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("user_id = ?", user.ID).
+			First(&twoFactor).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return errors.New("chưa thiết lập xác thực 2 lớp")
+			}
+			return err
+		}
+		if twoFactor.EnabledAt != nil {
+			return errors.New("xác thực 2 lớp đã được bật")
+		}
+
+		step, err := s.validateTOTP(&twoFactor, code)
+		if err != nil {
+			return err
+		}
+
+		now := time.Now()
+		if err := tx.Model(&twoFactor).Updates(map[string]interface{}{
+			"enabled_at":     now,
+			"last_used_step": step,
+		}).Error; err != nil {
+			return errors.New("không thể bật xác thực 2 lớp")
+		}
+
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+	return codes, nil
+}
+
+// Verify kiểm tra mã TOTP hoặc mã khôi phục của user đã bật 2FA
+// Mã TOTP đã dùng và mã khôi phục đã dùng đều không dùng lại được
+func (s *TwoFactorService) Verify(userID uint, code string) error {
+	return database.DB.Transaction(func(tx *gorm.DB) error {
+		return s.verify(tx, userID, code)
+	})
+}
+
+// Disable tắt 2FA (cần mã TOTP hoặc mã khôi phục). Không cho tắt khi bị bắt buộc
+// Nhập sai mã được tính vào khóa đăng nhập như ở bước login 2FA
+func (s *TwoFactorService) Disable(user *models.User, code string, client dto.ClientInfo) error {
+	if s.IsRequired(user) {
+		return errors.New("xác thực 2 lớp là bắt buộc với tài khoản quản trị")
+	}
+	if err := s.loginAttemptService.CheckLocked(user.Email, client.IPAddress); err != nil {
+		return err
+	}
+
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(tx, user.ID, code); err != nil {
+			return err
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+			return errors.New("không thể tắt xác thực 2 lớp")
+		}
+		if err := tx.Where("user_id = ?", user.ID).Delete(&models.UserTwoFactor{}).Error; err != nil {
+			return errors.New("không thể tắt xác thực 2 lớp")
+		}
+		return nil
+	})
+	if err != nil {
+		return s.verifyFailed(user.Email, client.IPAddress, err)
+	}
+	s.loginAttemptService.RegisterSuccess(user.Email)
+	return nil
+}
+
+// RegenerateRecoveryCodes hủy toàn bộ mã khôi phục cũ và cấp bộ mới (cần mã TOTP)
+// Nhập sai mã được tính vào khóa đăng nhập như ở bước login 2FA
+func (s *TwoFactorService) RegenerateRecoveryCodes(user *models.User, code string, client dto.ClientInfo) ([]string, error) {
+	if !isTOTPCode(code) {
+		return nil, errors.New("vui lòng nhập mã từ ứng dụng xác thực")
+	}
+	if err := s.loginAttemptService.CheckLocked(user.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	var codes []string
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.verify(tx, user.ID, code); err != nil {
+			return err
+		}
+
+		var err error
+		codes, err = s.replaceRecoveryCodes(tx, user.ID)
+		return err
+	})
+	if err != nil {
+		return nil, s.verifyFailed(user.Email, client.IPAddress, err)
+	}
+	s.loginAttemptService.RegisterSuccess(user.Email)
+	return codes, nil
+}
+
+// verifyFailed ghi nhận lần nhập sai mã 2FA (ErrInvalidTwoFactorCode); trả về lỗi khóa nếu vượt ngưỡng
+func (s *TwoFactorService) verifyFailed(email, ip string, err error) error {
+	if !errors.Is(err, ErrInvalidTwoFactorCode) {
+		return err
+	}
+	if lockErr := s.loginAttemptService.RegisterFailure(email, ip); lockErr != nil {
+		return lockErr
+	}
+	return err
+}
+
+func (s *TwoFactorService) verify(tx *gorm.DB, userID uint, code string) error {
+	var twoFactor models.UserTwoFactor
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("user_id = ? AND enabled_at IS NOT NULL", userID).
+		First(&twoFactor).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return errors.New("xác thực 2 lớp chưa được bật")
+		}
+		return err
+	}
+
+	if isTOTPCode(code) {
+		step, err := s.validateTOTP(&twoFactor, code)
+		if err != nil {
+			return err
+		}
+		if step <= twoFactor.LastUsedStep {
+			return ErrInvalidTwoFactorCode // Mã đã được dùng
+		}
+		return tx.Model(&twoFactor).Update("last_used_step", step).Error
+	}
+
+	// Mã khôi phục: so sánh theo hash, mỗi mã dùng một lần
+	result := tx.Model(&models.UserRecoveryCode{}).
+		Where("user_id = ? AND code_hash = ? AND used_at IS NULL", userID, hashRecoveryCode(code)).
+		Update("used_at", time.Now())
+	if result.Error != nil {
+		return result.Error
+	}
+	if result.RowsAffected == 0 {
+		return ErrInvalidTwoFactorCode
+	}
+	return nil
+}
+
+func (s *TwoFactorService) validateTOTP(twoFactor *models.UserTwoFactor, code string) (int64, error) {
+	secret, err := s.decrypt(twoFactor.EncryptedSecret)
+	if err != nil {
+		return 0, errors.New("không thể đọc cấu hình xác thực 2 lớp")
+	}
+	step, ok := utils.ValidateTOTP(secret, code, time.Now())
+	if !ok {
+		return 0, ErrInvalidTwoFactorCode
+	}
+	return step, nil
+}
+
+// replaceRecoveryCodes xóa mã khôi phục cũ và tạo recoveryCodeCount mã mới (dạng xxxxx-xxxxx)
+func (s *TwoFactorService) replaceRecoveryCodes(tx *gorm.DB, userID uint) ([]string, error) {
+	if err := tx.Where("user_id = ?", userID).Delete(&models.UserRecoveryCode{}).Error; err != nil {
+		return nil, errors.New("không thể tạo mã khôi phục")
+	}
+
+	codes := make([]string, recoveryCodeCount)
+	records := make([]models.UserRecoveryCode, recoveryCodeCount)
+	for i := range codes {
+		raw, err := generateRandomHex(5)
+		if err != nil {
+			return nil, errors.New("không thể tạo mã khôi phục")
+		}
+		codes[i] = raw[:5] + "-" + raw[5:]
+		records[i] = models.UserRecoveryCode{UserID: userID, CodeHash: hashRecoveryCode(codes[i])}
+	}
+	if err := tx.Create(&records).Error; err != nil {
+		return nil, errors.New("không thể tạo mã khôi phục")
+	}
+	return codes, nil
+}
+
+func (s *TwoFactorService) encrypt(plaintext string) (string, error) {
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	nonce := make([]byte, gcm.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return "", err
+	}
+	sealed := gcm.Seal(nonce, nonce, []byte(plaintext), nil)
+	return base64.StdEncoding.EncodeToString(sealed), nil
+}
+
+func (s *TwoFactorService) decrypt(ciphertext string) (string, error) {
+	data, err := base64.StdEncoding.DecodeString(ciphertext)
+	if err != nil {
+		return "", err
+	}
+	block, err := aes.NewCipher(s.key)
+	if err != nil {
+		return "", err
+	}
+	gcm, err := cipher.NewGCM(block)
+	if err != nil {
+		return "", err
+	}
+	if len(data) < gcm.NonceSize() {
+		return "", errors.New("ciphertext không hợp lệ")
+	}
+	plaintext, err := gcm.Open(nil, data[:gcm.NonceSize()], data[gcm.NonceSize():], nil)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// twoFactorLoginResponse là response đăng nhập khi còn thiếu bước xác thực 2 lớp
+func twoFactorLoginResponse(challenge *dto.TwoFactorChallenge) *dto.LoginResponse {
+	message := "Vui lòng nhập mã từ ứng dụng xác thực để hoàn tất đăng nhập."
+	if challenge.RequiresTwoFactorSetup {
+		message = "Tài khoản quản trị bắt buộc bật xác thực 2 lớp. Vui lòng thiết lập để tiếp tục."
+	}
+	return &dto.LoginResponse{
+		Success:                true,
+		Message:                message,
+		RequiresTwoFactor:      challenge.RequiresTwoFactor,
+		RequiresTwoFactorSetup: challenge.RequiresTwoFactorSetup,
+		TwoFactorToken:         challenge.TwoFactorToken,
+	}
+}
+
+// isTOTPCode phân biệt mã TOTP (6 chữ số) với mã khôi phục
+func isTOTPCode(code string) bool {
+	code = strings.TrimSpace(code)
+	if len(code) != utils.TOTPDigits {
+		return false
+	}
+	for _, c := range code {
+		if c < '0' || c > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// hashRecoveryCode chuẩn hóa (bỏ khoảng trắng, chữ thường) rồi hash mã khôi phục
+func hashRecoveryCode(code string) string {
+	normalized := strings.ToLower(strings.ReplaceAll(strings.TrimSpace(code), " ", ""))
+	sum := sha256.Sum256([]byte(normalized))
+	return hex.EncodeToString(sum[:])
+}
@@ -3,9 +3,10 @@ package services
 import (
 	"errors"
 	"fmt"
-	"math"
+	"log"
 	"strings"
 
+	"ecommerce-be/cache"
 	"ecommerce-be/database"
 	"ecommerce-be/dto"
 	"ecommerce-be/models"
@@ -14,10 +15,24 @@ import (
 	"gorm.io/gorm"
 )
 
-type UserService struct{}
+type UserService struct {
+	otpService          *OtpService
+	emailService        *EmailService
+	sessionService      *SessionService
+	loginAttemptService *LoginAttemptService
+	roleService         *RoleService
+	twoFactorService    *TwoFactorService
+}
 
 func NewUserService() *UserService {
-	return &UserService{}
+	return &UserService{
+		otpService:          NewOtpService(),
+		emailService:        NewEmailService(),
+		sessionService:      NewSessionService(),
+		loginAttemptService: NewLoginAttemptService(),
+		roleService:         NewRoleService(),
+		twoFactorService:    NewTwoFactorService(),
+	}
 }
 
 // CreateUserByAdmin tạo user bởi admin
@@ -46,9 +61,12 @@ func (s *UserService) CreateUserByAdmin(req dto.CreateUserByAdminRequest) (*mode
 	}
 
 	// Xác định role
-	role := "customer"
+	role := models.RoleCustomer
 	if req.Role != nil {
 		role = *req.Role
+		if err := s.roleService.ValidateRole(role); err != nil {
+			return nil, err
+		}
 	}
 
 	// Tạo user
@@ -173,10 +191,15 @@ func (s *UserService) UpdateUserByAdmin(userID uint, req dto.UpdateUserByAdminRe
 	if req.Gender != nil {
 		user.Gender = req.Gender
 	}
-	if req.Role != nil {
+	if req.Role != nil && *req.Role != user.Role {
+		if err := s.roleService.ValidateRole(*req.Role); err != nil {
+			return nil, err
+		}
 		user.Role = *req.Role
 	}
+	deactivated := false
 	if req.IsActive != nil {
+		deactivated = user.IsActive && !*req.IsActive
 		user.IsActive = *req.IsActive
 	}
 	if req.IsEmailVerified != nil {
@@ -187,9 +210,193 @@ func (s *UserService) UpdateUserByAdmin(userID uint, req dto.UpdateUserByAdminRe
 		return nil, errors.New("không thể cập nhật thông tin")
 	}
 
+	// Tài khoản bị vô hiệu hóa → thu hồi mọi phiên đăng nhập và access token đang dùng
+	if deactivated {
+		if _, err := s.sessionService.RevokeAll(user.ID, SessionRevokedDeactivated); err != nil {
+			return nil, err
+		}
+	}
+
 	return &user, nil
 }
 
+// GetLoginLockout admin xem trạng thái khóa đăng nhập (do nhập sai nhiều lần) của user
+func (s *UserService) GetLoginLockout(userID uint) (*dto.LoginLockoutResponse, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.loginAttemptService.GetLockout(user.Email)
+}
+
+// ClearLoginLockout admin mở khóa đăng nhập cho user
+func (s *UserService) ClearLoginLockout(userID uint) error {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return err
+	}
+	return s.loginAttemptService.ClearLockout(user.Email)
+}
+
+// RequestEmailChange user tự đổi email: xác thực lại bằng mật khẩu (và mã 2FA nếu đã bật) rồi gửi OTP tới email mới
+// Email chỉ được đổi sau khi xác thực OTP, token bị lộ không đủ để chiếm tài khoản qua đổi email
+// Sai mật khẩu/mã 2FA được tính vào khóa đăng nhập như ở bước login
+func (s *UserService) RequestEmailChange(userID uint, req dto.ChangeEmailRequest, client dto.ClientInfo) (*dto.ChangeEmailResponse, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := s.loginAttemptService.CheckLocked(user.Email, client.IPAddress); err != nil {
+		return nil, err
+	}
+
+	if !utils.CheckPassword(req.CurrentPassword, user.Password) {
+		return nil, s.stepUpFailed(user.Email, client.IPAddress, errors.New("mật khẩu hiện tại không đúng"))
+	}
+
+	twoFactorEnabled, err := s.twoFactorService.IsEnabled(user.ID)
+	if err != nil {
+		return nil, errors.New("không thể kiểm tra xác thực 2 lớp")
+	}
+	if twoFactorEnabled {
+		if req.TwoFactorCode == "" {
+			return nil, errors.New("vui lòng nhập mã xác thực 2 lớp")
+		}
+		if err := s.twoFactorService.Verify(user.ID, req.TwoFactorCode); err != nil {
+			if errors.Is(err, ErrInvalidTwoFactorCode) {
+				return nil, s.stepUpFailed(user.Email, client.IPAddress, err)
+			}
+			return nil, err
+		}
+	}
+	s.loginAttemptService.RegisterSuccess(user.Email)
+
+	return s.sendEmailChangeOtp(user, req.NewEmail)
+}
+
+// stepUpFailed ghi nhận một lần xác thực lại thất bại; trả về lỗi khóa nếu vượt ngưỡng
+func (s *UserService) stepUpFailed(email, ip string, err error) error {
+	if lockErr := s.loginAttemptService.RegisterFailure(email, ip); lockErr != nil {
+		return lockErr
+	}
+	return err
+}
+
+// RequestEmailChangeByAdmin admin sửa email giúp user: OTP được gửi tới email mới, user nhập OTP để hoàn tất
+func (s *UserService) RequestEmailChangeByAdmin(userID uint, req dto.ChangeEmailByAdminRequest) (*dto.ChangeEmailResponse, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	return s.sendEmailChangeOtp(user, req.NewEmail)
+}
+
+// sendEmailChangeOtp kiểm tra email mới và gửi OTP xác nhận tới email đó
+func (s *UserService) sendEmailChangeOtp(user *models.User, email string) (*dto.ChangeEmailResponse, error) {
+	// Normalize email
+	newEmail := strings.ToLower(strings.TrimSpace(email))
+	if newEmail == user.Email {
+		return nil, errors.New("email mới phải khác email hiện tại")
+	}
+
+	// Kiểm tra email đã tồn tại chưa (kể cả tài khoản đã xóa mềm vì cột email là unique)
+	var count int64
+	if err := database.DB.Unscoped().Model(&models.User{}).Where("email = ?", newEmail).Count(&count).Error; err != nil {
+		return nil, err
+	}
+	if count > 0 {
+		return nil, errors.New("email đã được sử dụng")
+	}
+
+	// Rate limiting: Kiểm tra cooldown 60 giây
+	if err := s.otpService.CheckCooldown(user.ID, OtpPurposeChangeEmail); err != nil {
+		return nil, err
+	}
+
+	otp, err := s.otpService.IssueForTarget(database.DB, user.ID, OtpPurposeChangeEmail, &newEmail)
+	if err != nil {
+		return nil, err
+	}
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeChangeEmail, user.Email)
+
+	// Gửi OTP tới email mới để chứng minh quyền sở hữu
+	if err := s.emailService.SendEmailChangeOtpEmail(newEmail, otp, user.Name); err != nil {
+		return nil, fmt.Errorf("không thể gửi email OTP: %v", err)
+	}
+
+	return &dto.ChangeEmailResponse{
+		Success: true,
+		Message: "OTP đã được gửi đến email mới. Vui lòng nhập mã OTP để hoàn tất đổi email.",
+		Email:   newEmail,
+	}, nil
+}
+
+// ConfirmEmailChange xác thực OTP và đổi email, thu hồi mọi phiên đăng nhập khác phiên hiện tại rồi thông báo tới email cũ
+func (s *UserService) ConfirmEmailChange(userID, currentSessionID uint, req dto.VerifyChangeEmailRequest) (*models.User, error) {
+	user, err := s.GetProfile(userID)
+	if err != nil {
+		return nil, err
+	}
+	oldEmail := user.Email
+
+	err = database.DB.Transaction(func(tx *gorm.DB) error {
+		otp, err := s.otpService.Consume(tx, user.ID, OtpPurposeChangeEmail, req.OTP)
+		if err != nil {
+			return err
+		}
+		if otp.Target == nil {
+			return ErrInvalidOtp
+		}
+
+		user.Email = *otp.Target
+		user.IsEmailVerified = true // Đã chứng minh sở hữu email mới qua OTP
+		if err := tx.Save(user).Error; err != nil {
+			if strings.Contains(err.Error(), "duplicate key") || strings.Contains(err.Error(), "UNIQUE constraint") {
+				return errors.New("email đã được sử dụng")
+			}
+			return errors.New("không thể cập nhật email")
+		}
+		return nil
+	})
+	if err != nil {
+		if errors.Is(err, ErrInvalidOtp) {
+			// Sai quá nhiều lần → hủy OTP, phải yêu cầu đổi email lại
+			if s.loginAttemptService.RegisterOtpFailure(OtpPurposeChangeEmail, oldEmail) {
+				if err := s.otpService.Invalidate(user.ID, OtpPurposeChangeEmail); err != nil {
+					return nil, errors.New("không thể hủy mã OTP")
+				}
+				return nil, errors.New("bạn đã nhập sai OTP quá nhiều lần. Mã OTP đã bị hủy, vui lòng yêu cầu mã mới")
+			}
+		}
+		return nil, err
+	}
+	s.loginAttemptService.ClearOtpFailures(OtpPurposeChangeEmail, oldEmail)
+
+	s.invalidateUserCache(user.ID)
+
+	// Email là danh tính đăng nhập → đăng xuất các thiết bị khác (kể cả phiên của người đang chiếm tài khoản)
+	if _, err := s.sessionService.RevokeAllExcept(user.ID, currentSessionID, SessionRevokedEmailChanged); err != nil {
+		log.Printf("⚠️  Warning: Không thể thu hồi phiên đăng nhập của user %d sau khi đổi email: %v", user.ID, err)
+	}
+
+	// Thông báo tới email cũ (không chặn việc đổi email nếu gửi thất bại)
+	if err := s.emailService.SendEmailChangedNotification(oldEmail, user.Email, user.Name); err != nil {
+		log.Printf("⚠️  Warning: Không thể gửi thông báo đổi email tới %s: %v", oldEmail, err)
+	}
+
+	return user, nil
+}
+
+// invalidateUserCache xóa cache thông tin user
+func (s *UserService) invalidateUserCache(userID uint) {
+	if cache.RedisClient == nil {
+		return
+	}
+	cache.Delete(cache.UserKey(userID))
+	cache.Delete(cache.UserProfileKey(userID))
+}
+
 // ChangePassword đổi mật khẩu
 func (s *UserService) ChangePassword(userID uint, req dto.ChangePasswordRequest) error {
 	var user models.User
@@ -335,23 +542,36 @@ func (s *UserService) Search(req dto.SearchUserRequest) (*dto.PaginationResponse
 		}
 	}
 
-	// Count total
-	var total int64
-	if err := query.Count(&total).Error; err != nil {
-		return nil, errors.New("không thể đếm số lượng users")
+	// Sort - map field names to database columns
+	key := s.sortKey(sortBy, sortOrder)
+	pagination, err := newPageRequest(key, page, limit, req.Cursor, req.IncludeTotal)
+	if err != nil {
+		return nil, err
 	}
 
-	// Sort - map field names to database columns
-	sortByColumn := s.mapSortFieldToColumn(sortBy)
-	orderBy := fmt.Sprintf("%s %s", sortByColumn, sortOrder)
-	query = query.Order(orderBy)
+	// Count total (tùy chọn - bỏ qua mặc định khi phân trang theo cursor)
+	var total int64
+	if pagination.IncludeTotal {
+		if err := query.Count(&total).Error; err != nil {
+			return nil, errors.New("không thể đếm số lượng users")
+		}
+	}
 
-	// Pagination
-	offset := (page - 1) * limit
+	// Pagination - theo page (offset) hoặc theo cursor (keyset)
 	var users []models.User
-	if err := query.Offset(offset).Limit(limit).Find(&users).Error; err != nil {
+	if err := pagination.paginate(query, key).Find(&users).Error; err != nil {
 		return nil, errors.New("không thể lấy danh sách users")
 	}
+	hasMore := len(users) > limit
+	if hasMore {
+		users = users[:limit]
+	}
+	var nextCursor *string
+	if len(users) > 0 {
+		if nextCursor, err = pagination.next(key, "users", hasMore, users[len(users)-1].ID); err != nil {
+			return nil, errors.New("không thể tạo cursor trang tiếp theo")
+		}
+	}
 
 	// Convert to response
 	userResponses := make([]dto.UserResponse, len(users))
@@ -373,27 +593,28 @@ func (s *UserService) Search(req dto.SearchUserRequest) (*dto.PaginationResponse
 		}
 	}
 
-	totalPages := int(math.Ceil(float64(total) / float64(limit)))
+	totalCount, totalPages := pagination.totals(total)
 
 	return &dto.PaginationResponse{
 		Data:       userResponses,
-		Total:      total,
-		Page:       page,
+		Total:      totalCount,
+		Page:       pagination.Page,
 		Limit:      limit,
 		TotalPages: totalPages,
+		NextCursor: nextCursor,
 	}, nil
 }
 
-// mapSortFieldToColumn map sort field name to database column name
-func (s *UserService) mapSortFieldToColumn(field string) string {
-	fieldMap := map[string]string{
-		"name":      "name",
-		"email":     "email",
-		"createdAt": "created_at",
-		"updatedAt": "updated_at",
+// sortKey map sort field name to database column name (kèm kiểu dữ liệu để tạo cursor)
+func (s *UserService) sortKey(field, order string) sortKey {
+	fieldMap := map[string]sortKey{
+		"name":      newSortKey("name", "name", cursorString, order),
+		"email":     newSortKey("email", "email", cursorString, order),
+		"createdAt": newSortKey("createdAt", "created_at", cursorTime, order),
+		"updatedAt": newSortKey("updatedAt", "updated_at", cursorTime, order),
 	}
-	if column, ok := fieldMap[field]; ok {
-		return column
+	if key, ok := fieldMap[field]; ok {
+		return key
 	}
-	return "created_at" // default
+	return fieldMap["createdAt"] // default
 }
@@ -0,0 +1,163 @@
+package services
+
+import (
+	"errors"
+
+	"ecommerce-be/database"
+	"ecommerce-be/dto"
+	"ecommerce-be/models"
+
+	"gorm.io/gorm"
+)
+
+type WishlistService struct{}
+
+func NewWishlistService() *WishlistService {
+	return &WishlistService{}
+}
+
+// List lấy danh sách sản phẩm yêu thích của user (mới thêm trước)
+func (s *WishlistService) List(userID uint) ([]dto.WishlistItemResponse, error) {
+	var wishlists []models.Wishlist
+	if err := database.DB.Where("user_id = ?", userID).
+		Preload("Product").
+		Order("created_at DESC").
+		Find(&wishlists).Error; err != nil {
+		return nil, errors.New("không thể lấy danh sách yêu thích")
+	}
+
+	items := make([]dto.WishlistItemResponse, 0, len(wishlists))
+	for i := range wishlists {
+		// Bỏ qua sản phẩm đã bị xóa
+		if wishlists[i].Product.ID == 0 {
+			continue
+		}
+		items = append(items, *mapWishlistToResponse(&wishlists[i]))
+	}
+
+	return items, nil
+}
+
+// Add thêm sản phẩm vào wishlist (gọi lại nhiều lần không tạo bản ghi trùng)
+func (s *WishlistService) Add(userID, productID uint) (*dto.WishlistItemResponse, error) {
+	var product models.Product
+	if err := database.DB.Where("id = ? AND is_active = ?", productID, true).First(&product).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, errors.New("sản phẩm không tồn tại hoặc không khả dụng")
+		}
+		return nil, err
+	}
+
+	// idx_user_product là unique index trên cả bản ghi đã xóa mềm → khôi phục nếu có
+	var wishlist models.Wishlist
+	err := database.DB.Unscoped().Where("user_id = ? AND product_id = ?", userID, productID).First(&wishlist).Error
+	switch {
+	case err == nil:
+		if wishlist.DeletedAt.Valid {
+			if err := database.DB.Unscoped().Model(&wishlist).Update("deleted_at", nil).Error; err != nil {
+				return nil, errors.New("không thể thêm sản phẩm vào danh sách yêu thích")
+			}
+		}
+	case errors.Is(err, gorm.ErrRecordNotFound):
+		wishlist = models.Wishlist{
+			UserID:    userID,
+			ProductID: productID,
+		}
+		if err := database.DB.Create(&wishlist).Error; err != nil {
+			// Request song song có thể đã thêm trước
+			if database.DB.Where("user_id = ? AND product_id = ?", userID, productID).First(&wishlist).Error != nil {
+				return nil, errors.New("không thể thêm sản phẩm vào danh sách yêu thích")
+			}
+		}
+	default:
+		return nil, err
+	}
+
+	wishlist.Product = product
+	return mapWishlistToResponse(&wishlist), nil
+}
+
+// Remove xóa sản phẩm khỏi wishlist
+func (s *WishlistService) Remove(userID, productID uint) error {
+	return s.remove(database.DB, userID, productID)
+}
+
+func (s *WishlistService) remove(tx *gorm.DB, userID, productID uint) error {
+	result := tx.Unscoped().
+		Where("user_id = ? AND product_id = ?", userID, productID).
+		Delete(&models.Wishlist{})
+	if result.Error != nil {
+		return errors.New("không thể xóa sản phẩm khỏi danh sách yêu thích")
+	}
+	if result.RowsAffected == 0 {
+		return errors.New("sản phẩm không có trong danh sách yêu thích")
+	}
+	return nil
+}
+
+// MoveToCart chuyển sản phẩm từ wishlist sang giỏ hàng
+// Dùng lại AddToCart để kiểm tra sản phẩm còn bán và đủ tồn kho; xóa khỏi wishlist và thêm vào giỏ trong cùng transaction
+func (s *WishlistService) MoveToCart(userID, productID uint, quantity int) (*dto.CartItemResponse, error) {
+	if quantity < 1 {
+		quantity = 1
+	}
+
+	var cartItem *dto.CartItemResponse
+	err := database.DB.Transaction(func(tx *gorm.DB) error {
+		if err := s.remove(tx, userID, productID); err != nil {
+			return err
+		}
+
+		var err error
+		cartItem, err = addToCart(tx, userID, dto.AddToCartRequest{
+			ProductID: productID,
+			Quantity:  quantity,
+		})
+		return err
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	return cartItem, nil
+}
+
+// MarkWishlisted gắn cờ isWishlisted cho danh sách sản phẩm theo wishlist của user
+func (s *WishlistService) MarkWishlisted(userID uint, products []dto.ProductResponse) error {
+	if len(products) == 0 {
+		return nil
+	}
+
+	productIDs := make([]uint, len(products))
+	for i, product := range products {
+		productIDs[i] = product.ID
+	}
+
+	var wishlistedIDs []uint
+	if err := database.DB.Model(&models.Wishlist{}).
+		Where("user_id = ? AND product_id IN ?", userID, productIDs).
+		Pluck("product_id", &wishlistedIDs).Error; err != nil {
+		return err
+	}
+
+	wishlisted := make(map[uint]bool, len(wishlistedIDs))
+	for _, id := range wishlistedIDs {
+		wishlisted[id] = true
+	}
+	for i := range products {
+		isWishlisted := wishlisted[products[i].ID]
+		products[i].IsWishlisted = &isWishlisted
+	}
+	return nil
+}
+
+// Helper function để map Wishlist sang WishlistItemResponse
+func mapWishlistToResponse(wishlist *models.Wishlist) *dto.WishlistItemResponse {
+	return &dto.WishlistItemResponse{
+		ID:        wishlist.ID,
+		UserID:    wishlist.UserID,
+		ProductID: wishlist.ProductID,
+		Product:   *MapProductToResponse(&wishlist.Product),
+		CreatedAt: wishlist.CreatedAt.Format("2006-01-02T15:04:05Z07:00"),
+	}
+}
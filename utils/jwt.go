@@ -1,57 +1,96 @@
 package utils
 
 import (
+	"crypto/rand"
+	"encoding/hex"
 	"errors"
-	"os"
 	"time"
 
 	"github.com/golang-jwt/jwt/v5"
 )
 
-var jwtSecret = []byte(getJWTSecret())
+const (
+	TokenTypeAccess    = "access"
+	TokenTypeRefresh   = "refresh"
+	TokenTypeTwoFactor = "two_factor" // Token tạm sau bước mật khẩu, chỉ dùng để hoàn tất xác thực 2 lớp
 
-func getJWTSecret() string {
-	secret := os.Getenv("JWT_SECRET")
-	if secret == "" {
-		return "your-secret-key-change-in-production"
-	}
-	return secret
-}
+	// AccessTokenTTL là thời hạn của access token
+	AccessTokenTTL = 15 * time.Minute
+
+	// RefreshTokenTTL là thời hạn của refresh token (cũng là thời hạn của phiên đăng nhập)
+	RefreshTokenTTL = 7 * 24 * time.Hour
+
+	// TwoFactorTokenTTL là thời gian để nhập mã xác thực 2 lớp sau khi nhập đúng mật khẩu
+	TwoFactorTokenTTL = 5 * time.Minute
+)
 
 type Claims struct {
-	UserID uint   `json:"sub"`
-	Email  string `json:"email"`
+	UserID    uint   `json:"sub"`
+	Email     string `json:"email"`
+	SessionID uint   `json:"sid,omitempty"` // ID phiên đăng nhập (user_sessions)
+	TokenType string `json:"typ,omitempty"` // access hoặc refresh
+	// IssuedAtMs thời điểm cấp token tính bằng mili giây (iat chỉ có độ chính xác tới giây)
+	// Denylist theo user so sánh với giá trị này để token cấp ngay sau khi thu hồi, trong cùng một giây, vẫn hợp lệ
+	IssuedAtMs int64 `json:"iat_ms,omitempty"`
 	jwt.RegisteredClaims
 }
 
-// GenerateToken tạo access token và refresh token
-func GenerateTokens(userID uint, email string) (string, string, error) {
+// IssuedAtTime trả về thời điểm cấp token (ưu tiên iat_ms; token cũ chỉ có iat, không có iat thì coi như cấp từ đầu)
+func (c *Claims) IssuedAtTime() time.Time {
+	if c.IssuedAtMs > 0 {
+		return time.UnixMilli(c.IssuedAtMs)
+	}
+	if c.IssuedAt != nil {
+		return c.IssuedAt.Time
+	}
+	return time.Time{}
+}
+
+// GenerateToken tạo access token và refresh token cho một phiên đăng nhập
+func GenerateTokens(userID uint, email string, sessionID uint) (string, string, error) {
 	// Access token - 15 phút
+	// jti dùng để thu hồi token (logout) qua denylist
+	accessJTI, err := generateJTI()
+	if err != nil {
+		return "", "", err
+	}
+	now := time.Now()
 	accessClaims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:     userID,
+		Email:      email,
+		SessionID:  sessionID,
+		TokenType:  TokenTypeAccess,
+		IssuedAtMs: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(15 * time.Minute)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        accessJTI,
+			ExpiresAt: jwt.NewNumericDate(now.Add(AccessTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	accessToken := jwt.NewWithClaims(jwt.SigningMethodHS256, accessClaims)
-	accessTokenString, err := accessToken.SignedString(jwtSecret)
+	accessTokenString, err := signToken(accessClaims)
 	if err != nil {
 		return "", "", err
 	}
 
 	// Refresh token - 7 ngày
+	// jti ngẫu nhiên để mỗi lần rotate sinh ra token khác nhau (kể cả trong cùng một giây)
+	refreshJTI, err := generateJTI()
+	if err != nil {
+		return "", "", err
+	}
 	refreshClaims := &Claims{
-		UserID: userID,
-		Email:  email,
+		UserID:     userID,
+		Email:      email,
+		SessionID:  sessionID,
+		TokenType:  TokenTypeRefresh,
+		IssuedAtMs: now.UnixMilli(),
 		RegisteredClaims: jwt.RegisteredClaims{
-			ExpiresAt: jwt.NewNumericDate(time.Now().Add(7 * 24 * time.Hour)),
-			IssuedAt:  jwt.NewNumericDate(time.Now()),
+			ID:        refreshJTI,
+			ExpiresAt: jwt.NewNumericDate(now.Add(RefreshTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
 		},
 	}
-	refreshToken := jwt.NewWithClaims(jwt.SigningMethodHS256, refreshClaims)
-	refreshTokenString, err := refreshToken.SignedString(jwtSecret)
+	refreshTokenString, err := signToken(refreshClaims)
 	if err != nil {
 		return "", "", err
 	}
@@ -59,14 +98,74 @@ func GenerateTokens(userID uint, email string) (string, string, error) {
 	return accessTokenString, refreshTokenString, nil
 }
 
+// GenerateTwoFactorToken tạo token tạm cho bước xác thực 2 lớp (không dùng được để gọi API)
+func GenerateTwoFactorToken(userID uint, email string) (string, error) {
+	jti, err := generateJTI()
+	if err != nil {
+		return "", err
+	}
+	now := time.Now()
+	claims := &Claims{
+		UserID:     userID,
+		Email:      email,
+		TokenType:  TokenTypeTwoFactor,
+		IssuedAtMs: now.UnixMilli(),
+		RegisteredClaims: jwt.RegisteredClaims{
+			ID:        jti,
+			ExpiresAt: jwt.NewNumericDate(now.Add(TwoFactorTokenTTL)),
+			IssuedAt:  jwt.NewNumericDate(now),
+		},
+	}
+	return signToken(claims)
+}
+
+// generateJTI tạo ID ngẫu nhiên (128 bit) cho token
+func generateJTI() (string, error) {
+	buf := make([]byte, 16)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return hex.EncodeToString(buf), nil
+}
+
+// signToken ký claims bằng khóa ký hiện tại, header có kid để bên xác thực chọn đúng public key
+func signToken(claims *Claims) (string, error) {
+	keys, err := currentJWTKeys()
+	if err != nil {
+		return "", err
+	}
+	token := jwt.NewWithClaims(keys.signing.method, claims)
+	token.Header["kid"] = keys.signing.kid
+	return token.SignedString(keys.signing.private)
+}
+
 // ValidateToken xác thực token và trả về claims
 func ValidateToken(tokenString string) (*Claims, error) {
+	keys, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
 	token, err := jwt.ParseWithClaims(tokenString, &Claims{}, func(token *jwt.Token) (interface{}, error) {
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
+		// Token HS256 cũ (không có kid) chỉ được chấp nhận khi bật JWT_ALLOW_HS256
+		if _, ok := token.Method.(*jwt.SigningMethodHMAC); ok {
+			if keys.legacySecret == nil {
+				return nil, errors.New("invalid signing method")
+			}
+			return keys.legacySecret, nil
+		}
+
+		kid, _ := token.Header["kid"].(string)
+		key, ok := keys.verification[kid]
+		if !ok {
+			return nil, errors.New("unknown key id")
+		}
+		// Thuật toán phải khớp với loại khóa, tránh tấn công đổi alg
+		if token.Method.Alg() != key.method.Alg() {
 			return nil, errors.New("invalid signing method")
 		}
-		return jwtSecret, nil
-	})
+		return key.public, nil
+	}, jwt.WithValidMethods([]string{"RS256", "EdDSA", "HS256"}))
 
 	if err != nil {
 		return nil, err
@@ -78,4 +177,3 @@ func ValidateToken(tokenString string) (*Claims, error) {
 
 	return nil, errors.New("invalid token")
 }
-
@@ -0,0 +1,307 @@
+package utils
+
+import (
+	"crypto"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"crypto/x509"
+	"encoding/base64"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"log"
+	"math/big"
+	"os"
+	"strings"
+	"sync"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// minRSAKeyBits là độ dài tối thiểu của khóa RSA dùng để ký token
+const minRSAKeyBits = 2048
+
+// signingKey là khóa đang dùng để ký token mới
+type signingKey struct {
+	kid     string
+	method  jwt.SigningMethod
+	private crypto.Signer
+}
+
+// verificationKey là public key dùng để xác thực token (gồm khóa hiện tại và các khóa cũ còn hiệu lực khi xoay khóa)
+type verificationKey struct {
+	kid    string
+	method jwt.SigningMethod
+	public crypto.PublicKey
+}
+
+// JWTKeySet gồm khóa ký hiện tại và tất cả khóa xác thực còn hiệu lực
+type JWTKeySet struct {
+	signing      *signingKey
+	verification map[string]*verificationKey
+	legacySecret []byte // Chỉ khác nil khi JWT_ALLOW_HS256=true: vẫn chấp nhận token HS256 cũ trong lúc chuyển đổi
+}
+
+var (
+	jwtKeysMu sync.RWMutex
+	jwtKeys   *JWTKeySet
+)
+
+// LoadJWTKeys đọc cấu hình khóa từ env:
+//   - JWT_SIGNING_KEY_FILE / JWT_SIGNING_KEY: private key PEM (RSA >= 2048 bit hoặc Ed25519) dùng để ký
+//   - JWT_VERIFICATION_KEY_FILES: danh sách file PEM (cách nhau bởi dấu phẩy) của các khóa cũ vẫn được chấp nhận
+//   - JWT_ALLOW_HS256: tạm thời chấp nhận token HS256 ký bằng JWT_SECRET (token phát hành trước khi đổi sang khóa bất đối xứng)
+//
+// Ở production mà không có khóa ký thì trả lỗi để dừng server; ở môi trường dev sẽ sinh khóa Ed25519 tạm (mất khi restart)
+func LoadJWTKeys(production bool) error {
+	keys, err := loadJWTKeySet(production)
+	if err != nil {
+		return err
+	}
+
+	jwtKeysMu.Lock()
+	jwtKeys = keys
+	jwtKeysMu.Unlock()
+	return nil
+}
+
+// currentJWTKeys trả về bộ khóa đã load (tự load theo chế độ dev nếu chưa gọi LoadJWTKeys, vd: trong các tool cmd/)
+func currentJWTKeys() (*JWTKeySet, error) {
+	jwtKeysMu.RLock()
+	keys := jwtKeys
+	jwtKeysMu.RUnlock()
+	if keys != nil {
+		return keys, nil
+	}
+
+	jwtKeysMu.Lock()
+	defer jwtKeysMu.Unlock()
+	if jwtKeys == nil {
+		loaded, err := loadJWTKeySet(false)
+		if err != nil {
+			return nil, err
+		}
+		jwtKeys = loaded
+	}
+	return jwtKeys, nil
+}
+
+func loadJWTKeySet(production bool) (*JWTKeySet, error) {
+	keys := &JWTKeySet{verification: make(map[string]*verificationKey)}
+
+	pemData, source, err := readSigningKeyPEM()
+	if err != nil {
+		return nil, err
+	}
+
+	var signer crypto.Signer
+	if pemData == nil {
+		if production {
+			return nil, errors.New("chưa cấu hình khóa ký JWT (JWT_SIGNING_KEY_FILE hoặc JWT_SIGNING_KEY) ở môi trường production")
+		}
+		_, priv, err := ed25519.GenerateKey(rand.Reader)
+		if err != nil {
+			return nil, fmt.Errorf("không thể sinh khóa ký JWT tạm: %w", err)
+		}
+		signer = priv
+		log.Println("⚠️  Warning: Chưa cấu hình JWT_SIGNING_KEY_FILE, dùng khóa Ed25519 tạm. Token sẽ mất hiệu lực khi restart server.")
+	} else {
+		signer, err = parsePrivateKeyPEM(pemData)
+		if err != nil {
+			return nil, fmt.Errorf("khóa ký JWT (%s) không hợp lệ: %w", source, err)
+		}
+	}
+
+	method, err := signingMethodFor(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	kid, err := jwkThumbprint(signer.Public())
+	if err != nil {
+		return nil, err
+	}
+	keys.signing = &signingKey{kid: kid, method: method, private: signer}
+	keys.verification[kid] = &verificationKey{kid: kid, method: method, public: signer.Public()}
+
+	// Khóa cũ: vẫn xác thực được token đã phát hành cho tới khi hết hạn
+	for _, path := range strings.Split(os.Getenv("JWT_VERIFICATION_KEY_FILES"), ",") {
+		path = strings.TrimSpace(path)
+		if path == "" {
+			continue
+		}
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, fmt.Errorf("không thể đọc khóa xác thực JWT %s: %w", path, err)
+		}
+		public, err := parsePublicKeyPEM(data)
+		if err != nil {
+			return nil, fmt.Errorf("khóa xác thực JWT %s không hợp lệ: %w", path, err)
+		}
+		method, err := signingMethodFor(public)
+		if err != nil {
+			return nil, fmt.Errorf("khóa xác thực JWT %s: %w", path, err)
+		}
+		kid, err := jwkThumbprint(public)
+		if err != nil {
+			return nil, err
+		}
+		if _, exists := keys.verification[kid]; !exists {
+			keys.verification[kid] = &verificationKey{kid: kid, method: method, public: public}
+		}
+	}
+
+	if strings.EqualFold(os.Getenv("JWT_ALLOW_HS256"), "true") {
+		secret := os.Getenv("JWT_SECRET")
+		if secret == "" {
+			return nil, errors.New("JWT_ALLOW_HS256=true nhưng chưa cấu hình JWT_SECRET")
+		}
+		keys.legacySecret = []byte(secret)
+		log.Println("⚠️  Warning: JWT_ALLOW_HS256=true, vẫn chấp nhận token HS256 cũ. Hãy tắt sau khi refresh token cũ hết hạn.")
+	}
+
+	return keys, nil
+}
+
+func readSigningKeyPEM() ([]byte, string, error) {
+	if path := strings.TrimSpace(os.Getenv("JWT_SIGNING_KEY_FILE")); path != "" {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			return nil, "", fmt.Errorf("không thể đọc khóa ký JWT %s: %w", path, err)
+		}
+		return data, path, nil
+	}
+	if value := os.Getenv("JWT_SIGNING_KEY"); value != "" {
+		// Cho phép ghi PEM trên một dòng trong .env với \n
+		return []byte(strings.ReplaceAll(value, `\n`, "\n")), "JWT_SIGNING_KEY", nil
+	}
+	return nil, "", nil
+}
+
+func parsePrivateKeyPEM(data []byte) (crypto.Signer, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("không tìm thấy PEM block")
+	}
+
+	var key interface{}
+	var err error
+	switch block.Type {
+	case "RSA PRIVATE KEY":
+		key, err = x509.ParsePKCS1PrivateKey(block.Bytes)
+	case "PRIVATE KEY":
+		key, err = x509.ParsePKCS8PrivateKey(block.Bytes)
+	default:
+		return nil, fmt.Errorf("loại PEM %q không được hỗ trợ", block.Type)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	switch k := key.(type) {
+	case *rsa.PrivateKey:
+		return k, nil
+	case ed25519.PrivateKey:
+		return k, nil
+	default:
+		return nil, errors.New("chỉ hỗ trợ khóa RSA hoặc Ed25519")
+	}
+}
+
+// parsePublicKeyPEM chấp nhận public key hoặc private key (lấy phần public)
+func parsePublicKeyPEM(data []byte) (crypto.PublicKey, error) {
+	block, _ := pem.Decode(data)
+	if block == nil {
+		return nil, errors.New("không tìm thấy PEM block")
+	}
+
+	switch block.Type {
+	case "PUBLIC KEY":
+		return x509.ParsePKIXPublicKey(block.Bytes)
+	case "RSA PUBLIC KEY":
+		return x509.ParsePKCS1PublicKey(block.Bytes)
+	default:
+		signer, err := parsePrivateKeyPEM(data)
+		if err != nil {
+			return nil, err
+		}
+		return signer.Public(), nil
+	}
+}
+
+func signingMethodFor(public crypto.PublicKey) (jwt.SigningMethod, error) {
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		if k.N.BitLen() < minRSAKeyBits {
+			return nil, fmt.Errorf("khóa RSA phải có ít nhất %d bit", minRSAKeyBits)
+		}
+		return jwt.SigningMethodRS256, nil
+	case ed25519.PublicKey:
+		return jwt.SigningMethodEdDSA, nil
+	default:
+		return nil, errors.New("chỉ hỗ trợ khóa RSA hoặc Ed25519")
+	}
+}
+
+// JWK là public key theo định dạng JSON Web Key (RFC 7517)
+type JWK struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	Alg string `json:"alg"`
+	N   string `json:"n,omitempty"`
+	E   string `json:"e,omitempty"`
+	Crv string `json:"crv,omitempty"`
+	X   string `json:"x,omitempty"`
+}
+
+// PublicJWKS trả về các public key đang được chấp nhận để service khác xác thực token
+func PublicJWKS() ([]JWK, error) {
+	keys, err := currentJWTKeys()
+	if err != nil {
+		return nil, err
+	}
+
+	jwks := make([]JWK, 0, len(keys.verification))
+	// Khóa ký hiện tại đứng đầu
+	jwks = append(jwks, toJWK(keys.verification[keys.signing.kid]))
+	for kid, key := range keys.verification {
+		if kid != keys.signing.kid {
+			jwks = append(jwks, toJWK(key))
+		}
+	}
+	return jwks, nil
+}
+
+func toJWK(key *verificationKey) JWK {
+	jwk := JWK{Kid: key.kid, Use: "sig", Alg: key.method.Alg()}
+	switch k := key.public.(type) {
+	case *rsa.PublicKey:
+		jwk.Kty = "RSA"
+		jwk.N = base64.RawURLEncoding.EncodeToString(k.N.Bytes())
+		jwk.E = base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes())
+	case ed25519.PublicKey:
+		jwk.Kty = "OKP"
+		jwk.Crv = "Ed25519"
+		jwk.X = base64.RawURLEncoding.EncodeToString(k)
+	}
+	return jwk
+}
+
+// jwkThumbprint tính JWK thumbprint (RFC 7638) để làm kid: cùng một khóa luôn có cùng kid, giữa các lần restart và giữa các instance
+func jwkThumbprint(public crypto.PublicKey) (string, error) {
+	var members string
+	switch k := public.(type) {
+	case *rsa.PublicKey:
+		members = fmt.Sprintf(`{"e":%q,"kty":"RSA","n":%q}`,
+			base64.RawURLEncoding.EncodeToString(big.NewInt(int64(k.E)).Bytes()),
+			base64.RawURLEncoding.EncodeToString(k.N.Bytes()))
+	case ed25519.PublicKey:
+		members = fmt.Sprintf(`{"crv":"Ed25519","kty":"OKP","x":%q}`, base64.RawURLEncoding.EncodeToString(k))
+	default:
+		return "", errors.New("chỉ hỗ trợ khóa RSA hoặc Ed25519")
+	}
+	sum := sha256.Sum256([]byte(members))
+	return base64.RawURLEncoding.EncodeToString(sum[:]), nil
+}
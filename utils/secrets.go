@@ -0,0 +1,131 @@
+package utils
+
+import (
+	"crypto/rand"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+	"sync"
+)
+
+// minSecretKeyBytes là độ dài tối thiểu của các secret dùng để hash/mã hóa dữ liệu nhạy cảm
+const minSecretKeyBytes = 32
+
+var (
+	secretsMu           sync.Mutex
+	otpSecret           []byte
+	twoFactorEncryptKey []byte
+)
+
+// LoadSecrets load tất cả khóa bí mật lúc khởi động: khóa ký JWT, khóa hash OTP và khóa mã hóa TOTP secret
+// Ở production thiếu bất kỳ khóa nào thì trả lỗi để dừng server, không bao giờ dùng giá trị mặc định
+func LoadSecrets(production bool) error {
+	if err := LoadJWTKeys(production); err != nil {
+		return fmt.Errorf("khóa ký JWT: %w", err)
+	}
+	if err := LoadOtpSecret(production); err != nil {
+		return err
+	}
+	return LoadTwoFactorKey(production)
+}
+
+// LoadOtpSecret đọc OTP_SECRET - khóa HMAC dùng để hash OTP trước khi lưu database
+// Ở production mà thiếu (hoặc quá ngắn) thì trả lỗi để dừng server; ở môi trường dev sẽ sinh khóa ngẫu nhiên (OTP đã gửi mất hiệu lực khi restart)
+func LoadOtpSecret(production bool) error {
+	secret, err := loadSecretKey("OTP_SECRET", production)
+	if err != nil {
+		return err
+	}
+
+	secretsMu.Lock()
+	otpSecret = secret
+	secretsMu.Unlock()
+	return nil
+}
+
+// OtpSecret trả về khóa HMAC của OTP (tự load theo chế độ dev nếu chưa gọi LoadOtpSecret, vd: trong các tool cmd/)
+func OtpSecret() []byte {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if otpSecret == nil {
+		otpSecret, _ = loadSecretKey("OTP_SECRET", false) // Chế độ dev không bao giờ trả lỗi
+	}
+	return otpSecret
+}
+
+// LoadTwoFactorKey đọc TWO_FACTOR_ENCRYPTION_KEY - khóa mã hóa TOTP secret của user trong database
+// Ở production mà thiếu (hoặc dưới 32 byte sau khi giải mã) thì trả lỗi để dừng server; ở dev sẽ sinh khóa ngẫu nhiên
+// (TOTP đã bật sẽ không giải mã được sau khi restart)
+func LoadTwoFactorKey(production bool) error {
+	key, err := loadSecretKey("TWO_FACTOR_ENCRYPTION_KEY", production)
+	if err != nil {
+		return err
+	}
+
+	secretsMu.Lock()
+	twoFactorEncryptKey = key
+	secretsMu.Unlock()
+	return nil
+}
+
+// TwoFactorKey trả về khóa mã hóa TOTP secret (tự load theo chế độ dev nếu chưa gọi LoadTwoFactorKey)
+func TwoFactorKey() []byte {
+	secretsMu.Lock()
+	defer secretsMu.Unlock()
+	if twoFactorEncryptKey == nil {
+		twoFactorEncryptKey, _ = loadSecretKey("TWO_FACTOR_ENCRYPTION_KEY", false)
+	}
+	return twoFactorEncryptKey
+}
+
+// loadSecretKey đọc secret từ biến môi trường name (xem decodeSecretKey), yêu cầu tối thiểu minSecretKeyBytes byte sau khi giải mã
+func loadSecretKey(name string, production bool) ([]byte, error) {
+	value := strings.TrimSpace(os.Getenv(name))
+	if value == "" {
+		if production {
+			return nil, fmt.Errorf("chưa cấu hình %s ở môi trường production", name)
+		}
+		secret := make([]byte, minSecretKeyBytes)
+		if _, err := rand.Read(secret); err != nil {
+			return nil, fmt.Errorf("không thể sinh %s tạm: %w", name, err)
+		}
+		log.Printf("⚠️  Warning: Chưa cấu hình %s, dùng khóa ngẫu nhiên tạm. Dữ liệu ký/mã hóa bằng khóa này sẽ mất hiệu lực khi restart server.", name)
+		return secret, nil
+	}
+
+	secret, err := decodeSecretKey(value)
+	if err != nil {
+		return nil, fmt.Errorf("%s không hợp lệ: %w", name, err)
+	}
+	if len(secret) < minSecretKeyBytes {
+		if production {
+			return nil, fmt.Errorf("%s phải có ít nhất %d byte (sau khi giải mã base64/hex)", name, minSecretKeyBytes)
+		}
+		log.Printf("⚠️  Warning: %s ngắn hơn %d byte, chỉ chấp nhận ở môi trường dev.", name, minSecretKeyBytes)
+	}
+	return secret, nil
+}
+
+// decodeSecretKey giải mã secret theo tiền tố: "base64:<chuỗi base64 chuẩn>" hoặc "hex:<chuỗi hex>"
+// Không có tiền tố thì dùng nguyên byte của chuỗi (giữ nguyên cách đọc OTP_SECRET trước đây)
+func decodeSecretKey(value string) ([]byte, error) {
+	switch {
+	case strings.HasPrefix(value, "base64:"):
+		decoded, err := base64.StdEncoding.DecodeString(strings.TrimPrefix(value, "base64:"))
+		if err != nil {
+			return nil, fmt.Errorf("giá trị base64 sai định dạng: %w", err)
+		}
+		return decoded, nil
+	case strings.HasPrefix(value, "hex:"):
+		decoded, err := hex.DecodeString(strings.TrimPrefix(value, "hex:"))
+		if err != nil {
+			return nil, fmt.Errorf("giá trị hex sai định dạng: %w", err)
+		}
+		return decoded, nil
+	default:
+		return []byte(value), nil
+	}
+}